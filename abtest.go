@@ -0,0 +1,131 @@
+package cpoker
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+)
+
+// An ABTest randomly assigns hands to one of two evaluators, and
+// tests whether one scores better than the other. The test is
+// sequential: its result is valid whenever it's looked at, so it's safe
+// to stop as soon as it reaches a decision. It uses a mixture sequential
+// probability ratio test on the difference in mean score per hand.
+// It's safe to use from several goroutines, so its results can be
+// reported while hands are being recorded.
+type ABTest struct {
+	Names [2]string
+	Arms  [2]HandEvaluator
+
+	// Alpha is the chance of deciding that one arm is better when
+	// they're really the same.
+	Alpha float64
+
+	// Tau is the size of difference in points per hand the test is
+	// tuned to detect.
+	Tau float64
+
+	mu         sync.Mutex
+	rnd        *rand.Rand
+	hands      [2]int
+	sum, sumSq [2]float64
+	decided    bool
+	winner     int
+	ratio      float64
+}
+
+// NewABTest returns an ABTest of two named evaluators. It uses
+// rnd to assign hands to evaluators, or the global random source if
+// it's nil.
+func NewABTest(rnd *rand.Rand, nameA string, a HandEvaluator, nameB string, b HandEvaluator) *ABTest {
+	return &ABTest{
+		Names: [2]string{nameA, nameB},
+		Arms:  [2]HandEvaluator{a, b},
+		Alpha: 0.05,
+		Tau:   0.1,
+		rnd:   rnd,
+	}
+}
+
+// Assign picks an arm (0 or 1) at random for the next hand.
+func (ab *ABTest) Assign() int {
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+	if ab.rnd == nil {
+		return rand.Intn(2)
+	}
+	return ab.rnd.Intn(2)
+}
+
+// Record records the score of a hand played by an arm, and
+// updates the test.
+func (ab *ABTest) Record(arm int, score float64) {
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+	ab.hands[arm]++
+	ab.sum[arm] += score
+	ab.sumSq[arm] += score * score
+	if ab.decided || ab.hands[0] < 2 || ab.hands[1] < 2 {
+		return
+	}
+	d := ab.mean(0) - ab.mean(1)
+	v := ab.variance(0)/float64(ab.hands[0]) + ab.variance(1)/float64(ab.hands[1])
+	if v <= 0 {
+		return
+	}
+	t2 := ab.Tau * ab.Tau
+	ab.ratio = math.Sqrt(v/(v+t2)) * math.Exp(t2*d*d/(2*v*(v+t2)))
+	if ab.ratio >= 1/ab.Alpha {
+		ab.decided = true
+		ab.winner = 1
+		if d > 0 {
+			ab.winner = 0
+		}
+	}
+}
+
+// Hands returns how many hands have been recorded for an arm.
+func (ab *ABTest) Hands(arm int) int {
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+	return ab.hands[arm]
+}
+
+// Mean returns the mean score per hand of an arm.
+func (ab *ABTest) Mean(arm int) float64 {
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+	return ab.mean(arm)
+}
+
+func (ab *ABTest) mean(arm int) float64 {
+	if ab.hands[arm] == 0 {
+		return 0
+	}
+	return ab.sum[arm] / float64(ab.hands[arm])
+}
+
+func (ab *ABTest) variance(arm int) float64 {
+	n := float64(ab.hands[arm])
+	return (ab.sumSq[arm] - ab.sum[arm]*ab.sum[arm]/n) / (n - 1)
+}
+
+// Decision returns the arm which scores better, if the test has
+// decided.
+func (ab *ABTest) Decision() (winner int, decided bool) {
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+	return ab.winner, ab.decided
+}
+
+func (ab *ABTest) String() string {
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+	r := fmt.Sprintf("%s: %.4f per hand over %d hands; %s: %.4f per hand over %d hands; likelihood ratio %.2f",
+		ab.Names[0], ab.mean(0), ab.hands[0], ab.Names[1], ab.mean(1), ab.hands[1], ab.ratio)
+	if ab.decided {
+		r += "; " + ab.Names[ab.winner] + " is better"
+	}
+	return r
+}
@@ -0,0 +1,42 @@
+package cpoker
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestABTest(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	ab := NewABTest(rnd, "a", MaxProdEvaluator{}, "b", MaxProdEvaluator{})
+	for i := 0; i < 10000; i++ {
+		arm := ab.Assign()
+		score := rnd.NormFloat64() * 2
+		if arm == 1 {
+			score += 0.5
+		}
+		ab.Record(arm, score)
+		if _, ok := ab.Decision(); ok {
+			break
+		}
+	}
+	if winner, ok := ab.Decision(); !ok || winner != 1 {
+		t.Errorf("got decision %d, %v, want arm 1 to be better: %s", winner, ok, ab)
+	}
+
+	same := NewABTest(rnd, "a", MaxProdEvaluator{}, "b", MaxProdEvaluator{})
+	for i := 0; i < 2000; i++ {
+		same.Record(same.Assign(), rnd.NormFloat64()*2)
+	}
+	if _, ok := same.Decision(); ok {
+		t.Errorf("decided between identical arms: %s", same)
+	}
+
+	// A nil rnd uses the global random source.
+	global := NewABTest(nil, "a", MaxProdEvaluator{}, "b", MaxProdEvaluator{})
+	for i := 0; i < 100; i++ {
+		global.Record(global.Assign(), 0)
+	}
+	if global.Hands(0) == 0 || global.Hands(1) == 0 {
+		t.Errorf("a nil rnd assigned %d and %d hands to the arms", global.Hands(0), global.Hands(1))
+	}
+}
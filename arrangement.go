@@ -0,0 +1,188 @@
+package cpoker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// An Arrangement is a legal way of playing 13 cards, along with
+// the eval ranks of its front, middle and back.
+type Arrangement struct {
+	Hand  Hand
+	Ranks [3]int16
+}
+
+// Arrangements returns every legal arrangement of the 13 cards c that
+// isn't dominated by another. One arrangement dominates another if
+// each of its front, middle and back is at least as strong. When
+// several arrangements have the same ranks, only one is returned.
+// Any sensible objective is maximized by one of these arrangements.
+func Arrangements(c []poker.Card) []Arrangement {
+	var maxima []Arrangement
+	fIdx := [3]int{-1, 1, 2}
+	for next3(&fIdx) {
+		front := [3]poker.Card{c[fIdx[0]], c[fIdx[1]], c[fIdx[2]]}
+		ef := poker.Eval3(&front)
+		bIdx := [5]int{-1, -1, 1, 2, 3}
+		for next4(&bIdx) {
+			back, middle := split(c, &fIdx, &bIdx)
+			eb := poker.Eval5(&back)
+			em := poker.Eval5(&middle)
+			if em > eb {
+				em, eb = eb, em
+				middle, back = back, middle
+			}
+			if ef >= em || em == eb {
+				continue
+			}
+			a := Arrangement{Hand{front, middle, back}, [3]int16{ef, em, eb}}
+			dominated := false
+			for i := 0; i < len(maxima); {
+				mr := &maxima[i].Ranks
+				if mr[0] >= ef && mr[1] >= em && mr[2] >= eb {
+					dominated = true
+					break
+				}
+				if mr[0] <= ef && mr[1] <= em && mr[2] <= eb {
+					maxima[i] = maxima[len(maxima)-1]
+					maxima = maxima[:len(maxima)-1]
+					continue
+				}
+				i++
+			}
+			if !dominated {
+				maxima = append(maxima, a)
+			}
+		}
+	}
+	return maxima
+}
+
+// CheckHand returns an error if h isn't a legal way to play the
+// cards c: it must use exactly the cards of c, and the front must
+// be weaker than the middle, which must be weaker than the back.
+func CheckHand(h *Hand, c []poker.Card) error {
+	if len(c) != 13 {
+		return fmt.Errorf("got %d cards, want 13", len(c))
+	}
+	want := map[poker.Card]int{}
+	for _, ci := range c {
+		want[ci]++
+	}
+	for _, hc := range append(append(h.Front[:], h.Middle[:]...), h.Back[:]...) {
+		if want[hc] == 0 {
+			return fmt.Errorf("card %s isn't dealt, or is used more than once", hc)
+		}
+		want[hc]--
+	}
+	r := h.ranks()
+	if r[0] > r[1] || r[1] > r[2] {
+		return fmt.Errorf("hand %s is fouled", h)
+	}
+	return nil
+}
+
+// handJSON is the JSON representation of a Hand.
+type handJSON struct {
+	Front  []string `json:"front"`
+	Middle []string `json:"middle"`
+	Back   []string `json:"back"`
+}
+
+func cardNames(c []poker.Card) []string {
+	r := make([]string, len(c))
+	for i, ci := range c {
+		r[i] = ci.String()
+	}
+	return r
+}
+
+func parseCardNames(dst []poker.Card, names []string) error {
+	if len(names) != len(dst) {
+		return fmt.Errorf("got %d cards, want %d", len(names), len(dst))
+	}
+	for i, n := range names {
+		c, ok := poker.NameToCard[n]
+		if !ok {
+			return fmt.Errorf("bad card %q", n)
+		}
+		dst[i] = c
+	}
+	return nil
+}
+
+// MarshalJSON encodes a hand as an object with "front", "middle" and
+// "back" fields, each an array of card names such as "HA" or "C8".
+func (h Hand) MarshalJSON() ([]byte, error) {
+	return json.Marshal(handJSON{cardNames(h.Front[:]), cardNames(h.Middle[:]), cardNames(h.Back[:])})
+}
+
+// UnmarshalJSON decodes a hand encoded with MarshalJSON.
+func (h *Hand) UnmarshalJSON(b []byte) error {
+	var hj handJSON
+	if err := json.Unmarshal(b, &hj); err != nil {
+		return err
+	}
+	if err := parseCardNames(h.Front[:], hj.Front); err != nil {
+		return fmt.Errorf("front: %s", err)
+	}
+	if err := parseCardNames(h.Middle[:], hj.Middle); err != nil {
+		return fmt.Errorf("middle: %s", err)
+	}
+	if err := parseCardNames(h.Back[:], hj.Back); err != nil {
+		return fmt.Errorf("back: %s", err)
+	}
+	return nil
+}
+
+// ExportArrangements writes the non-dominated arrangements of the
+// 13 cards c as JSON, for use by external optimizers. The format is:
+//
+//	{
+//	  "cards": ["HA", "C8", ...],
+//	  "arrangements": [
+//	    {"front": [...], "middle": [...], "back": [...], "ranks": [f, m, b]},
+//	    ...
+//	  ]
+//	}
+//
+// where ranks are the eval ranks of the front, middle and back
+// (larger is better), as returned by poker.Eval3 and poker.Eval5.
+// A chosen arrangement can be read back with ImportHand.
+func ExportArrangements(w io.Writer, c []poker.Card) error {
+	type arrangementJSON struct {
+		handJSON
+		Ranks [3]int16 `json:"ranks"`
+	}
+	out := struct {
+		Cards        []string          `json:"cards"`
+		Arrangements []arrangementJSON `json:"arrangements"`
+	}{Cards: cardNames(c)}
+	for _, a := range Arrangements(c) {
+		h := &a.Hand
+		out.Arrangements = append(out.Arrangements, arrangementJSON{
+			handJSON{cardNames(h.Front[:]), cardNames(h.Middle[:]), cardNames(h.Back[:])},
+			a.Ranks,
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// ImportHand reads a hand in JSON (as a single element of the
+// "arrangements" written by ExportArrangements, with or without the
+// "ranks" field), and checks it's a legal way to play the cards c.
+func ImportHand(r io.Reader, c []poker.Card) (Hand, error) {
+	var h Hand
+	if err := json.NewDecoder(r).Decode(&h); err != nil {
+		return Hand{}, err
+	}
+	if err := CheckHand(&h, c); err != nil {
+		return Hand{}, err
+	}
+	return h, nil
+}
@@ -0,0 +1,61 @@
+package cpoker
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+func randomDeal(rnd *rand.Rand) []poker.Card {
+	cards := append([]poker.Card{}, poker.Cards...)
+	rnd.Shuffle(len(cards), func(i, j int) { cards[i], cards[j] = cards[j], cards[i] })
+	return cards[:13]
+}
+
+func TestArrangementsContainsPlay(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		c := randomDeal(rnd)
+		best, _ := Play(c, MaxProdEvaluator{})
+		found := false
+		for _, a := range Arrangements(c) {
+			if err := CheckHand(&a.Hand, c); err != nil {
+				t.Fatalf("arrangement of %v is illegal: %s", c, err)
+			}
+			found = found || a.Ranks == best.ranks()
+		}
+		if !found {
+			t.Errorf("Play(%v) = %s, which isn't in Arrangements", c, &best)
+		}
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	c := randomDeal(rand.New(rand.NewSource(2)))
+	var b bytes.Buffer
+	if err := ExportArrangements(&b, c); err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Arrangements []json.RawMessage
+	}
+	if err := json.Unmarshal(b.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Arrangements) == 0 {
+		t.Fatalf("no arrangements exported")
+	}
+	h, err := ImportHand(bytes.NewReader(out.Arrangements[0]), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h != Arrangements(c)[0].Hand {
+		t.Errorf("imported %s, want %s", &h, &Arrangements(c)[0].Hand)
+	}
+	if _, err := ImportHand(bytes.NewReader(out.Arrangements[0]), randomDeal(rand.New(rand.NewSource(3)))); err == nil {
+		t.Errorf("imported a hand using cards that weren't dealt")
+	}
+}
@@ -0,0 +1,335 @@
+package cpoker
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// This file writes tables in the Arrow IPC streaming format
+// (https://arrow.apache.org/docs/format/Columnar.html), which pandas,
+// polars and duckdb can read directly. Only what's needed for flat
+// tables of integers, floats and strings is supported, so the
+// flatbuffers metadata is encoded by hand rather than with a
+// generated library.
+
+// An ArrowColumn is a named column of a table written by WriteArrow.
+// Exactly one of Ints, Floats and Strings should be set.
+type ArrowColumn struct {
+	Name    string
+	Ints    []int64
+	Floats  []float64
+	Strings []string
+}
+
+func (ac *ArrowColumn) len() int {
+	switch {
+	case ac.Ints != nil:
+		return len(ac.Ints)
+	case ac.Floats != nil:
+		return len(ac.Floats)
+	}
+	return len(ac.Strings)
+}
+
+// A flatbuffer object is one of these types.
+type (
+	fbTable        []fbField // Indexed by field id.
+	fbString       string
+	fbStructVector struct {
+		n    int
+		data []byte // The structs, each 8-byte aligned.
+	}
+	fbOffsetVector []interface{}
+)
+
+// An fbField is a table field: either an inline scalar (little-endian),
+// or an offset to another object. The zero value is an absent field.
+type fbField struct {
+	scalar []byte
+	child  interface{}
+}
+
+// appendLE16, appendLE32 and appendLE64 append little-endian integers.
+func appendLE16(b []byte, x uint16) []byte {
+	return append(b, byte(x), byte(x>>8))
+}
+
+func appendLE32(b []byte, x uint32) []byte {
+	return append(b, byte(x), byte(x>>8), byte(x>>16), byte(x>>24))
+}
+
+func appendLE64(b []byte, x uint64) []byte {
+	return appendLE32(appendLE32(b, uint32(x)), uint32(x>>32))
+}
+
+func fbInt8(x uint8) fbField { return fbField{scalar: []byte{x}} }
+
+func fbInt16(x int16) fbField {
+	return fbField{scalar: appendLE16(nil, uint16(x))}
+}
+
+func fbInt32(x int32) fbField {
+	return fbField{scalar: appendLE32(nil, uint32(x))}
+}
+
+func fbInt64(x int64) fbField {
+	return fbField{scalar: appendLE64(nil, uint64(x))}
+}
+
+func fbChild(x interface{}) fbField { return fbField{child: x} }
+
+// fbBuilder lays out flatbuffer objects front to back, so every
+// object is placed after the objects that refer to it, and offsets
+// (which must point forwards) are patched in afterwards.
+type fbBuilder struct {
+	buf []byte
+}
+
+func (b *fbBuilder) align(n int) {
+	for len(b.buf)%n != 0 {
+		b.buf = append(b.buf, 0)
+	}
+}
+
+func (b *fbBuilder) putUint32(pos int, x uint32) {
+	binary.LittleEndian.PutUint32(b.buf[pos:], x)
+}
+
+// finish returns a flatbuffer whose root is the table t.
+func (b *fbBuilder) finish(t fbTable) []byte {
+	b.buf = make([]byte, 4)
+	b.putUint32(0, uint32(b.write(t)))
+	b.align(8)
+	return b.buf
+}
+
+// write writes an object, and then the objects it refers to, and
+// returns the position of the object.
+func (b *fbBuilder) write(obj interface{}) int {
+	switch o := obj.(type) {
+	case fbTable:
+		// Lay out the fields after the table's soffset to its vtable,
+		// each aligned to its size.
+		size := 4
+		offsets := make([]int, len(o))
+		for i, f := range o {
+			n := len(f.scalar)
+			if f.child != nil {
+				n = 4
+			}
+			if n == 0 {
+				continue
+			}
+			for size%n != 0 {
+				size++
+			}
+			offsets[i] = size
+			size += n
+		}
+		b.align(2)
+		vt := len(b.buf)
+		b.buf = appendLE16(b.buf, uint16(4+2*len(o)))
+		b.buf = appendLE16(b.buf, uint16(size))
+		for _, off := range offsets {
+			b.buf = appendLE16(b.buf, uint16(off))
+		}
+		b.align(8)
+		pos := len(b.buf)
+		b.buf = append(b.buf, make([]byte, size)...)
+		b.putUint32(pos, uint32(pos-vt))
+		for i, f := range o {
+			copy(b.buf[pos+offsets[i]:], f.scalar)
+		}
+		for i, f := range o {
+			if f.child != nil {
+				at := pos + offsets[i]
+				b.putUint32(at, uint32(b.write(f.child)-at))
+			}
+		}
+		return pos
+	case fbString:
+		b.align(4)
+		pos := len(b.buf)
+		b.buf = appendLE32(b.buf, uint32(len(o)))
+		b.buf = append(append(b.buf, o...), 0)
+		return pos
+	case fbStructVector:
+		for (len(b.buf)+4)%8 != 0 {
+			b.buf = append(b.buf, 0)
+		}
+		pos := len(b.buf)
+		b.buf = appendLE32(b.buf, uint32(o.n))
+		b.buf = append(b.buf, o.data...)
+		return pos
+	case fbOffsetVector:
+		b.align(4)
+		pos := len(b.buf)
+		b.buf = appendLE32(b.buf, uint32(len(o)))
+		b.buf = append(b.buf, make([]byte, 4*len(o))...)
+		for i, child := range o {
+			at := pos + 4 + 4*i
+			b.putUint32(at, uint32(b.write(child)-at))
+		}
+		return pos
+	}
+	panic(fmt.Sprintf("unknown flatbuffer object %T", obj))
+}
+
+// Arrow metadata constants.
+const (
+	arrowVersionV5      = 4
+	arrowHeaderSchema   = 1
+	arrowHeaderBatch    = 3
+	arrowTypeInt        = 2
+	arrowTypeFloat      = 3
+	arrowTypeUtf8       = 5
+	arrowPrecisionFloat = 2 // DOUBLE
+)
+
+// writeArrowMessage writes an encapsulated IPC message.
+func writeArrowMessage(w io.Writer, headerType uint8, header fbTable, body []byte) error {
+	msg := fbTable{fbInt16(arrowVersionV5), fbInt8(headerType), fbChild(header), fbInt64(int64(len(body)))}
+	meta := (&fbBuilder{}).finish(msg)
+	prefix := appendLE32([]byte{0xff, 0xff, 0xff, 0xff}, uint32(len(meta)))
+	for _, b := range [][]byte{prefix, meta, body} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteArrow writes the columns as a table in the Arrow IPC
+// streaming format. The columns must all have the same length.
+func WriteArrow(w io.Writer, cols []ArrowColumn) error {
+	n := 0
+	if len(cols) > 0 {
+		n = cols[0].len()
+	}
+	var fields fbOffsetVector
+	var nodes, buffers, body []byte
+	addBuffer := func(data []byte) {
+		buffers = appendLE64(buffers, uint64(len(body)))
+		buffers = appendLE64(buffers, uint64(len(data)))
+		body = append(body, data...)
+		for len(body)%8 != 0 {
+			body = append(body, 0)
+		}
+	}
+	for i := range cols {
+		c := &cols[i]
+		if c.len() != n {
+			return fmt.Errorf("column %q has %d rows, want %d", c.Name, c.len(), n)
+		}
+		var typeID uint8
+		var typ fbTable
+		addBuffer(nil) // No validity bitmap, since there are no nulls.
+		switch {
+		case c.Ints != nil:
+			typeID, typ = arrowTypeInt, fbTable{fbInt32(64), fbInt8(1)}
+			var data []byte
+			for _, x := range c.Ints {
+				data = appendLE64(data, uint64(x))
+			}
+			addBuffer(data)
+		case c.Floats != nil:
+			typeID, typ = arrowTypeFloat, fbTable{fbInt16(arrowPrecisionFloat)}
+			var data []byte
+			for _, x := range c.Floats {
+				data = appendLE64(data, math.Float64bits(x))
+			}
+			addBuffer(data)
+		default:
+			typeID, typ = arrowTypeUtf8, fbTable{}
+			offsets := appendLE32(nil, 0)
+			var data []byte
+			for _, s := range c.Strings {
+				data = append(data, s...)
+				offsets = appendLE32(offsets, uint32(len(data)))
+			}
+			addBuffer(offsets)
+			addBuffer(data)
+		}
+		fields = append(fields, fbTable{
+			fbChild(fbString(c.Name)), fbInt8(0), fbInt8(typeID), fbChild(typ), {}, fbChild(fbOffsetVector{}),
+		})
+		nodes = appendLE64(nodes, uint64(n))
+		nodes = appendLE64(nodes, 0)
+	}
+	schema := fbTable{fbInt16(0), fbChild(fields)}
+	if err := writeArrowMessage(w, arrowHeaderSchema, schema, nil); err != nil {
+		return err
+	}
+	batch := fbTable{
+		fbInt64(int64(n)),
+		fbChild(fbStructVector{n: len(cols), data: nodes}),
+		fbChild(fbStructVector{n: len(buffers) / 16, data: buffers}),
+	}
+	if err := writeArrowMessage(w, arrowHeaderBatch, batch, body); err != nil {
+		return err
+	}
+	_, err := w.Write([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0})
+	return err
+}
+
+// handColumn formats one slot of a hand as card names.
+func handColumn(h *Hand, slot int) string {
+	c := [][]poker.Card{h.Front[:], h.Middle[:], h.Back[:]}[slot]
+	return strings.Join(cardNames(c), " ")
+}
+
+// WriteHandRecordsArrow writes the per-hand records of a comparison
+// as an Arrow table, with a row for each hand.
+func WriteHandRecordsArrow(w io.Writer, recs []HandRecord) error {
+	cols := []ArrowColumn{
+		{Name: "deal", Ints: []int64{}},
+		{Name: "seat", Ints: []int64{}},
+		{Name: "score", Ints: []int64{}},
+		{Name: "wins", Ints: []int64{}},
+		{Name: "losses", Ints: []int64{}},
+	}
+	slots := []string{"front", "middle", "back"}
+	for _, who := range []string{"hero", "villain"} {
+		for _, s := range slots {
+			cols = append(cols, ArrowColumn{Name: who + "_" + s, Strings: []string{}})
+		}
+	}
+	for i := range recs {
+		r := &recs[i]
+		for j, x := range []int{r.Deal, r.Seat, r.Score, r.Wins, r.Losses} {
+			cols[j].Ints = append(cols[j].Ints, int64(x))
+		}
+		for s := range slots {
+			cols[5+s].Strings = append(cols[5+s].Strings, handColumn(&r.Hero, s))
+			cols[8+s].Strings = append(cols[8+s].Strings, handColumn(&r.Villain, s))
+		}
+	}
+	return WriteArrow(w, cols)
+}
+
+// WriteMetricsArrow writes metrics records as an Arrow table, with a
+// row for each record. Times are in nanoseconds since the Unix epoch.
+func WriteMetricsArrow(w io.Writer, recs []MetricRecord) error {
+	cols := []ArrowColumn{
+		{Name: "time_ns", Ints: []int64{}},
+		{Name: "run", Strings: []string{}},
+		{Name: "metric", Strings: []string{}},
+		{Name: "step", Ints: []int64{}},
+		{Name: "value", Floats: []float64{}},
+		{Name: "stderr", Floats: []float64{}},
+	}
+	for _, r := range recs {
+		cols[0].Ints = append(cols[0].Ints, r.Time.UnixNano())
+		cols[1].Strings = append(cols[1].Strings, r.Run)
+		cols[2].Strings = append(cols[2].Strings, r.Metric)
+		cols[3].Ints = append(cols[3].Ints, int64(r.Step))
+		cols[4].Floats = append(cols[4].Floats, r.Value)
+		cols[5].Floats = append(cols[5].Floats, r.StdErr)
+	}
+	return WriteArrow(w, cols)
+}
@@ -0,0 +1,55 @@
+package cpoker
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestWriteArrow(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteArrow(&buf, []ArrowColumn{
+		{Name: "n", Ints: []int64{1, 2, 3}},
+		{Name: "x", Floats: []float64{0.5, -1, 2}},
+		{Name: "s", Strings: []string{"a", "", "bc"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Walk the encapsulated messages: a schema, a record batch, and
+	// the end-of-stream marker.
+	b := buf.Bytes()
+	var bodies []int64
+	for len(b) > 0 {
+		if len(b) < 8 || binary.LittleEndian.Uint32(b) != 0xffffffff {
+			t.Fatalf("missing continuation marker")
+		}
+		size := int(binary.LittleEndian.Uint32(b[4:]))
+		if size == 0 {
+			b = b[8:]
+			break
+		}
+		if size%8 != 0 {
+			t.Errorf("metadata size %d isn't a multiple of 8", size)
+		}
+		meta := b[8 : 8+size]
+		root := int(binary.LittleEndian.Uint32(meta))
+		vt := root - int(int32(binary.LittleEndian.Uint32(meta[root:])))
+		// Field 3 of the Message table is the body length.
+		off := int(binary.LittleEndian.Uint16(meta[vt+4+2*3:]))
+		body := int64(binary.LittleEndian.Uint64(meta[root+off:]))
+		bodies = append(bodies, body)
+		b = b[8+size+int(body):]
+	}
+	if len(b) != 0 || len(bodies) != 2 {
+		t.Fatalf("got %d messages and %d trailing bytes, want 2 and 0", len(bodies), len(b))
+	}
+	// The body has, for each column, an empty validity buffer and
+	// its data, padded to 8 bytes: 24 + 24 + (16 offsets + 8 data).
+	if bodies[0] != 0 || bodies[1] != 72 {
+		t.Errorf("got body lengths %v, want [0 72]", bodies)
+	}
+	if err := WriteArrow(&buf, []ArrowColumn{{Name: "a", Ints: []int64{1}}, {Name: "b", Ints: []int64{}}}); err == nil {
+		t.Errorf("WriteArrow with columns of different lengths succeeded")
+	}
+}
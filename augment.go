@@ -0,0 +1,80 @@
+package cpoker
+
+import (
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// suitPermutations are the 24 ways of relabeling the four suits.
+var suitPermutations = func() [][4]poker.Suit {
+	var r [][4]poker.Suit
+	var perm [4]poker.Suit
+	var used [4]bool
+	var rec func(i int)
+	rec = func(i int) {
+		if i == 4 {
+			r = append(r, perm)
+			return
+		}
+		for s := poker.Suit(0); s < 4; s++ {
+			if !used[s] {
+				used[s], perm[i] = true, s
+				rec(i + 1)
+				used[s] = false
+			}
+		}
+	}
+	rec(0)
+	return r
+}()
+
+// PermuteSuits returns the card c with its suit s changed to perm[s].
+func PermuteSuits(c poker.Card, perm [4]poker.Suit) poker.Card {
+	return c&^3 | poker.Card(perm[c.Suit()])
+}
+
+// permuteSuits returns the hand with the suits of its cards permuted.
+func (h *Hand) permuteSuits(perm [4]poker.Suit) Hand {
+	var r Hand
+	for i, c := range h.Front {
+		r.Front[i] = PermuteSuits(c, perm)
+	}
+	for i, c := range h.Middle {
+		r.Middle[i] = PermuteSuits(c, perm)
+	}
+	for i, c := range h.Back {
+		r.Back[i] = PermuteSuits(c, perm)
+	}
+	return r
+}
+
+// AugmentHandRecords returns the records together with copies of each
+// with the suits of both players' cards relabeled, one for each
+// distinct relabeling. Relabeling suits doesn't change how any hand
+// ranks, so the copies are exactly as likely as the original deal and
+// score the same, which multiplies the samples available for
+// statistics that depend on the cards (such as how often flush draws
+// are played) without playing more deals.
+//
+// Only hand records are augmented. The sample counts of evaluators such
+// as SampledEvaluator count ranks, which relabeling suits doesn't
+// change, so augmenting them would multiply every count by the same
+// factor: the win probabilities would be unchanged, but Samples would
+// be overstated and WinStdErr understated. For the same reason, the
+// copies aren't independent samples, and standard errors should be
+// computed from the number of deals, not the number of records.
+func AugmentHandRecords(recs []HandRecord) []HandRecord {
+	var r []HandRecord
+	for _, rec := range recs {
+		seen := map[[2]Hand]bool{}
+		for _, perm := range suitPermutations {
+			p := rec
+			p.Hero = rec.Hero.permuteSuits(perm)
+			p.Villain = rec.Villain.permuteSuits(perm)
+			if k := [2]Hand{p.Hero, p.Villain}; !seen[k] {
+				seen[k] = true
+				r = append(r, p)
+			}
+		}
+	}
+	return r
+}
@@ -0,0 +1,67 @@
+package cpoker
+
+import (
+	"github.com/paulhankin/poker/v2/poker"
+	"math/rand"
+	"testing"
+)
+
+func TestAugmentHandRecords(t *testing.T) {
+	c := CompareEvaluatorsWithOptions(MaxProdEvaluator{}, MaxProdEvaluator{}, 5, 0, CompareOptions{
+		Rand: rand.New(rand.NewSource(1)),
+		OnHand: func(r HandRecord) {
+			recs := AugmentHandRecords([]HandRecord{r})
+			// Between them, the players have 26 cards, so they have
+			// cards of at least two suits.
+			if len(recs) < 12 || len(recs) > 24 {
+				t.Errorf("got %d suit relabelings, want 12 to 24", len(recs))
+			}
+			seen := map[string]bool{}
+			for _, a := range recs {
+				if err := CheckHand(&a.Hero, a.Hero.cards()); err != nil {
+					t.Fatal(err)
+				}
+				if a.Hero.ranks() != r.Hero.ranks() || a.Villain.ranks() != r.Villain.ranks() {
+					t.Errorf("relabeling %s changed its ranks", &r.Hero)
+				}
+				if got := Scoring2to4.Score(&a.Hero, &a.Villain); got != r.Score {
+					t.Errorf("relabeled hands score %d, want %d", got, r.Score)
+				}
+				k := a.Hero.String() + a.Villain.String()
+				if seen[k] {
+					t.Errorf("relabeling %s repeated", k)
+				}
+				seen[k] = true
+			}
+		},
+	})
+	if c.Played != 10 {
+		t.Errorf("played %d hands, want 10", c.Played)
+	}
+}
+
+func TestPermuteSuits(t *testing.T) {
+	if len(suitPermutations) != 24 {
+		t.Fatalf("got %d suit permutations, want 24", len(suitPermutations))
+	}
+	for _, perm := range suitPermutations {
+		seen := map[poker.Card]bool{}
+		for _, c := range poker.Cards {
+			p := PermuteSuits(c, perm)
+			if p.Rank() != c.Rank() || p.Suit() != perm[c.Suit()] {
+				t.Errorf("PermuteSuits(%s, %v) = %s", c, perm, p)
+			}
+			seen[p] = true
+		}
+		// Each permutation maps the deck onto itself.
+		if len(seen) != 52 {
+			t.Errorf("permutation %v maps the deck to %d cards", perm, len(seen))
+		}
+	}
+	identity := [4]poker.Suit{0, 1, 2, 3}
+	for _, c := range poker.Cards {
+		if p := PermuteSuits(c, identity); p != c {
+			t.Errorf("the identity permutation changed %s to %s", c, p)
+		}
+	}
+}
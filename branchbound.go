@@ -0,0 +1,149 @@
+package cpoker
+
+import (
+	"sort"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// PlayBranchAndBound finds a hand as good as Play's, but evaluates
+// fewer hands, which matters when the evaluator is expensive. For each
+// front, it bounds the value of every hand with that front by valuing
+// it with the strongest middle and the strongest back the other 10
+// cards can make (which may not be possible together), and a front's
+// hands are only searched if its bound beats the best hand found so
+// far. Like Play, it relies on he's values never decreasing
+// when a rank increases, which is true of the evaluators in this
+// package (and of expected points, as long as royalties don't
+// decrease with rank). stats.Hands counts the bounds as well as the
+// hands evaluated.
+func PlayBranchAndBound(c []poker.Card, he HandEvaluator) (Hand, EvalStats) {
+	var stats EvalStats
+	evaluator := he.Evaluator(c)
+	type split struct {
+		back   uint16 // Which of the other 10 cards are in the back
+		em, eb int16
+	}
+	type frontBound struct {
+		front  [3]poker.Card
+		rest   [10]poker.Card
+		rank   int16
+		splits []split
+		bound  [3]int16 // The front, and the strongest middle and back
+	}
+	var fronts []frontBound
+	forEachCombination(13, 3, func(idx []int) {
+		var fb frontBound
+		f, r := 0, 0
+		for i, ci := range c {
+			if f < 3 && idx[f] == i {
+				fb.front[f] = ci
+				f++
+			} else {
+				fb.rest[r] = ci
+				r++
+			}
+		}
+		fb.rank = poker.Eval3(&fb.front)
+		var maxM, maxB int16
+		forEachCombination(10, 5, func(idx []int) {
+			var back, middle [5]poker.Card
+			var s split
+			b, m := 0, 0
+			for i, ci := range fb.rest {
+				if b < 5 && idx[b] == i {
+					back[b] = ci
+					s.back |= 1 << uint(i)
+					b++
+				} else {
+					middle[m] = ci
+					m++
+				}
+			}
+			s.eb, s.em = poker.Eval5(&back), poker.Eval5(&middle)
+			// Each split is seen twice, with the middle and back
+			// swapped, so only the one with the stronger back is kept.
+			switch {
+			case s.em > s.eb:
+				return
+			case s.em == s.eb:
+				stats.BackEqualsMiddle++
+				return
+			case fb.rank >= s.em:
+				stats.StrongFront++
+				return
+			}
+			if s.em > maxM {
+				maxM = s.em
+			}
+			if s.eb > maxB {
+				maxB = s.eb
+			}
+			fb.splits = append(fb.splits, s)
+		})
+		if len(fb.splits) == 0 {
+			return
+		}
+		fb.bound = [3]int16{fb.rank, maxM, maxB}
+		fronts = append(fronts, fb)
+	})
+	// Fronts with high bounds are searched first, so good hands are
+	// found early. Bounds are only evaluated when they aren't dominated
+	// by a hand that's already been evaluated.
+	prod := func(r [3]int16) float64 { return evaluateProdHand(r[0], r[1], r[2]) }
+	sort.SliceStable(fronts, func(i, j int) bool { return prod(fronts[i].bound) > prod(fronts[j].bound) })
+	var best Hand
+	bestEV, found := 0.0, false
+	var maxima [][3]int16
+	// dominated reports whether r is dominated by an evaluated hand.
+	dominated := func(r [3]int16) bool {
+		for _, mx := range maxima {
+			if mx[0] >= r[0] && mx[1] >= r[1] && mx[2] >= r[2] {
+				return true
+			}
+		}
+		return false
+	}
+	for _, fb := range fronts {
+		if found {
+			if dominated(fb.bound) {
+				continue
+			}
+			stats.Hands++
+			if evaluator(fb.bound[0], fb.bound[1], fb.bound[2]) <= bestEV {
+				continue
+			}
+		}
+		for _, s := range fb.splits {
+			r := [3]int16{fb.rank, s.em, s.eb}
+			if dominated(r) {
+				continue
+			}
+			for i := 0; i < len(maxima); i++ {
+				if maxima[i][0] <= r[0] && maxima[i][1] <= r[1] && maxima[i][2] <= r[2] {
+					maxima[i] = maxima[len(maxima)-1]
+					maxima = maxima[:len(maxima)-1]
+					i--
+				}
+			}
+			maxima = append(maxima, r)
+			ev := evaluator(r[0], r[1], r[2])
+			stats.Hands++
+			if !found || ev > bestEV {
+				found, bestEV = true, ev
+				best.Front = fb.front
+				b, m := 0, 0
+				for i, ci := range fb.rest {
+					if s.back&(1<<uint(i)) != 0 {
+						best.Back[b] = ci
+						b++
+					} else {
+						best.Middle[m] = ci
+						m++
+					}
+				}
+			}
+		}
+	}
+	return best, stats
+}
@@ -0,0 +1,37 @@
+package cpoker
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestPlayBranchAndBound(t *testing.T) {
+	rnd := rand.New(rand.NewSource(11))
+	se := smallSampledEvaluator(t, 1000)
+	for _, he := range []HandEvaluator{MaxProdEvaluator{}, se} {
+		playHands, bnbHands := 0, 0
+		for i := 0; i < 30; i++ {
+			c := randomDeal(rnd)
+			deal := he
+			if ep, ok := he.(EvaluatorPoints); ok && i%2 == 1 {
+				// Expected points include royalties.
+				deal = fixedEvaluator(ep.Points(c, ScoringHK))
+			}
+			ev := deal.Evaluator(c)
+			best, ps := Play(c, deal)
+			h, bs := PlayBranchAndBound(c, deal)
+			if err := CheckHand(&h, c); err != nil {
+				t.Fatalf("%v: %s", &h, err)
+			}
+			br, hr := best.ranks(), h.ranks()
+			if got, want := ev(hr[0], hr[1], hr[2]), ev(br[0], br[1], br[2]); got != want {
+				t.Errorf("PlayBranchAndBound found %v worth %f, but Play found %v worth %f", &h, got, &best, want)
+			}
+			playHands += ps.Hands
+			bnbHands += bs.Hands
+		}
+		if bnbHands >= playHands {
+			t.Errorf("%T: PlayBranchAndBound evaluated %d hands, but Play only %d", he, bnbHands, playHands)
+		}
+	}
+}
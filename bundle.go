@@ -0,0 +1,205 @@
+package cpoker
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// A Bundle is a complete strategy as a single portable artifact: an
+// evaluator, together with the scoring and variant it was made for,
+// and free-form metadata (such as who trained it, and how).
+type Bundle struct {
+	Evaluator *SampledEvaluator
+	Scoring   *Scoring
+	Variant   VariantConfig
+	Metadata  map[string]string
+}
+
+// BundleFormat is the version of the bundle format written by
+// Bundle.Marshal.
+const BundleFormat = 1
+
+// The files in a bundle.
+const (
+	bundleManifest  = "manifest.json"
+	bundleEvaluator = "evaluator.data"
+)
+
+// bundleScoring is how a Scoring is stored in a bundle's manifest.
+type bundleScoring struct {
+	Name      string    `json:"name"`
+	Slot      int       `json:"slot"`
+	Majority  int       `json:"majority"`
+	Scoop     int       `json:"scoop"`
+	Surrender int       `json:"surrender,omitempty"`
+	Royalties *[3][]int `json:"royalties,omitempty"`
+}
+
+// bundleManifestJSON is the manifest of a bundle.
+type bundleManifestJSON struct {
+	Format   int               `json:"format"`
+	Table    TableMetadata     `json:"table"`
+	Scoring  bundleScoring     `json:"scoring"`
+	Variant  VariantConfig     `json:"variant"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Marshal writes the bundle as a zip archive containing a
+// manifest.json, which records the scoring, variant, metadata and the
+// rank tables of the variant (see TablesFor), and an evaluator.data, which
+// is the evaluator as written by SampledEvaluator.Marshal. Bundles are
+// conventionally given the extension .cpk.
+func (b *Bundle) Marshal(w io.Writer) error {
+	if b.Evaluator == nil {
+		return fmt.Errorf("bundle has no evaluator")
+	}
+	s := b.Scoring
+	if s == nil {
+		s = Scoring2to4
+	}
+	t, err := TablesFor(b.Variant)
+	if err != nil {
+		return err
+	}
+	m := bundleManifestJSON{
+		Format:   BundleFormat,
+		Table:    t.Info(),
+		Scoring:  bundleScoring{s.Name, s.Slot, s.Majority, s.Scoop, s.Surrender, s.Royalties},
+		Variant:  b.Variant,
+		Metadata: b.Metadata,
+	}
+	zw := zip.NewWriter(w)
+	mw, err := zw.Create(bundleManifest)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(mw)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		return err
+	}
+	ew, err := zw.Create(bundleEvaluator)
+	if err != nil {
+		return err
+	}
+	if err := b.Evaluator.Marshal(ew); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Save writes the bundle to a named file, replacing it atomically.
+func (b *Bundle) Save(filename string) error {
+	var buf bytes.Buffer
+	if err := b.Marshal(&buf); err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, buf.Bytes())
+}
+
+// UnmarshalBundle reads a bundle written by Bundle.Marshal. It's an
+// error if the bundle was written in a newer format, or its variant's
+// rank tables are different now. If the bundle's scoring is the same
+// as a preset's, the preset is used. The evaluator values hands with
+// the bundle's scoring.
+func UnmarshalBundle(r io.ReaderAt, size int64) (*Bundle, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	for _, name := range []string{bundleManifest, bundleEvaluator} {
+		if files[name] == nil {
+			return nil, fmt.Errorf("bundle has no %s", name)
+		}
+	}
+	var m bundleManifestJSON
+	if err := readBundleFile(files[bundleManifest], func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&m)
+	}); err != nil {
+		return nil, err
+	}
+	if m.Format > BundleFormat {
+		return nil, fmt.Errorf("bundle has format %d, but only formats up to %d are supported", m.Format, BundleFormat)
+	}
+	t, err := TablesFor(m.Variant)
+	if err != nil {
+		return nil, err
+	}
+	if m.Table.Checksum != t.Checksum() {
+		return nil, fmt.Errorf("bundle was made with different rank tables for variant %q (checksum %s, want %s)", m.Variant.Name, m.Table.Checksum, t.Checksum())
+	}
+	b := &Bundle{Variant: m.Variant, Metadata: m.Metadata}
+	if err := readBundleFile(files[bundleEvaluator], func(r io.Reader) error {
+		var err error
+		b.Evaluator, err = UnmarshalSampledEvaluator(r)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	ms := m.Scoring
+	b.Scoring = &Scoring{Name: ms.Name, Slot: ms.Slot, Majority: ms.Majority, Scoop: ms.Scoop, Surrender: ms.Surrender, Royalties: ms.Royalties}
+	if s, err := ScoringByName(ms.Name); err == nil && reflect.DeepEqual(s, b.Scoring) {
+		b.Scoring = s
+	}
+	b.Evaluator.Scoring = b.Scoring
+	return b, nil
+}
+
+// Play plays a deal as the bundle's variant deals it: 13 cards, or 14
+// if the variant discards one (see PlayWithDiscard), in which case the
+// card discarded is returned too. Hands are valued with the bundle's
+// evaluator and scoring. It's an error if the deal has the wrong number
+// of cards, or the variant is a lowball one, which Play doesn't support.
+func (b *Bundle) Play(c []poker.Card) (Hand, []poker.Card, error) {
+	if b.Variant.Lowball != "" {
+		return Hand{}, nil, fmt.Errorf("can't play lowball variant %q", b.Variant.Name)
+	}
+	se := *b.Evaluator
+	se.Scoring = b.Scoring
+	if !b.Variant.Discard {
+		h, _, err := PlayE(c, &se)
+		return h, nil, err
+	}
+	h, discard, _, err := PlayWithDiscard(c, &se)
+	if err != nil {
+		return Hand{}, nil, fmt.Errorf("variant %q deals 14 distinct cards: %s", b.Variant.Name, err)
+	}
+	return h, []poker.Card{discard}, nil
+}
+
+// readBundleFile calls read with the contents of a file in a bundle.
+func readBundleFile(f *zip.File, read func(r io.Reader) error) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := read(rc); err != nil {
+		return fmt.Errorf("%s: %s", f.Name, err)
+	}
+	return nil
+}
+
+// LoadBundle reads a bundle from a named file.
+func LoadBundle(filename string) (*Bundle, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	b, err := UnmarshalBundle(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+	return b, nil
+}
@@ -0,0 +1,152 @@
+package cpoker
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+func TestBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	se := smallSampledEvaluator(t, 100)
+	custom := &Scoring{Name: "house", Slot: 1, Majority: 2, Royalties: NewRoyalties(func(slot int, hand []poker.Card) int { return slot })}
+	for _, s := range []*Scoring{ScoringHK, custom} {
+		filename := filepath.Join(dir, s.Name+".cpk")
+		b := &Bundle{
+			Evaluator: se,
+			Scoring:   s,
+			Variant:   VariantConfig{Name: "pineapple", Discard: true},
+			Metadata:  map[string]string{"author": "test"},
+		}
+		if err := b.Save(filename); err != nil {
+			t.Fatal(err)
+		}
+		got, err := LoadBundle(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got.Evaluator.Counts(2), se.Counts(2)) || got.Evaluator.Samples() != se.Samples() {
+			t.Errorf("%s: loaded evaluator differs from the saved one", s.Name)
+		}
+		if !reflect.DeepEqual(got.Scoring, s) || got.Variant != b.Variant || !reflect.DeepEqual(got.Metadata, b.Metadata) {
+			t.Errorf("%s: loaded %+v, want %+v", s.Name, got, b)
+		}
+		if s == ScoringHK && got.Scoring != ScoringHK {
+			t.Errorf("loaded scoring isn't the %s preset", s.Name)
+		}
+		// The evaluator values hands with the bundle's scoring.
+		if got.Evaluator.Scoring != got.Scoring {
+			t.Errorf("%s: loaded evaluator has scoring %v", s.Name, got.Evaluator.Scoring)
+		}
+		if se2, err := LoadEvaluatorFile(filename); err != nil || se2.Samples() != se.Samples() || !reflect.DeepEqual(se2.Scoring, s) {
+			t.Errorf("LoadEvaluatorFile(%s) = %v, %v", filename, se2, err)
+		}
+		// The variant deals 14 cards and discards one.
+		c := mustCards(t, "HAHKHQHJHTH9H8H7H6H5H4H3H2SA")
+		h, discard, err := got.Play(c)
+		if err != nil || len(discard) != 1 {
+			t.Fatalf("%s: Play(%v) = %v, %v, %v", s.Name, c, &h, discard, err)
+		}
+		var rest []poker.Card
+		for _, ci := range c {
+			if ci != discard[0] {
+				rest = append(rest, ci)
+			}
+		}
+		if err := CheckHand(&h, rest); err != nil {
+			t.Errorf("%s: Play(%v) = %v discarding %v: %s", s.Name, c, &h, discard, err)
+		}
+		if _, _, err := got.Play(c[:13]); err == nil {
+			t.Errorf("%s: played 13 cards in a variant which deals 14", s.Name)
+		}
+	}
+	if _, err := UnmarshalBundle(strings.NewReader("not a zip"), 9); err == nil {
+		t.Errorf("read a bundle that isn't a zip archive")
+	}
+	if err := (&Bundle{}).Marshal(ioutil.Discard); err == nil {
+		t.Errorf("wrote a bundle without an evaluator")
+	}
+}
+
+func TestBundleVariant(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "lowball.cpk")
+	b := &Bundle{Evaluator: smallSampledEvaluator(t, 100), Variant: VariantConfig{Name: "lowball-2-7", Lowball: LowballDeuceToSeven}}
+	if err := b.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadBundle(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Variant != b.Variant {
+		t.Errorf("loaded variant %+v, want %+v", got.Variant, b.Variant)
+	}
+	if _, _, err := got.Play(randomDeal(rand.New(rand.NewSource(1)))); err == nil {
+		t.Errorf("played a lowball deal with the standard tables")
+	}
+	// An engine for the standard game can't use it.
+	if _, err := LoadEvaluatorFile(filename); err == nil {
+		t.Errorf("loaded a lowball evaluator for the standard game")
+	}
+
+	// A variant that names the standard game but has a lowball can't
+	// be saved, or loaded from a bundle written before that was checked.
+	mixed := VariantConfig{Name: "standard", Lowball: LowballDeuceToSeven}
+	if err := (&Bundle{Evaluator: b.Evaluator, Variant: mixed}).Save(filename); err == nil {
+		t.Errorf("saved a bundle with variant %+v", mixed)
+	}
+	var buf bytes.Buffer
+	if err := (&Bundle{Evaluator: b.Evaluator, Variant: VariantConfig{Name: "standard"}}).Marshal(&buf); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	zw := zip.NewWriter(&out)
+	for _, f := range zr.File {
+		var data []byte
+		if err := readBundleFile(f, func(r io.Reader) error {
+			data, err = ioutil.ReadAll(r)
+			return err
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if f.Name == bundleManifest {
+			data = bytes.Replace(data, []byte(`"name": "standard"`), []byte(`"name": "standard", "lowball": "2-7"`), 1)
+		}
+		w, err := zw.Create(f.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filename, out.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadEvaluatorFile(filename); err == nil || !strings.Contains(err.Error(), "lowball") {
+		t.Errorf("loaded a bundle with variant %+v: got error %v", mixed, err)
+	}
+}
@@ -0,0 +1,268 @@
+package cpoker
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// A CardFormat is a way of writing cards.
+type CardFormat int
+
+// The card formats. Parsing ignores case, so, for example, "ha" is
+// read as HA in SuitFirst, and "AH" as Ah in RankFirst.
+const (
+	SuitFirst CardFormat = iota // HA, C8: the suit then the rank, as poker.Card's String
+	RankFirst                   // Ah, 8c: the rank then the suit, as many other poker tools write cards
+	Unicode                     // A♥, 8♣: the rank then a suit symbol
+	AnyFormat                   // Parses cards in any of the other formats, and formats them as SuitFirst
+)
+
+const (
+	rankLetters  = "23456789TJQKA"
+	suitLetters  = "CDHS" // In the order of poker.Suit
+	suitSymbols  = "♣♦♥♠"
+	suitOutlines = "♧♢♡♤"
+)
+
+// rawRankOf returns the raw rank (2->0, ..., A->12) named by s, which is
+// a rank letter or "10", or -1 if s isn't a rank.
+func rawRankOf(s string) int {
+	if s == "10" {
+		return 8
+	}
+	if len(s) != 1 {
+		return -1
+	}
+	return strings.IndexByte(rankLetters, byte(unicode.ToUpper(rune(s[0]))))
+}
+
+// suitOf returns the suit named by s, which is a suit letter or symbol,
+// and whether it's a letter. ok is false if s isn't a suit.
+func suitOf(s string) (suit poker.Suit, letter, ok bool) {
+	r := []rune(s)
+	if len(r) != 1 {
+		return 0, false, false
+	}
+	if i := strings.IndexRune(suitLetters, unicode.ToUpper(r[0])); i >= 0 {
+		return poker.Suit(i), true, true
+	}
+	for _, symbols := range []string{suitSymbols, suitOutlines} {
+		for i, sym := range []rune(symbols) {
+			if sym == r[0] {
+				return poker.Suit(i), false, true
+			}
+		}
+	}
+	return 0, false, false
+}
+
+// makeRawCard returns the card with a suit and raw rank.
+func makeRawCard(s poker.Suit, raw int) poker.Card {
+	c, _ := poker.MakeCard(s, poker.Rank((raw+1)%13+1))
+	return c
+}
+
+// Format returns the card written in the format.
+func (f CardFormat) Format(c poker.Card) string {
+	if !c.Valid() {
+		return "??"
+	}
+	rank := rankLetters[c.RawRank() : c.RawRank()+1]
+	switch f {
+	case RankFirst:
+		return rank + strings.ToLower(suitLetters[c.Suit():c.Suit()+1])
+	case Unicode:
+		return rank + string([]rune(suitSymbols)[c.Suit()])
+	}
+	return c.String()
+}
+
+// FormatCards returns the cards written in the format, separated by
+// spaces.
+func (f CardFormat) FormatCards(c []poker.Card) string {
+	names := make([]string, len(c))
+	for i, ci := range c {
+		names[i] = f.Format(ci)
+	}
+	return strings.Join(names, " ")
+}
+
+// Parse parses a card written in the format, ignoring surrounding
+// spaces.
+func (f CardFormat) Parse(s string) (poker.Card, error) {
+	t := strings.TrimSpace(s)
+	r := []rune(t)
+	if len(r) >= 2 {
+		// Suit first.
+		if suit, letter, ok := suitOf(string(r[0])); ok && letter && (f == SuitFirst || f == AnyFormat) {
+			if raw := rawRankOf(string(r[1:])); raw >= 0 {
+				return makeRawCard(suit, raw), nil
+			}
+		}
+		// Rank first.
+		if suit, letter, ok := suitOf(string(r[len(r)-1])); ok && (f == RankFirst && letter || f == Unicode && !letter || f == AnyFormat) {
+			if raw := rawRankOf(string(r[:len(r)-1])); raw >= 0 {
+				return makeRawCard(suit, raw), nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("bad card %q: want %s", s, f.describe())
+}
+
+// describe describes the format, for error messages.
+func (f CardFormat) describe() string {
+	switch f {
+	case SuitFirst:
+		return "a suit (C, D, H or S) followed by a rank (2-9, T, J, Q, K or A)"
+	case RankFirst:
+		return "a rank (2-9, T, J, Q, K or A) followed by a suit (c, d, h or s)"
+	case Unicode:
+		return "a rank (2-9, T, J, Q, K or A) followed by a suit symbol (♣, ♦, ♥ or ♠)"
+	}
+	return "a card such as HA, Ah or A♥"
+}
+
+// ParseCards parses a list of cards written in the format, separated
+// by spaces, commas, or both. It's an error if a card is repeated.
+func (f CardFormat) ParseCards(s string) ([]poker.Card, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+	var r []poker.Card
+	var seen CardSet
+	for _, field := range fields {
+		c, err := f.Parse(field)
+		if err != nil {
+			return nil, err
+		}
+		if seen.Contains(c) {
+			return nil, fmt.Errorf("card %s is repeated", f.Format(c))
+		}
+		seen = seen.Add(c)
+		r = append(r, c)
+	}
+	return r, nil
+}
+
+// MustMakeCard returns the card with a suit and rank, as poker.MakeCard,
+// but panics if there's no such card. It's for cards written in code.
+func MustMakeCard(s poker.Suit, r poker.Rank) poker.Card {
+	c, err := poker.MakeCard(s, r)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// CardOf returns the card with a name such as "HA", in any format
+// (as AnyFormat), and panics if s isn't a card. It's for cards written
+// in code; use ParseCard to parse input.
+func CardOf(s string) poker.Card {
+	c, err := AnyFormat.Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// ParseCard parses a card name, such as HA or C8, ignoring surrounding
+// spaces and case. Use CardFormat to parse cards written in other ways.
+func ParseCard(s string) (poker.Card, error) {
+	return SuitFirst.Parse(s)
+}
+
+// ParseCards parses a list of card names separated by spaces, commas,
+// or both, such as "HA, C8 DT". It's an error if a card is repeated.
+func ParseCards(s string) ([]poker.Card, error) {
+	return SuitFirst.ParseCards(s)
+}
+
+// A TextCard is a card which is encoded as its name, such as "HA" or
+// "C8", by encoding/json and other packages which use
+// encoding.TextMarshaler, so cards can appear in configs and APIs as
+// strings. poker.Card can't be given the methods itself, since it
+// belongs to another package.
+type TextCard poker.Card
+
+// TextCards converts cards to TextCards.
+func TextCards(c []poker.Card) []TextCard {
+	r := make([]TextCard, len(c))
+	for i, ci := range c {
+		r[i] = TextCard(ci)
+	}
+	return r
+}
+
+// Card returns the card.
+func (tc TextCard) Card() poker.Card {
+	return poker.Card(tc)
+}
+
+func (tc TextCard) String() string {
+	return poker.Card(tc).String()
+}
+
+// MarshalText encodes the card as its name.
+func (tc TextCard) MarshalText() ([]byte, error) {
+	if !poker.Card(tc).Valid() {
+		return nil, fmt.Errorf("invalid card %d", tc)
+	}
+	return []byte(poker.Card(tc).String()), nil
+}
+
+// UnmarshalText decodes a card's name, as ParseCard does.
+func (tc *TextCard) UnmarshalText(b []byte) error {
+	c, err := SuitFirst.Parse(string(b))
+	if err != nil {
+		return err
+	}
+	*tc = TextCard(c)
+	return nil
+}
+
+// A TextSuit is a suit which is encoded as its letter (C, D, H or S),
+// as TextCard is for cards.
+type TextSuit poker.Suit
+
+// MarshalText encodes the suit as its letter.
+func (ts TextSuit) MarshalText() ([]byte, error) {
+	if ts > TextSuit(poker.Spade) {
+		return nil, fmt.Errorf("invalid suit %d", ts)
+	}
+	return []byte(poker.Suit(ts).String()), nil
+}
+
+// UnmarshalText decodes a suit's letter or symbol, ignoring case and
+// surrounding spaces.
+func (ts *TextSuit) UnmarshalText(b []byte) error {
+	s, _, ok := suitOf(strings.TrimSpace(string(b)))
+	if !ok {
+		return fmt.Errorf("bad suit %q: want C, D, H or S", b)
+	}
+	*ts = TextSuit(s)
+	return nil
+}
+
+// A TextRank is a rank which is encoded as its letter (2-9, T, J, Q, K
+// or A), as TextCard is for cards.
+type TextRank poker.Rank
+
+// MarshalText encodes the rank as its letter.
+func (tr TextRank) MarshalText() ([]byte, error) {
+	if tr < 1 || tr > 13 {
+		return nil, fmt.Errorf("invalid rank %d", tr)
+	}
+	return []byte(poker.Rank(tr).String()), nil
+}
+
+// UnmarshalText decodes a rank's letter, or 10, ignoring case and
+// surrounding spaces.
+func (tr *TextRank) UnmarshalText(b []byte) error {
+	raw := rawRankOf(strings.TrimSpace(string(b)))
+	if raw < 0 {
+		return fmt.Errorf("bad rank %q: want 2-9, T, J, Q, K or A", b)
+	}
+	*tr = TextRank((raw+1)%13 + 1)
+	return nil
+}
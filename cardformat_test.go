@@ -0,0 +1,153 @@
+package cpoker
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/paulhankin/poker/v2/poker"
+	"reflect"
+	"testing"
+)
+
+func TestTextCardJSON(t *testing.T) {
+	c := mustCards(t, "HAC8DTS2")
+	b, err := json.Marshal(TextCards(c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `["HA","C8","DT","S2"]`; string(b) != want {
+		t.Errorf("encoded %v as %s, want %s", c, b, want)
+	}
+	var got []TextCard
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	for i, tc := range got {
+		if tc.Card() != c[i] {
+			t.Errorf("decoded %s, want %s", tc, c[i])
+		}
+	}
+	if err := json.Unmarshal([]byte(`["ha"," c8","Dt","S2"]`), &got); err != nil || len(got) != 4 || got[0].Card() != c[0] || got[2].Card() != c[2] {
+		t.Errorf("decoding cards ignoring case and spaces got %v, %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(`["HA","X9"]`), &got); err == nil {
+		t.Errorf("decoded a bad card name")
+	}
+	if _, err := json.Marshal(TextCard(52)); err == nil {
+		t.Errorf("encoded an invalid card")
+	}
+}
+
+func TestParseCards(t *testing.T) {
+	want := mustCards(t, "HAC8DTS2")
+	for _, s := range []string{"HA C8 DT S2", "HA,C8,DT,S2", " HA, C8,\tDT  S2 ,"} {
+		got, err := ParseCards(s)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseCards(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"HA X8", "HA HA", "HAC8"} {
+		if got, err := ParseCards(s); err == nil {
+			t.Errorf("ParseCards(%q) = %v, want an error", s, got)
+		}
+	}
+	if c, err := ParseCard(" HA "); err != nil || c != want[0] {
+		t.Errorf("ParseCard(\" HA \") = %v, %v, want %v", c, err, want[0])
+	}
+	if _, err := ParseCard(""); err == nil {
+		t.Errorf("ParseCard(\"\") succeeded")
+	}
+}
+
+func TestCardFormat(t *testing.T) {
+	c := mustCards(t, "HAC8DTS2")
+	for _, tc := range []struct {
+		f          CardFormat
+		want       string
+		alternates []string
+	}{
+		{SuitFirst, "HA C8 DT S2", []string{"ha c8 d10 s2"}},
+		{RankFirst, "Ah 8c Td 2s", []string{"AH 8C 10d 2S"}},
+		{Unicode, "A♥ 8♣ T♦ 2♠", []string{"a♡ 8♧ 10♢ 2♤"}},
+		{AnyFormat, "HA C8 DT S2", []string{"Ah, C8, T♦ 2s"}},
+	} {
+		if got := tc.f.FormatCards(c); got != tc.want {
+			t.Errorf("format %d: formatted %v as %q, want %q", tc.f, c, got, tc.want)
+		}
+		for _, s := range append([]string{tc.want}, tc.alternates...) {
+			got, err := tc.f.ParseCards(s)
+			if err != nil || !reflect.DeepEqual(got, c) {
+				t.Errorf("format %d: ParseCards(%q) = %v, %v, want %v", tc.f, s, got, err, c)
+			}
+		}
+	}
+	for _, tc := range []struct {
+		f CardFormat
+		s string
+	}{{SuitFirst, "Ah"}, {RankFirst, "HA"}, {RankFirst, "A♥"}, {Unicode, "Ah"}, {AnyFormat, "♥A"}, {AnyFormat, "H1"}} {
+		if c, err := tc.f.Parse(tc.s); err == nil {
+			t.Errorf("format %d: Parse(%q) = %v, want an error", tc.f, tc.s, c)
+		}
+	}
+}
+
+func TestCardOf(t *testing.T) {
+	want := mustCards(t, "HA")[0]
+	for _, s := range []string{"HA", "Ah", "A♥"} {
+		if got := CardOf(s); got != want {
+			t.Errorf("CardOf(%q) = %s, want %s", s, got, want)
+		}
+	}
+	if got := MustMakeCard(poker.Heart, 1); got != want {
+		t.Errorf("MustMakeCard(Heart, 1) = %s, want %s", got, want)
+	}
+	for name, f := range map[string]func(){
+		"CardOf(\"H1\")":         func() { CardOf("H1") },
+		"MustMakeCard(4, 1)":     func() { MustMakeCard(4, 1) },
+		"MustMakeCard(Heart, 0)": func() { MustMakeCard(poker.Heart, 0) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s didn't panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func TestTextSuitRankJSON(t *testing.T) {
+	type suitRank struct {
+		Suit TextSuit `json:"suit"`
+		Rank TextRank `json:"rank"`
+	}
+	for _, c := range poker.Cards {
+		sr := suitRank{TextSuit(c.Suit()), TextRank(c.Rank())}
+		b, err := json.Marshal(sr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprintf(`{"suit":"%s","rank":"%s"}`, c.String()[:1], c.String()[1:]); string(b) != want {
+			t.Errorf("encoded %s as %s, want %s", c, b, want)
+		}
+		var got suitRank
+		if err := json.Unmarshal(b, &got); err != nil || got != sr {
+			t.Errorf("decoded %s as %+v, %v, want %+v", b, got, err, sr)
+		}
+	}
+	var got suitRank
+	if err := json.Unmarshal([]byte(`{"suit":"h","rank":"10"}`), &got); err != nil || got.Suit != TextSuit(poker.Heart) || got.Rank != 10 {
+		t.Errorf("decoded h and 10 as %+v, %v", got, err)
+	}
+	for _, bad := range []string{`{"suit":"X"}`, `{"rank":"1"}`} {
+		if err := json.Unmarshal([]byte(bad), &got); err == nil {
+			t.Errorf("decoded %s", bad)
+		}
+	}
+	if _, err := json.Marshal(suitRank{Suit: 7, Rank: 1}); err == nil {
+		t.Errorf("encoded an invalid suit")
+	}
+	if _, err := json.Marshal(suitRank{Rank: 0}); err == nil {
+		t.Errorf("encoded an invalid rank")
+	}
+}
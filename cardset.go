@@ -0,0 +1,54 @@
+package cpoker
+
+import (
+	"math/bits"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// A CardSet is a set of cards, represented as a bitmask.
+type CardSet uint64
+
+// NewCardSet returns the set of the given cards, and whether
+// the cards were all valid and distinct.
+func NewCardSet(c []poker.Card) (CardSet, bool) {
+	var cs CardSet
+	for _, ci := range c {
+		if !ci.Valid() || cs.Contains(ci) {
+			return cs, false
+		}
+		cs = cs.Add(ci)
+	}
+	return cs, true
+}
+
+// Add returns the set with c added.
+func (cs CardSet) Add(c poker.Card) CardSet {
+	return cs | 1<<c
+}
+
+// Remove returns the set with c removed.
+func (cs CardSet) Remove(c poker.Card) CardSet {
+	return cs &^ (1 << c)
+}
+
+// Contains reports whether c is in the set.
+func (cs CardSet) Contains(c poker.Card) bool {
+	return cs&(1<<c) != 0
+}
+
+// Len returns the number of cards in the set.
+func (cs CardSet) Len() int {
+	return bits.OnesCount64(uint64(cs))
+}
+
+// Cards returns the cards in the set, in the same order as poker.Cards.
+func (cs CardSet) Cards() []poker.Card {
+	var r []poker.Card
+	for _, c := range poker.Cards {
+		if cs.Contains(c) {
+			r = append(r, c)
+		}
+	}
+	return r
+}
@@ -0,0 +1,192 @@
+package cpoker
+
+import "github.com/paulhankin/poker/v2/poker"
+
+// A HandCategory is a coarse class of poker hand, such as a pair
+// or a flush.
+type HandCategory int
+
+// The categories of hand, from weakest to strongest.
+const (
+	HighCard HandCategory = iota
+	Pair
+	TwoPair
+	Trips
+	Straight
+	Flush
+	FullHouse
+	Quads
+	StraightFlush
+	FiveOfAKind
+)
+
+var categoryNames = [...]string{"high card", "pair", "two pair", "trips", "straight", "flush", "full house", "quads", "straight flush", "five of a kind"}
+
+func (hc HandCategory) String() string {
+	if hc < 0 || int(hc) >= len(categoryNames) {
+		return "?"
+	}
+	return categoryNames[hc]
+}
+
+// The smallest score (from Eval3 or Eval5) of a hand in each category.
+// Scores in a category go up to one less than the next category's
+// smallest score, and Flush and above only come from 5-card hands.
+const (
+	MinScoreHighCard      int16 = 0
+	MinScorePair          int16 = 1563
+	MinScoreTwoPair       int16 = 4579
+	MinScoreTrips         int16 = 5437
+	MinScoreStraight      int16 = 6308
+	MinScoreFlush         int16 = 6318
+	MinScoreFullHouse     int16 = 7595
+	MinScoreQuads         int16 = 7751
+	MinScoreStraightFlush int16 = 7907
+	MinScoreFiveOfAKind   int16 = 7917
+)
+
+var minScores = [...]int16{
+	MinScoreHighCard, MinScorePair, MinScoreTwoPair, MinScoreTrips, MinScoreStraight,
+	MinScoreFlush, MinScoreFullHouse, MinScoreQuads, MinScoreStraightFlush, MinScoreFiveOfAKind,
+}
+
+// Category returns the category of hands with the given score, from
+// Eval3 or Eval5, or -1 if the score is out of range.
+func Category(score int16) HandCategory {
+	if score < 0 || score > poker.ScoreMax {
+		return -1
+	}
+	c := FiveOfAKind
+	for score < minScores[c] {
+		c--
+	}
+	return c
+}
+
+// MinScore returns the smallest score of a hand in the category, or
+// -1 if there's no such category.
+func (hc HandCategory) MinScore() int16 {
+	if hc < 0 || int(hc) >= len(minScores) {
+		return -1
+	}
+	return minScores[hc]
+}
+
+// rankShape returns the category of a 3- or 5-card hand and the raw rank
+// (2->0, ..., A->12) of its most significant card: the top card of a
+// straight, or the largest group of matched cards otherwise.
+func rankShape(c []poker.Card) (class HandCategory, top int) {
+	var counts [13]int
+	flush := len(c) == 5
+	for _, ci := range c {
+		counts[ci.RawRank()]++
+		if ci.Suit() != c[0].Suit() {
+			flush = false
+		}
+	}
+	var groups [6]int
+	top, topN := -1, 0
+	for r, n := range counts {
+		groups[n]++
+		if n > 0 && n >= topN {
+			top, topN = r, n
+		}
+	}
+	straight := false
+	if len(c) == 5 && groups[1] == 5 {
+		lo := 0
+		for counts[lo] == 0 {
+			lo++
+		}
+		if top-lo == 4 {
+			straight = true
+		} else if counts[12] == 1 && counts[0] == 1 && counts[1] == 1 && counts[2] == 1 && counts[3] == 1 {
+			straight, top = true, 3 // The wheel: A2345.
+		}
+	}
+	switch {
+	case groups[5] == 1:
+		return FiveOfAKind, top
+	case straight && flush:
+		return StraightFlush, top
+	case groups[4] == 1:
+		return Quads, top
+	case groups[3] == 1 && groups[2] == 1:
+		return FullHouse, top
+	case flush:
+		return Flush, top
+	case straight:
+		return Straight, top
+	case groups[3] == 1:
+		return Trips, top
+	case groups[2] == 2:
+		return TwoPair, top
+	case groups[2] == 1:
+		return Pair, top
+	}
+	return HighCard, top
+}
+
+// categories[0] and categories[1] map Eval3 and Eval5 ranks
+// to their categories, or -1 if there's no hand with that rank.
+var categories = func() (r [2][poker.ScoreMax + 1]HandCategory) {
+	for e := range r[0] {
+		r[0][e], r[1][e] = -1, -1
+		if h, ok := poker.EvalToHand3(int16(e)); ok {
+			r[0][e], _ = rankShape(h)
+		}
+		if h, ok := poker.EvalToHand5(int16(e)); ok {
+			r[1][e], _ = rankShape(h)
+		}
+	}
+	return r
+}()
+
+// slotCategory returns the category of a hand of rank e in slot i.
+func slotCategory(i int, e int16) HandCategory {
+	if i == 0 {
+		return categories[0][e]
+	}
+	return categories[1][e]
+}
+
+var (
+	reachableInit tableInit
+	reachable     [2][]bool // of 3-card and 5-card ranks
+)
+
+// Reachable reports whether a hand of rank e can be played in slot i
+// (0, 1, 2 means front, middle, back). The reachable ranks are those of
+// the hands that can be dealt from one deck, which are found from the
+// exact hand counts: 3-card ranks in the front, and 5-card ranks in the
+// middle and back. Every such hand can be played legally in its slots,
+// since the other 5-card slot can hold a hand of the same rank in other
+// suits (or a stronger one), and the front a weaker hand. So the middle
+// and back share the same ranks, from 7-5-4-3-2 to a royal flush.
+func Reachable(i int, e int16) bool {
+	if i < 0 || i > 2 || e < 0 || e > poker.ScoreMax {
+		return false
+	}
+	reachableInit.Do(func() {
+		for j := range reachable {
+			counts := countHands(j)
+			reachable[j] = make([]bool, len(counts))
+			for r, n := range counts {
+				reachable[j][r] = n > 0
+			}
+		}
+	})
+	return reachable[b2i(i > 0)][e]
+}
+
+// ReachableRanks returns the ranks which can be played in slot i,
+// in increasing order.
+func ReachableRanks(i int) []int16 {
+	var r []int16
+	for e := int16(0); e <= poker.ScoreMax; e++ {
+		if Reachable(i, e) {
+			r = append(r, e)
+		}
+	}
+	return r
+}
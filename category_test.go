@@ -0,0 +1,87 @@
+package cpoker
+
+import (
+	"testing"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+func TestCategory(t *testing.T) {
+	for _, tc := range []struct {
+		cards string
+		want  HandCategory
+	}{
+		{"H2C3D5", HighCard},
+		{"H2C2D3", Pair},
+		{"HAHKHQHJH9", Flush},
+		{"C2D2H2S2C3", Quads},
+		{"HAHKHQ", HighCard},
+		{"HTHJHQHKHA", StraightFlush},
+		{"C8D8H9S9CQ", TwoPair},
+	} {
+		c := mustCards(t, tc.cards)
+		if got := Category(eval(c)); got != tc.want {
+			t.Errorf("Category(%s) = %s, want %s", tc.cards, got, tc.want)
+		}
+	}
+	// The boundaries agree with the categories of every hand.
+	for k := 0; k < 2; k++ {
+		for e, c := range categories[k] {
+			if c >= 0 && Category(int16(e)) != c {
+				t.Errorf("Category(%d) = %s, but there's a %d-card %s with that score", e, Category(int16(e)), 3+2*k, c)
+			}
+		}
+	}
+	for hc := HighCard; hc <= FiveOfAKind; hc++ {
+		if Category(hc.MinScore()) != hc || hc > HighCard && Category(hc.MinScore()-1) != hc-1 {
+			t.Errorf("%s starts at score %d", hc, hc.MinScore())
+		}
+	}
+	for _, e := range []int16{-1, poker.ScoreMax + 1} {
+		if c := Category(e); c != -1 {
+			t.Errorf("Category(%d) = %s, want -1", e, c)
+		}
+	}
+}
+
+func TestReachable(t *testing.T) {
+	// A rank is reachable in a slot exactly when hands of that size with
+	// that rank can be dealt.
+	for i, counts := range [][]int{HandCounts3(), HandCounts5(), HandCounts5()} {
+		want := 0
+		for e, n := range counts {
+			if got := Reachable(i, int16(e)); got != (n > 0) {
+				t.Errorf("Reachable(%d, %d) = %v, but %d hands have that rank", i, e, got, n)
+			}
+			want += b2i(n > 0)
+		}
+		if got := len(ReachableRanks(i)); got != want {
+			t.Errorf("%d ranks are reachable in slot %d, want %d", got, i, want)
+		}
+	}
+	if n3, n5 := len(ReachableRanks(0)), len(ReachableRanks(2)); n3 != 455 || n5 != 7462 {
+		t.Errorf("got %d front and %d back ranks, want 455 and 7462", n3, n5)
+	}
+	// The weakest hand can be played in the back, and the strongest in
+	// the middle, in legal arrangements.
+	for _, tc := range []struct {
+		h    *Hand
+		slot int
+	}{
+		{mustHand(t, "S6H5H4", "H7D5C4S3H2", "D7C5S4H3D2"), 2},
+		{mustHand(t, "H2D3C4", "HAHKHQHJHT", "SASKSQSJST"), 1},
+	} {
+		r := tc.h.ranks()
+		if r[0] > r[1] || r[1] > r[2] {
+			t.Fatalf("%s fouls", tc.h)
+		}
+		if !Reachable(tc.slot, r[tc.slot]) {
+			t.Errorf("rank %d of %s isn't reachable in slot %d", r[tc.slot], tc.h, tc.slot)
+		}
+	}
+	for _, e := range []int16{-1, poker.ScoreMax + 1, MinScoreFiveOfAKind} {
+		if Reachable(1, e) {
+			t.Errorf("Reachable(1, %d) = true, want false", e)
+		}
+	}
+}
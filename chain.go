@@ -0,0 +1,164 @@
+package cpoker
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// An OpponentChain is the sequence of evaluators made by the cycles of
+// fictitious-play training, each trained against those before it. As a
+// HandEvaluator, it plays the average strategy of the chain: each deal
+// is played by one of the members, chosen uniformly by a hash of the
+// cards, so the same deal is always played the same way. Unlike the
+// averaged win probabilities of TrainSampledEvaluator, this is an
+// average over strategies, and can be reconstructed exactly from the
+// saved chain.
+type OpponentChain struct {
+	// Scoring is how the members value hands. If nil, Scoring2to4 is
+	// used. It isn't saved with the chain.
+	Scoring *Scoring
+
+	Members []*SampledEvaluator
+}
+
+// ChainFormat is the version of the chain format written by
+// OpponentChain.Marshal.
+const ChainFormat = 1
+
+// chainManifest is the name of the manifest of a saved chain. It's
+// different to a bundle's, so neither can be mistaken for the other.
+const chainManifest = "chain.json"
+
+// chainManifestJSON is the manifest of a saved chain.
+type chainManifestJSON struct {
+	Format  int           `json:"format"`
+	Table   TableMetadata `json:"table"`
+	Members int           `json:"members"`
+}
+
+// chainMember returns the name of the file holding member i of a
+// saved chain.
+func chainMember(i int) string {
+	return fmt.Sprintf("member-%d.data", i)
+}
+
+// Add adds an evaluator to the end of the chain.
+func (oc *OpponentChain) Add(se *SampledEvaluator) {
+	oc.Members = append(oc.Members, se)
+}
+
+// Evaluator returns a hand evaluator for the given set of cards, from
+// the member of the chain that plays them. The chain must have at least
+// one member.
+func (oc *OpponentChain) Evaluator(c []poker.Card) func(f, m, b int16) float64 {
+	s, _ := NewCardSet(c)
+	se := *oc.Members[mix64(uint64(s))%uint64(len(oc.Members))]
+	se.Scoring = oc.Scoring
+	return se.evaluateHand
+}
+
+// Marshal writes the chain as a zip archive containing a chain.json,
+// which records the number of members and the rank tables, and the
+// members in order as member-0.data, member-1.data and so on, each as
+// written by SampledEvaluator.Marshal.
+func (oc *OpponentChain) Marshal(w io.Writer) error {
+	if len(oc.Members) == 0 {
+		return errors.New("chain has no members")
+	}
+	zw := zip.NewWriter(w)
+	mw, err := zw.Create(chainManifest)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(mw)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(chainManifestJSON{Format: ChainFormat, Table: TableInfo(), Members: len(oc.Members)}); err != nil {
+		return err
+	}
+	for i, se := range oc.Members {
+		ew, err := zw.Create(chainMember(i))
+		if err != nil {
+			return err
+		}
+		if err := se.Marshal(ew); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// Save writes the chain to a named file, replacing it atomically.
+func (oc *OpponentChain) Save(filename string) error {
+	var buf bytes.Buffer
+	if err := oc.Marshal(&buf); err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, buf.Bytes())
+}
+
+// UnmarshalOpponentChain reads a chain written by OpponentChain.Marshal.
+// It's an error if the chain was written in a newer format, or the rank
+// tables are different now.
+func UnmarshalOpponentChain(r io.ReaderAt, size int64) (*OpponentChain, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	if files[chainManifest] == nil {
+		return nil, fmt.Errorf("chain has no %s", chainManifest)
+	}
+	var m chainManifestJSON
+	if err := readBundleFile(files[chainManifest], func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&m)
+	}); err != nil {
+		return nil, err
+	}
+	if m.Format > ChainFormat {
+		return nil, fmt.Errorf("chain has format %d, but only formats up to %d are supported", m.Format, ChainFormat)
+	}
+	if m.Table.Checksum != TableChecksum() {
+		return nil, fmt.Errorf("chain was made with different rank tables (checksum %s, want %s)", m.Table.Checksum, TableChecksum())
+	}
+	if m.Members < 1 {
+		return nil, errors.New("chain has no members")
+	}
+	oc := &OpponentChain{}
+	for i := 0; i < m.Members; i++ {
+		f := files[chainMember(i)]
+		if f == nil {
+			return nil, fmt.Errorf("chain has no %s", chainMember(i))
+		}
+		if err := readBundleFile(f, func(r io.Reader) error {
+			se, err := UnmarshalSampledEvaluator(r)
+			oc.Add(se)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return oc, nil
+}
+
+// LoadOpponentChain reads a chain from a named file.
+func LoadOpponentChain(filename string) (*OpponentChain, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	oc, err := UnmarshalOpponentChain(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+	return oc, nil
+}
@@ -0,0 +1,72 @@
+package cpoker
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestOpponentChain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	oc := &OpponentChain{Scoring: ScoringHK}
+	oc.Add(smallSampledEvaluator(t, 100))
+	oc.Add(NewTrainedSampledEvaluatorWithScoring(oc, 100, ScoringHK))
+	oc.Add(smallSampledEvaluator(t, 300))
+	filename := filepath.Join(dir, "opponents.chain")
+	if err := oc.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadOpponentChain(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Members) != 3 {
+		t.Fatalf("loaded %d members, want 3", len(got.Members))
+	}
+	for i, se := range got.Members {
+		if !reflect.DeepEqual(se.WinProbabilities(2), oc.Members[i].WinProbabilities(2)) {
+			t.Errorf("member %d differs after loading", i)
+		}
+	}
+	// The loaded chain plays every deal the same way, and different
+	// deals are played by different members.
+	got.Scoring = ScoringHK
+	rnd := rand.New(rand.NewSource(8))
+	used := map[int]bool{}
+	for i := 0; i < 30; i++ {
+		c := randomDeal(rnd)
+		h0, _ := Play(c, oc)
+		h1, _ := Play(c, got)
+		if h0.Key() != h1.Key() {
+			t.Errorf("the chain played %v, but the loaded chain played %v", &h0, &h1)
+		}
+		r := h0.ranks()
+		v := oc.Evaluator(c)(r[0], r[1], r[2])
+		for m, se := range oc.Members {
+			if se.Scoring = ScoringHK; se.Evaluator(c)(r[0], r[1], r[2]) == v {
+				used[m] = true
+			}
+		}
+	}
+	if len(used) < 2 {
+		t.Errorf("only members %v played deals", used)
+	}
+	if err := (&OpponentChain{}).Marshal(ioutil.Discard); err == nil {
+		t.Errorf("wrote a chain without members")
+	}
+	var b bytes.Buffer
+	if err := (&Bundle{Evaluator: oc.Members[0]}).Marshal(&b); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UnmarshalOpponentChain(bytes.NewReader(b.Bytes()), int64(b.Len())); err == nil {
+		t.Errorf("read a bundle as a chain")
+	}
+}
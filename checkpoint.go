@@ -0,0 +1,60 @@
+package cpoker
+
+import (
+	"fmt"
+	"os"
+)
+
+// Checkpoints saves the evaluators produced during training, keeping
+// the latest few, and separately the best so far, so that a bad
+// late training cycle can't overwrite the strongest evaluator found.
+type Checkpoints struct {
+	// Filename is the base name of the files. The checkpoint after
+	// cycle n is saved as Filename.cycle-n, and the best as Filename.best.
+	Filename string
+
+	// Keep is how many of the latest checkpoints to keep, or 0 to
+	// keep them all. The best checkpoint is always kept.
+	Keep int
+
+	BestCycle int     // The cycle of the best checkpoint, or 0 if there's none yet
+	BestScore float64 // The score of the best checkpoint
+
+	saved []string
+}
+
+// CycleFilename returns the name of the checkpoint after a cycle.
+func (cp *Checkpoints) CycleFilename(cycle int) string {
+	return fmt.Sprintf("%s.cycle-%d", cp.Filename, cycle)
+}
+
+// BestFilename returns the name of the best checkpoint.
+func (cp *Checkpoints) BestFilename() string {
+	return cp.Filename + ".best"
+}
+
+// Save saves the evaluator produced by a training cycle, which has
+// the given score (higher is better, for example the EV against a
+// benchmark), and removes the oldest checkpoints beyond Keep. It
+// reports whether the evaluator is the best so far.
+func (cp *Checkpoints) Save(se *SampledEvaluator, cycle int, score float64) (bool, error) {
+	filename := cp.CycleFilename(cycle)
+	if err := se.Save(filename); err != nil {
+		return false, err
+	}
+	cp.saved = append(cp.saved, filename)
+	for cp.Keep > 0 && len(cp.saved) > cp.Keep {
+		if err := os.Remove(cp.saved[0]); err != nil && !os.IsNotExist(err) {
+			return false, err
+		}
+		cp.saved = cp.saved[1:]
+	}
+	if cp.BestCycle != 0 && score <= cp.BestScore {
+		return false, nil
+	}
+	if err := se.Save(cp.BestFilename()); err != nil {
+		return false, err
+	}
+	cp.BestCycle, cp.BestScore = cycle, score
+	return true, nil
+}
@@ -0,0 +1,39 @@
+package cpoker
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCheckpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoints")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	se := smallSampledEvaluator(t, 100)
+	cp := &Checkpoints{Filename: filepath.Join(dir, "c.data"), Keep: 2}
+	for i, score := range []float64{1, 3, 2} {
+		best, err := cp.Save(se, i+1, score)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := i < 2; best != want {
+			t.Errorf("cycle %d: best = %v, want %v", i+1, best, want)
+		}
+	}
+	if cp.BestCycle != 2 || cp.BestScore != 3 {
+		t.Errorf("best is cycle %d with score %f, want cycle 2 with score 3", cp.BestCycle, cp.BestScore)
+	}
+	var names []string
+	files, _ := ioutil.ReadDir(dir)
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	if want := []string{"c.data.best", "c.data.cycle-2", "c.data.cycle-3"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got files %v, want %v", names, want)
+	}
+}
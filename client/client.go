@@ -0,0 +1,239 @@
+// Package client talks to chinese poker engines using the engine
+// protocol (see cpoker.ServeEngine), so programs can use an engine
+// without handling the protocol themselves. For example:
+//
+//	c, err := client.Start("engine -from coefficients.data", time.Minute)
+//	if err != nil {
+//		log.Fatalf("failed to start engine: %s", err)
+//	}
+//	defer c.Close()
+//	h, err := c.Play(cards, client.Options{Time: time.Second})
+package client
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/paulhankin/cpoker"
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// ErrTimeout is returned when an engine doesn't reply in time. After a
+// timeout, replies may be out of step with requests, so the client
+// can't be used any more.
+var ErrTimeout = errors.New("engine ran out of time")
+
+// A Client is a connection to an engine.
+type Client struct {
+	Name string // The name of the engine
+
+	// Timeout is how long to wait for a reply. If it's zero, requests
+	// wait for as long as it takes.
+	Timeout time.Duration
+
+	in     io.WriteCloser
+	lines  chan string
+	cmd    *exec.Cmd
+	broken error
+
+	// done is closed when no more replies will be read, after a
+	// timeout or when the client is closed. Lines the engine writes
+	// after that are discarded, so it never blocks writing them.
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// New connects to an engine which reads requests from w and writes
+// replies to r. It waits up to startTimeout for the engine's handshake.
+func New(r io.Reader, w io.WriteCloser, startTimeout time.Duration) (*Client, error) {
+	c := &Client{in: w, lines: make(chan string), done: make(chan struct{})}
+	go func() {
+		s := bufio.NewScanner(r)
+		for s.Scan() {
+			select {
+			case c.lines <- s.Text():
+			case <-c.done:
+			}
+		}
+		close(c.lines)
+	}()
+	c.Timeout = startTimeout
+	reply, err := c.request(cpoker.EngineProtocol)
+	c.Timeout = 0
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(reply, "ok ") {
+		return nil, fmt.Errorf("bad handshake %q", reply)
+	}
+	c.Name = reply[len("ok "):]
+	return c, nil
+}
+
+// Start runs an engine process from a command line, and connects to it.
+// The engine's standard error is passed through to this process's.
+func Start(cmdline string, startTimeout time.Duration) (*Client, error) {
+	args := strings.Fields(cmdline)
+	if len(args) == 0 {
+		return nil, errors.New("empty command line")
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = os.Stderr
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	c, err := New(out, in, startTimeout)
+	if err != nil {
+		in.Close()
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, err
+	}
+	c.cmd = cmd
+	return c, nil
+}
+
+func (c *Client) request(line string) (string, error) {
+	if c.broken != nil {
+		return "", c.broken
+	}
+	if _, err := fmt.Fprintln(c.in, line); err != nil {
+		c.broken = err
+		return "", err
+	}
+	var timeout <-chan time.Time
+	if c.Timeout > 0 {
+		timeout = time.After(c.Timeout)
+	}
+	select {
+	case reply, ok := <-c.lines:
+		if !ok {
+			c.broken = errors.New("engine exited")
+			return "", c.broken
+		}
+		return reply, nil
+	case <-timeout:
+		c.broken = ErrTimeout
+		c.stop()
+		return "", c.broken
+	}
+}
+
+// stop stops replies being read, so the late reply to a request which
+// timed out can't be mistaken for the reply to a later one.
+func (c *Client) stop() {
+	c.stopOnce.Do(func() { close(c.done) })
+}
+
+// Options limit the work an engine does for a deal. Zero values
+// mean the engine's own limits and default evaluator are used.
+type Options struct {
+	MaxHands  int           // The most hands to evaluate
+	Time      time.Duration // How long to spend
+	Evaluator string        // The name of the evaluator to use
+}
+
+// String formats the options as they're sent with a deal.
+func (o Options) String() string {
+	var r []string
+	if o.MaxHands > 0 {
+		r = append(r, fmt.Sprintf("hands=%d", o.MaxHands))
+	}
+	if o.Time > 0 {
+		r = append(r, "time="+o.Time.String())
+	}
+	if o.Evaluator != "" {
+		r = append(r, "evaluator="+o.Evaluator)
+	}
+	return strings.Join(r, " ")
+}
+
+// Play asks the engine to play 13 cards. The hand returned is checked
+// to be a legal arrangement of the cards. If the engine passes the deal,
+// the error is cpoker.ErrPassed.
+func (c *Client) Play(cards []poker.Card, opts Options) (cpoker.Hand, error) {
+	line := "deal " + poker.Hand(cards).String()
+	if o := opts.String(); o != "" {
+		line += " " + o
+	}
+	reply, err := c.request(line)
+	if err != nil {
+		return cpoker.Hand{}, err
+	}
+	h, err := cpoker.ParseEngineHand(reply)
+	if err != nil {
+		return h, err
+	}
+	return h, cpoker.CheckHand(&h, cards)
+}
+
+func (c *Client) admin(line string) error {
+	reply, err := c.request(line)
+	if err != nil {
+		return err
+	}
+	if reply != "ok" {
+		return fmt.Errorf("%s: %s", line, strings.TrimPrefix(reply, "error "))
+	}
+	return nil
+}
+
+// Load asks the engine to load an evaluator from a file.
+func (c *Client) Load(name, filename string) error {
+	return c.admin("load " + name + " " + filename)
+}
+
+// Unload asks the engine to remove an evaluator.
+func (c *Client) Unload(name string) error {
+	return c.admin("unload " + name)
+}
+
+// Reload asks the engine to load an evaluator again from its file.
+func (c *Client) Reload(name string) error {
+	return c.admin("reload " + name)
+}
+
+// Observe tells the engine how an opponent played a hand.
+func (c *Client) Observe(h *cpoker.Hand) error {
+	return c.admin("observe " + strings.TrimPrefix(cpoker.FormatEngineHand(h), "hand "))
+}
+
+// Result tells an engine which is running an A/B test how many
+// points it scored with the hand it last played.
+func (c *Client) Result(points float64) error {
+	return c.admin(fmt.Sprintf("result %g", points))
+}
+
+// Close ends the session, and if the client started the engine,
+// waits for it to exit.
+func (c *Client) Close() error {
+	if c.broken == nil {
+		fmt.Fprintln(c.in, "quit")
+	}
+	err := c.in.Close()
+	c.stop()
+	if c.cmd != nil {
+		if c.broken != nil {
+			c.cmd.Process.Kill()
+		}
+		if werr := c.cmd.Wait(); err == nil && c.broken == nil {
+			err = werr
+		}
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/paulhankin/cpoker"
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+func TestClientPlay(t *testing.T) {
+	reqR, reqW := io.Pipe()
+	repR, repW := io.Pipe()
+	e := &cpoker.Engine{Name: "test", Evaluators: map[string]cpoker.HandEvaluator{"default": cpoker.MaxProdEvaluator{}}, Admin: true}
+	done := make(chan error)
+	go func() {
+		err := e.Serve(reqR, repW)
+		repW.Close()
+		done <- err
+	}()
+	c, err := New(repR, reqW, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "test" {
+		t.Errorf("got name %q, want %q", c.Name, "test")
+	}
+	cards := append([]poker.Card{}, poker.Cards...)
+	rand.New(rand.NewSource(1)).Shuffle(len(cards), func(i, j int) { cards[i], cards[j] = cards[j], cards[i] })
+	if _, err := c.Play(cards[:13], Options{MaxHands: 10, Time: time.Second}); err != nil {
+		t.Errorf("Play failed: %s", err)
+	}
+	if err := c.Unload("missing"); err == nil {
+		t.Errorf("Unload of a missing evaluator succeeded")
+	}
+	if _, err := c.Play(cards[:13], Options{Evaluator: "default"}); err != nil {
+		t.Errorf("Play after admin error failed: %s", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Errorf("Close failed: %s", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("engine failed: %s", err)
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	reqR, reqW := io.Pipe()
+	repR, repW := io.Pipe()
+	go io.Copy(ioutil.Discard, reqR)
+	go io.WriteString(repW, "ok slow\n")
+	c, err := New(repR, reqW, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Timeout = time.Millisecond
+	if err := c.Unload("default"); err != ErrTimeout {
+		t.Fatalf("got %v, want a timeout", err)
+	}
+	// The engine's late replies are read and discarded, rather than
+	// blocking it, or being taken as replies to later requests.
+	for i := 0; i < 3; i++ {
+		if _, err := io.WriteString(repW, "ok\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Unload("default"); err != ErrTimeout {
+		t.Errorf("after a timeout, got %v, want the timeout again", err)
+	}
+	repW.Close()
+	c.Close()
+}
@@ -0,0 +1,106 @@
+package cpoker
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// A SeatHand is a hand played by a player against an opponent, as
+// recorded by an operator running games.
+type SeatHand struct {
+	Player   string
+	Opponent string
+	Cards    []poker.Card // The 13 cards dealt to the player
+	Hand     Hand         // How the player arranged them
+}
+
+// A SoftPlayFlag marks a player who plays unusually badly against a
+// particular opponent, which may be a sign of collusion.
+type SoftPlayFlag struct {
+	Player, Opponent string
+	Hands            int     // How many hands the player played against the opponent
+	MeanLoss         float64 // The mean EV given up per hand against the opponent
+	OtherHands       int     // How many hands the player played against others
+	OtherMeanLoss    float64 // The mean EV given up per hand against others
+	Z                float64 // How many standard errors MeanLoss exceeds OtherMeanLoss by
+}
+
+func (f SoftPlayFlag) String() string {
+	return fmt.Sprintf("%s vs %s: loses %.3f per hand over %d hands, against %.3f over %d hands vs others (z=%.2f)",
+		f.Player, f.Opponent, f.MeanLoss, f.Hands, f.OtherMeanLoss, f.OtherHands, f.Z)
+}
+
+// lossStats accumulates the EV lost on a set of hands.
+type lossStats struct {
+	n          int
+	sum, sumSq float64
+}
+
+func (ls *lossStats) add(x float64) {
+	ls.n++
+	ls.sum += x
+	ls.sumSq += x * x
+}
+
+func (ls *lossStats) mean() float64 { return ls.sum / float64(ls.n) }
+
+func (ls *lossStats) variance() float64 {
+	if ls.n < 2 {
+		return 0
+	}
+	return math.Max(0, (ls.sumSq-ls.sum*ls.sum/float64(ls.n))/float64(ls.n-1))
+}
+
+// DetectSoftPlay measures how much EV (according to he) each player gives
+// up on each hand, compared to the best arrangement. It flags each pair
+// of player and opponent with at least minHands hands where the player
+// gives up more against that opponent than against everyone else, by at
+// least z standard errors. Flags are returned with the most
+// suspicious first.
+func DetectSoftPlay(hands []SeatHand, he HandEvaluator, minHands int, z float64) ([]SoftPlayFlag, error) {
+	type pair struct{ player, opponent string }
+	byPair := map[pair]*lossStats{}
+	byPlayer := map[string]*lossStats{}
+	for i, sh := range hands {
+		if err := CheckHand(&sh.Hand, sh.Cards); err != nil {
+			return nil, fmt.Errorf("hand %d: %s", i, err)
+		}
+		ev := he.Evaluator(sh.Cards)
+		best, _ := Play(sh.Cards, fixedEvaluator(ev))
+		br, pr := best.ranks(), sh.Hand.ranks()
+		loss := math.Max(0, ev(br[0], br[1], br[2])-ev(pr[0], pr[1], pr[2]))
+		p := pair{sh.Player, sh.Opponent}
+		if byPair[p] == nil {
+			byPair[p] = &lossStats{}
+		}
+		if byPlayer[sh.Player] == nil {
+			byPlayer[sh.Player] = &lossStats{}
+		}
+		byPair[p].add(loss)
+		byPlayer[sh.Player].add(loss)
+	}
+	var flags []SoftPlayFlag
+	for p, ls := range byPair {
+		all := byPlayer[p.player]
+		other := lossStats{n: all.n - ls.n, sum: all.sum - ls.sum, sumSq: all.sumSq - ls.sumSq}
+		if ls.n < minHands || other.n < 2 {
+			continue
+		}
+		se := math.Sqrt(ls.variance()/float64(ls.n) + other.variance()/float64(other.n))
+		diff := ls.mean() - other.mean()
+		if se == 0 || diff/se < z {
+			continue
+		}
+		flags = append(flags, SoftPlayFlag{
+			Player: p.player, Opponent: p.opponent,
+			Hands: ls.n, MeanLoss: ls.mean(),
+			OtherHands: other.n, OtherMeanLoss: other.mean(),
+			Z: diff / se,
+		})
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Z > flags[j].Z })
+	return flags, nil
+}
@@ -0,0 +1,34 @@
+package cpoker
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestDetectSoftPlay(t *testing.T) {
+	rnd := rand.New(rand.NewSource(8))
+	var hands []SeatHand
+	for i := 0; i < 300; i++ {
+		c := randomDeal(rnd)
+		opp := []string{"b", "c", "d"}[i%3]
+		h, _ := Play(c, MaxProdEvaluator{})
+		if opp == "b" {
+			// Against b, a throws away the front.
+			as := Arrangements(c)
+			h = as[0].Hand
+			for _, a := range as {
+				if a.Ranks[0] < h.ranks()[0] {
+					h = a.Hand
+				}
+			}
+		}
+		hands = append(hands, SeatHand{Player: "a", Opponent: opp, Cards: c, Hand: h})
+	}
+	flags, err := DetectSoftPlay(hands, MaxProdEvaluator{}, 50, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flags) != 1 || flags[0].Player != "a" || flags[0].Opponent != "b" {
+		t.Errorf("got flags %v, want just a vs b", flags)
+	}
+}
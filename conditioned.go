@@ -0,0 +1,314 @@
+package cpoker
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// NumDealClasses is the number of classes DealClass puts deals in.
+const NumDealClasses = 10
+
+// DealClass returns a coarse class of a deal, from 0 to NumDealClasses-1,
+// from the number of ranks the cards pair (0 to 4 or more), and whether
+// five or more of them are one suit. The class of a deal affects what
+// the opponent can be dealt: for example, when you hold many pairs, the
+// opponent holds fewer.
+func DealClass(cs []poker.Card) int {
+	var ranks [13]int
+	var suits [4]int
+	for _, c := range cs {
+		ranks[c.RawRank()]++
+		suits[c.Suit()]++
+	}
+	pairs := 0
+	for _, n := range ranks {
+		pairs += b2i(n >= 2)
+	}
+	if pairs > 4 {
+		pairs = 4
+	}
+	flush := false
+	for _, n := range suits {
+		flush = flush || n >= 5
+	}
+	return 2*pairs + b2i(flush)
+}
+
+// DealClassString describes a class returned by DealClass.
+func DealClassString(class int) string {
+	if class < 0 || class >= NumDealClasses {
+		return "?"
+	}
+	s := fmt.Sprintf("%d pairs", class/2)
+	if class/2 == 4 {
+		s = "4+ pairs"
+	}
+	if class%2 == 1 {
+		s += ", flush draw"
+	}
+	return s
+}
+
+// A ConditionedEvaluator is like a SampledEvaluator, but it has separate
+// win probabilities for each DealClass of the cards being played, which
+// capture how your own deal changes the hands the opponent plays. Classes
+// which were seen too rarely in training use the win probabilities of
+// all the samples together.
+type ConditionedEvaluator struct {
+	// Scoring is how hands are valued. If nil, Scoring2to4 is used.
+	// It isn't saved with the evaluator.
+	Scoring *Scoring
+
+	classes [NumDealClasses]*SampledEvaluator // nil if the class has too few samples
+	pooled  *SampledEvaluator
+}
+
+// Class returns the win probabilities used for deals of the given
+// class, which are the pooled ones if the class was seen too rarely
+// in training.
+func (ce *ConditionedEvaluator) Class(class int) *SampledEvaluator {
+	if class >= 0 && class < NumDealClasses && ce.classes[class] != nil {
+		return ce.classes[class]
+	}
+	return ce.pooled
+}
+
+// Conditioned reports whether deals of the given class have their own
+// win probabilities.
+func (ce *ConditionedEvaluator) Conditioned(class int) bool {
+	return class >= 0 && class < NumDealClasses && ce.classes[class] != nil
+}
+
+// Pooled returns the win probabilities of all the samples together,
+// whatever the class of the deal.
+func (ce *ConditionedEvaluator) Pooled() *SampledEvaluator {
+	return ce.pooled
+}
+
+// Evaluator returns a hand evaluator for the given set of cards, using
+// the win probabilities for their class.
+func (ce *ConditionedEvaluator) Evaluator(cs []poker.Card) func(f, m, b int16) float64 {
+	se, s := ce.Class(DealClass(cs)), ce.Scoring
+	return func(f, m, b int16) float64 {
+		return se.evaluate(s, f, m, b)
+	}
+}
+
+// ConditionedFormat is the version of the format written by
+// ConditionedEvaluator.Marshal.
+const ConditionedFormat = 1
+
+// conditionedManifest is the name of the manifest of a saved
+// ConditionedEvaluator.
+const conditionedManifest = "conditioned.json"
+
+// conditionedPooled is the name of the file holding the pooled win
+// probabilities of a saved ConditionedEvaluator.
+const conditionedPooled = "pooled.data"
+
+// conditionedManifestJSON is the manifest of a saved
+// ConditionedEvaluator.
+type conditionedManifestJSON struct {
+	Format  int           `json:"format"`
+	Table   TableMetadata `json:"table"`
+	Classes []int         `json:"classes"` // The classes with their own win probabilities
+}
+
+// conditionedClass returns the name of the file holding the win
+// probabilities of a class of a saved ConditionedEvaluator.
+func conditionedClass(class int) string {
+	return fmt.Sprintf("class-%d.data", class)
+}
+
+// Marshal writes the evaluator as a zip archive containing a
+// conditioned.json, which records the rank tables and which classes
+// have their own win probabilities, the pooled win probabilities as
+// pooled.data, and those of each class k as class-k.data, each as
+// written by SampledEvaluator.Marshal.
+func (ce *ConditionedEvaluator) Marshal(w io.Writer) error {
+	if ce.pooled == nil {
+		return errors.New("evaluator has no pooled win probabilities")
+	}
+	m := conditionedManifestJSON{Format: ConditionedFormat, Table: TableInfo(), Classes: []int{}}
+	for k := range ce.classes {
+		if ce.classes[k] != nil {
+			m.Classes = append(m.Classes, k)
+		}
+	}
+	zw := zip.NewWriter(w)
+	mw, err := zw.Create(conditionedManifest)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(mw)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		return err
+	}
+	files := []string{conditionedPooled}
+	evaluators := []*SampledEvaluator{ce.pooled}
+	for _, k := range m.Classes {
+		files = append(files, conditionedClass(k))
+		evaluators = append(evaluators, ce.classes[k])
+	}
+	for i, se := range evaluators {
+		ew, err := zw.Create(files[i])
+		if err != nil {
+			return err
+		}
+		if err := se.Marshal(ew); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// Save writes the evaluator to a named file, replacing it atomically.
+func (ce *ConditionedEvaluator) Save(filename string) error {
+	var buf bytes.Buffer
+	if err := ce.Marshal(&buf); err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, buf.Bytes())
+}
+
+// UnmarshalConditionedEvaluator reads an evaluator written by
+// ConditionedEvaluator.Marshal. It's an error if it was written in a
+// newer format, or the rank tables are different now.
+func UnmarshalConditionedEvaluator(r io.ReaderAt, size int64) (*ConditionedEvaluator, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	if files[conditionedManifest] == nil {
+		return nil, fmt.Errorf("evaluator has no %s", conditionedManifest)
+	}
+	var m conditionedManifestJSON
+	if err := readBundleFile(files[conditionedManifest], func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&m)
+	}); err != nil {
+		return nil, err
+	}
+	if m.Format > ConditionedFormat {
+		return nil, fmt.Errorf("evaluator has format %d, but only formats up to %d are supported", m.Format, ConditionedFormat)
+	}
+	if m.Table.Checksum != TableChecksum() {
+		return nil, fmt.Errorf("evaluator was made with different rank tables (checksum %s, want %s)", m.Table.Checksum, TableChecksum())
+	}
+	read := func(name string) (*SampledEvaluator, error) {
+		f := files[name]
+		if f == nil {
+			return nil, fmt.Errorf("evaluator has no %s", name)
+		}
+		var se *SampledEvaluator
+		err := readBundleFile(f, func(r io.Reader) error {
+			var err error
+			se, err = UnmarshalSampledEvaluator(r)
+			return err
+		})
+		return se, err
+	}
+	ce := &ConditionedEvaluator{}
+	if ce.pooled, err = read(conditionedPooled); err != nil {
+		return nil, err
+	}
+	for _, k := range m.Classes {
+		if k < 0 || k >= NumDealClasses || ce.classes[k] != nil {
+			return nil, fmt.Errorf("evaluator has bad or repeated class %d", k)
+		}
+		if ce.classes[k], err = read(conditionedClass(k)); err != nil {
+			return nil, err
+		}
+	}
+	return ce, nil
+}
+
+// LoadConditionedEvaluator reads an evaluator from a named file.
+func LoadConditionedEvaluator(filename string) (*ConditionedEvaluator, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	ce, err := UnmarshalConditionedEvaluator(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+	return ce, nil
+}
+
+// TrainConditionedEvaluator samples N deals, each of 13 cards to you
+// and 13 to the opponent, who plays them with opp, and counts the ranks
+// the opponent plays separately for each class of your cards. A class
+// gets its own win probabilities if it's seen in at least minSamples
+// deals. The evaluator values hands using the given scoring (or
+// Scoring2to4 if it's nil). The deals are made with rnd (or the global
+// random source if it's nil), so training can be repeated.
+func TrainConditionedEvaluator(opp HandEvaluator, N, minSamples int, s *Scoring, rnd *rand.Rand) (*ConditionedEvaluator, error) {
+	if N <= 0 {
+		return nil, errors.New("no samples")
+	}
+	if minSamples < 1 {
+		minSamples = 1
+	}
+	classes, played := conditionedRollout(rnd, opp, N)
+	var counts [NumDealClasses + 1][3][]int
+	for k := range counts {
+		for i := 0; i < 3; i++ {
+			counts[k][i] = make([]int, poker.ScoreMax+1)
+		}
+	}
+	var seen [NumDealClasses]int
+	for j, p := range played {
+		seen[classes[j]]++
+		for i := 0; i < 3; i++ {
+			counts[classes[j]][i][p[i]]++
+			counts[NumDealClasses][i][p[i]]++
+		}
+	}
+	ce := &ConditionedEvaluator{Scoring: s}
+	var err error
+	if ce.pooled, err = NewSampledEvaluatorFromCounts(counts[NumDealClasses]); err != nil {
+		return nil, err
+	}
+	ce.pooled.Scoring = s
+	for k := range ce.classes {
+		if seen[k] < minSamples {
+			continue
+		}
+		if ce.classes[k], err = NewSampledEvaluatorFromCounts(counts[k]); err != nil {
+			return nil, fmt.Errorf("class %s: %s", DealClassString(k), err)
+		}
+		ce.classes[k].Scoring = s
+	}
+	return ce, nil
+}
+
+// conditionedRollout deals N pairs of hands, and returns the class of
+// the first of each, and the ranks the opponent played with the second.
+func conditionedRollout(rnd *rand.Rand, opp HandEvaluator, N int) (classes []int, played [][3]int16) {
+	classes = make([]int, N)
+	played = rolloutDeals(rnd, opp, N, func() func(int, *rand.Rand) []poker.Card {
+		mydeck := append([]poker.Card{}, poker.Cards...)
+		return func(c int, myrnd *rand.Rand) []poker.Card {
+			for i := 0; i < 26; i++ {
+				j := myrnd.Intn(len(mydeck)-i) + i
+				mydeck[i], mydeck[j] = mydeck[j], mydeck[i]
+			}
+			classes[c] = DealClass(mydeck[:13])
+			return mydeck[13:26]
+		}
+	})
+	return classes, played
+}
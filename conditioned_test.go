@@ -0,0 +1,175 @@
+package cpoker
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestConditionedEvaluator(t *testing.T) {
+	for _, tc := range []struct {
+		cards string
+		want  int
+	}{
+		{"HAHKHQHJH9C2D3S4C5D7S8CTDJ", 2*1 + 1},
+		{"HAHKCQDJS9C2D3S4C5D7S8CTHJ", 2 * 1},
+		{"HASACKDKS2C2D3S3C5D5S8C8DJ", 2 * 4},
+		{"HAH2H3H4H5H6H7H8H9HTHJHQHK", 1},
+	} {
+		if got := DealClass(mustCards(t, tc.cards)); got != tc.want {
+			t.Errorf("DealClass(%s) = %d (%s), want %d (%s)", tc.cards, got, DealClassString(got), tc.want, DealClassString(tc.want))
+		}
+	}
+
+	ce, err := TrainConditionedEvaluator(MaxProdEvaluator{}, 2000, 100, ScoringHK, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := ce.Pooled().Samples(); n != 2000 {
+		t.Errorf("pooled %d samples, want 2000", n)
+	}
+	total, conditioned := 0, 0
+	for k := 0; k < NumDealClasses; k++ {
+		if ce.Conditioned(k) {
+			conditioned++
+			total += ce.Class(k).Samples()
+			if ce.Class(k).Samples() < 100 {
+				t.Errorf("class %s has its own probabilities from only %d samples", DealClassString(k), ce.Class(k).Samples())
+			}
+		} else if ce.Class(k) != ce.Pooled() {
+			t.Errorf("class %s isn't conditioned, but doesn't use the pooled probabilities", DealClassString(k))
+		}
+	}
+	if conditioned == 0 || total > 2000 {
+		t.Errorf("%d classes have %d samples between them", conditioned, total)
+	}
+	rnd := rand.New(rand.NewSource(4))
+	for i := 0; i < 10; i++ {
+		c := randomDeal(rnd)
+		h, _ := Play(c, ce)
+		r := h.ranks()
+		want := ce.Class(DealClass(c)).Evaluator(c)(r[0], r[1], r[2])
+		if got := ce.Evaluator(c)(r[0], r[1], r[2]); got != want {
+			t.Errorf("%v is worth %f, but %f with the probabilities for its class", &h, got, want)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "conditioned")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "conditioned.data")
+	if err := ce.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadConditionedEvaluator(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got.Scoring = ScoringHK
+	for k := 0; k < NumDealClasses; k++ {
+		if got.Conditioned(k) != ce.Conditioned(k) || !reflect.DeepEqual(got.Class(k).Counts(1), ce.Class(k).Counts(1)) {
+			t.Errorf("class %s differs after loading", DealClassString(k))
+		}
+	}
+	for i := 0; i < 10; i++ {
+		c := randomDeal(rnd)
+		h0, _ := Play(c, ce)
+		h1, _ := Play(c, got)
+		if h0.Key() != h1.Key() {
+			t.Errorf("the evaluator played %v, but the loaded evaluator played %v", &h0, &h1)
+		}
+	}
+}
+
+// rewriteConditioned returns the archive data with its manifest
+// rewritten by edit.
+func rewriteConditioned(t *testing.T, data []byte, edit func(m *conditionedManifestJSON)) []byte {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	zw := zip.NewWriter(&out)
+	for _, f := range zr.File {
+		var b []byte
+		if err := readBundleFile(f, func(r io.Reader) error {
+			b, err = ioutil.ReadAll(r)
+			return err
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if f.Name == conditionedManifest {
+			var m conditionedManifestJSON
+			if err := json.Unmarshal(b, &m); err != nil {
+				t.Fatal(err)
+			}
+			edit(&m)
+			if b, err = json.Marshal(m); err != nil {
+				t.Fatal(err)
+			}
+		}
+		w, err := zw.Create(f.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func TestUnmarshalConditionedEvaluator(t *testing.T) {
+	ce := &ConditionedEvaluator{pooled: smallSampledEvaluator(t, 100)}
+	ce.classes[3] = smallSampledEvaluator(t, 200)
+	var buf bytes.Buffer
+	if err := ce.Marshal(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	got, err := UnmarshalConditionedEvaluator(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k := 0; k < NumDealClasses; k++ {
+		if got.Conditioned(k) != (k == 3) {
+			t.Errorf("class %s: conditioned is %v after loading", DealClassString(k), got.Conditioned(k))
+		}
+		for i := 0; i < 3; i++ {
+			if !reflect.DeepEqual(got.Class(k).Counts(i), ce.Class(k).Counts(i)) {
+				t.Errorf("class %s: slot %d counts differ after loading", DealClassString(k), i)
+			}
+		}
+	}
+
+	for _, tc := range []struct {
+		name string
+		edit func(m *conditionedManifestJSON)
+	}{
+		{"newer format", func(m *conditionedManifestJSON) { m.Format = ConditionedFormat + 1 }},
+		{"different tables", func(m *conditionedManifestJSON) { m.Table.Checksum = "0" }},
+		{"bad class", func(m *conditionedManifestJSON) { m.Classes = []int{NumDealClasses} }},
+		{"repeated class", func(m *conditionedManifestJSON) { m.Classes = []int{3, 3} }},
+		{"missing class", func(m *conditionedManifestJSON) { m.Classes = []int{3, 4} }},
+	} {
+		bad := rewriteConditioned(t, data, tc.edit)
+		if _, err := UnmarshalConditionedEvaluator(bytes.NewReader(bad), int64(len(bad))); err == nil {
+			t.Errorf("%s: loaded the evaluator", tc.name)
+		}
+	}
+	if err := (&ConditionedEvaluator{}).Marshal(&buf); err == nil {
+		t.Errorf("saved an evaluator with no pooled win probabilities")
+	}
+}
@@ -14,9 +14,12 @@
 package cpoker
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"math/rand"
-	"reflect"
+	"time"
 
 	"github.com/paulhankin/poker/v2/poker"
 )
@@ -35,6 +38,11 @@ func (h *Hand) String() string {
 	return fmt.Sprintf("%v (%s), %v (%s), %v (%s)", h.Front, fd, h.Middle, md, h.Back, bd)
 }
 
+// ranks returns the eval ranks of the front, middle and back.
+func (h *Hand) ranks() [3]int16 {
+	return [3]int16{poker.Eval3(&h.Front), poker.Eval5(&h.Middle), poker.Eval5(&h.Back)}
+}
+
 // A HandEvaluator scores a Chinese poker hand.
 type HandEvaluator interface {
 	// Evaluator should, given cards, return a function that can
@@ -80,38 +88,139 @@ func next4(ix *[5]int) bool {
 	return ix[4] < 9
 }
 
+// split divides the 10 cards of c which aren't in the front (as given
+// by fIdx) into the back (as given by bIdx) and the middle.
+func split(c []poker.Card, fIdx *[3]int, bIdx *[5]int) (back, middle [5]poker.Card) {
+	f, b := 0, 0
+	for i := 0; i < 13; i++ {
+		if f < 3 && fIdx[f] == i {
+			f++
+		} else if b < 5 && i == bIdx[b]+f+1 {
+			back[b] = c[i]
+			b++
+		} else {
+			middle[i-f-b] = c[i]
+		}
+	}
+	return back, middle
+}
+
 // EvalStats are data from playing a single hand.
 type EvalStats struct {
-	Hands            int // How many evals we did
-	StrongFront      int // How many times the front was too strong
-	BackEqualsMiddle int // How many times the back was equal to the middle
+	Hands            int  // How many evals we did
+	StrongFront      int  // How many times the front was too strong
+	BackEqualsMiddle int  // How many times the back was equal to the middle
+	Truncated        bool // Whether the budget ran out before every hand was considered
+
+	// Margin is how much better the value of the hand played is than
+	// that of the next best hand considered, or +Inf if no other hand
+	// was considered. It's only set by Play, PlayE and PlayWithBudget.
+	Margin float64
+}
+
+// A PlayBudget limits the work done by PlayWithBudget.
+type PlayBudget struct {
+	MaxHands int           // The most hands to evaluate, or 0 for no limit
+	Time     time.Duration // How long to spend, or 0 for no limit
+}
+
+// PlayWithBudget is like PlayE, but stops considering hands when the
+// budget runs out, and returns the best hand found so far. The time
+// taken to construct the evaluator for c counts towards the budget,
+// but can't be interrupted. It's an error if the budget runs out before
+// any hand is considered.
+func PlayWithBudget(c []poker.Card, he HandEvaluator, b PlayBudget) (Hand, EvalStats, error) {
+	if err := checkDeal(c); err != nil {
+		return Hand{}, EvalStats{}, err
+	}
+	var deadline time.Time
+	if b.Time > 0 {
+		deadline = time.Now().Add(b.Time)
+	}
+	h, stats := play(c, he, b.MaxHands, deadline)
+	if stats.Hands == 0 {
+		return h, stats, errors.New("budget ran out before any hand was considered")
+	}
+	return h, stats, nil
+}
+
+// PlayE is like Play, but returns an error unless c is exactly
+// 13 distinct valid cards.
+func PlayE(c []poker.Card, he HandEvaluator) (Hand, EvalStats, error) {
+	if err := checkDeal(c); err != nil {
+		return Hand{}, EvalStats{}, err
+	}
+	h, stats := Play(c, he)
+	return h, stats, nil
+}
+
+// checkDeal returns an error unless c is 13 distinct valid cards.
+func checkDeal(c []poker.Card) error {
+	if len(c) != 13 {
+		return fmt.Errorf("got %d cards, want 13", len(c))
+	}
+	if _, ok := NewCardSet(c); !ok {
+		return fmt.Errorf("cards %v contain an invalid or duplicate card", c)
+	}
+	return nil
 }
 
 // Play takes 13 cards and returns the hand for which
 // the evaluator returns the largest value.
+// The cards must be distinct; use PlayE to check this.
 func Play(c []poker.Card, he HandEvaluator) (Hand, EvalStats) {
+	return play(c, he, 0, time.Time{})
+}
+
+// PlayWithDiscard takes 14 distinct cards, as in home games where
+// one card is discarded, and returns the hand and discard for which
+// the evaluator returns the largest value. The stats are summed over
+// the 14 ways of discarding. It's an error if c isn't 14 distinct
+// valid cards.
+func PlayWithDiscard(c []poker.Card, he HandEvaluator) (Hand, poker.Card, EvalStats, error) {
+	if len(c) != 14 {
+		return Hand{}, 0, EvalStats{}, fmt.Errorf("got %d cards, want 14", len(c))
+	}
+	if _, ok := NewCardSet(c); !ok {
+		return Hand{}, 0, EvalStats{}, fmt.Errorf("cards %v contain an invalid or duplicate card", c)
+	}
+	var best Hand
+	var discard poker.Card
+	var stats EvalStats
+	bestEV := math.Inf(-1)
+	rest := make([]poker.Card, 0, len(c)-1)
+	for i, d := range c {
+		rest = append(append(rest[:0], c[:i]...), c[i+1:]...)
+		ev := he.Evaluator(rest)
+		h, st := Play(rest, fixedEvaluator(ev))
+		stats.Hands += st.Hands
+		stats.StrongFront += st.StrongFront
+		stats.BackEqualsMiddle += st.BackEqualsMiddle
+		r := h.ranks()
+		if v := ev(r[0], r[1], r[2]); v > bestEV {
+			best, discard, bestEV = h, d, v
+		}
+	}
+	return best, discard, stats, nil
+}
+
+// play is Play, but stops after evaluating maxHands hands or
+// at the deadline, unless they're zero.
+func play(c []poker.Card, he HandEvaluator, maxHands int, deadline time.Time) (Hand, EvalStats) {
 	stats := EvalStats{}
 	evaluator := he.Evaluator(c)
 	maxima := make([][3]int16, 0, 128)
-	best, bestEV := Hand{}, -9999999.9
+	best, bestEV, nextEV := Hand{}, math.Inf(-1), math.Inf(-1)
 	fIdx := [3]int{-1, 1, 2} // Which cards go in front
 	for next3(&fIdx) {
+		if stats.Truncated {
+			break
+		}
 		front := [3]poker.Card{c[fIdx[0]], c[fIdx[1]], c[fIdx[2]]}
 		ef := poker.Eval3(&front)
 		bIdx := [5]int{-1, -1, 1, 2, 3}
 		for next4(&bIdx) {
-			var back, middle [5]poker.Card
-			f, b := 0, 0
-			for i := 0; i < 13; i++ {
-				if f < 3 && fIdx[f] == i {
-					f++
-				} else if b < 5 && i == bIdx[b]+f+1 {
-					back[b] = c[i]
-					b++
-				} else {
-					middle[i-f-b] = c[i]
-				}
-			}
+			back, middle := split(c, &fIdx, &bIdx)
 			eb := poker.Eval5(&back)
 			em := poker.Eval5(&middle)
 			if ef >= em || ef >= eb {
@@ -151,8 +260,11 @@ func Play(c []poker.Card, he HandEvaluator) (Hand, EvalStats) {
 				ev = evaluator(ef, em, eb)
 			}
 			stats.Hands++
+			if (maxHands > 0 && stats.Hands >= maxHands) || (!deadline.IsZero() && stats.Hands%64 == 0 && time.Now().After(deadline)) {
+				stats.Truncated = true
+			}
 			if ev >= bestEV {
-				bestEV = ev
+				bestEV, nextEV = ev, bestEV
 				best.Front = front
 				if em > eb {
 					best.Middle = back
@@ -161,69 +273,253 @@ func Play(c []poker.Card, he HandEvaluator) (Hand, EvalStats) {
 					best.Middle = middle
 					best.Back = back
 				}
+			} else if ev > nextEV {
+				nextEV = ev
+			}
+			if stats.Truncated {
+				break
 			}
 		}
 	}
+	stats.Margin = math.Inf(1)
+	if stats.Hands > 1 {
+		stats.Margin = bestEV - nextEV
+	}
 	return best, stats
 }
 
 // A Comparison is aggregated statistics from matching two
 // players ("hero" and "villain").
 type Comparison struct {
-	Played        int     // The number of hands played
-	EVPerHand     float64 // Expectation of hero per hand
-	HeroScoops    int     // How many time the hero won all three hands
-	VillainScoops int     // How many times the villain won all three hands
-	Same          int     // How many times the hero and villain played the hand the same way
+	Played        int     `json:"played"`         // The number of hands played
+	EVPerHand     float64 `json:"ev"`             // Expectation of hero per hand
+	StdErr        float64 `json:"stderr"`         // Standard error of EVPerHand
+	HeroScoops    int     `json:"hero_scoops"`    // How many time the hero won all three hands
+	VillainScoops int     `json:"villain_scoops"` // How many times the villain won all three hands
+	Same          int     `json:"same"`           // How many times the hero and villain played the hand the same way
+
+	// These are only set if the comparison has pass policies.
+	HeroPassed    int `json:"hero_passed,omitempty"`    // How many hands the hero passed
+	VillainPassed int `json:"villain_passed,omitempty"` // How many hands the villain passed
+
+	// These are only set if the comparison has a Stake.
+	NetPerHand float64 `json:"net,omitempty"`  // Expected money won by the hero per hand, after rake
+	RakePaid   float64 `json:"rake,omitempty"` // Total rake paid by both players
+
+	// PredictedPerHand is the mean of the points the hero expected
+	// to score, which is only set if the comparison has ScoredPlay and
+	// the hero is an EvaluatorPoints. It can be checked against
+	// EVPerHand.
+	PredictedPerHand float64 `json:"predicted,omitempty"`
+
+	// These are only set if the comparison has AdjustSamples. They're
+	// an estimate of EVPerHand with much less variance, made by
+	// replacing each hand's score with the hero's expected score
+	// against a sample of the villain's hands, less the villain's
+	// expected score with the same cards against the same sample.
+	AdjustedEV     float64 `json:"adjusted_ev,omitempty"`
+	AdjustedStdErr float64 `json:"adjusted_stderr,omitempty"`
+}
+
+// CompareOptions are options for CompareEvaluatorsWithOptions.
+type CompareOptions struct {
+	Scoring *Scoring // How hands are scored. If nil, Scoring2to4 is used.
+	Stake   *Stake   // If non-nil, results are also reported in money.
+
+	// If non-nil, Progress is called with the running results
+	// whenever progress is reported.
+	Progress func(hand int, c Comparison)
+
+	// If non-nil, Printer prints progress whenever it's reported.
+	// If nil, nothing is printed.
+	Printer ProgressPrinter
+
+	// Passes are the pass policies of the players, in variants where
+	// players may pass deals (see PassPolicy): the hero's and the
+	// villain's in CompareEvaluatorsWithOptions, and each player's in
+	// SimulateTable. A player without a policy never passes.
+	Passes []*PassPolicy
+
+	// If non-nil, OnHand is called with a record of every hand played.
+	OnHand func(r HandRecord)
+
+	// If non-nil, deals (and with AdjustSamples, the villain's
+	// sampled hands) are drawn from Rand rather than the global
+	// random source, so that comparisons can be repeated on the
+	// same deals.
+	Rand *rand.Rand
+
+	// If AdjustSamples is positive, the villain's play of this many
+	// random deals is sampled, and used to report EV-adjusted results.
+	AdjustSamples int
+
+	// If ScoredPlay is set, players which are EvaluatorPoints play to
+	// maximize their expected points under Scoring (see PlayForPoints),
+	// rather than as they would by themselves. For example, a
+	// SampledEvaluator then ignores its own Scoring.
+	ScoredPlay bool
+}
+
+// A HandRecord is the outcome of one hand of a comparison.
+type HandRecord struct {
+	Deal    int  `json:"deal"`    // Each deal is played twice, with the players swapping cards
+	Seat    int  `json:"seat"`    // 0 or 1, for the first and second time the deal is played
+	Hero    Hand `json:"hero"`    // The hero's hand
+	Villain Hand `json:"villain"` // The villain's hand
+	Score   int  `json:"score"`   // The hero's score
+	Wins    int  `json:"wins"`    // The number of slots the hero won
+	Losses  int  `json:"losses"`  // The number of slots the hero lost
+}
+
+// passes reports whether player i passes the deal c, with the pass
+// policies of the options.
+func (opts *CompareOptions) passes(i int, c []poker.Card) bool {
+	return i < len(opts.Passes) && opts.Passes[i].Pass(c)
+}
+
+// play plays the cards c with he, for points scored with s if the
+// options have ScoredPlay.
+func (opts *CompareOptions) play(c []poker.Card, he HandEvaluator, s *Scoring) (h Hand, points float64, ok bool) {
+	if opts.ScoredPlay {
+		return PlayForPoints(c, he, s)
+	}
+	h, _ = Play(c, he)
+	return h, 0, false
+}
+
+// A ComparisonProgress is the state of a comparison when progress is
+// reported: the latest deal, and the results so far.
+type ComparisonProgress struct {
+	Hand    int        // The number of the latest deal, from 0
+	Hero    [2]Hand    // The hero's hands, in the first and second seat
+	Villain [2]Hand    // The villain's hands, in the first and second seat
+	Scores  [2]int     // The hero's scores in the first and second seat
+	Result  Comparison // The results so far
+}
+
+// A ProgressPrinter prints the progress of a comparison.
+type ProgressPrinter interface {
+	PrintProgress(p *ComparisonProgress)
+}
+
+// A TextProgressPrinter prints progress as text, showing the latest
+// deal and the results so far.
+type TextProgressPrinter struct {
+	W io.Writer
+}
+
+// PrintProgress prints the progress to tp.W.
+func (tp TextProgressPrinter) PrintProgress(p *ComparisonProgress) {
+	fmt.Fprintf(tp.W, "hand %d\n", p.Hand)
+	fmt.Fprintf(tp.W, "  %s\n", &p.Hero[0])
+	fmt.Fprintf(tp.W, "  %s\n", &p.Villain[0])
+	fmt.Fprintf(tp.W, "Played the other way:\n")
+	fmt.Fprintf(tp.W, "  %s\n", &p.Hero[1])
+	fmt.Fprintf(tp.W, "  %s\n", &p.Villain[1])
+	fmt.Fprintf(tp.W, "score: %d + %d\n", p.Scores[0], p.Scores[1])
+	fmt.Fprintf(tp.W, "comparison:\n%#v\n\n", p.Result)
 }
 
 // CompareEvaluators matches the two evaluators against each other on
-// n random hands. Aggregate statistics are returned.
+// n random hands. Aggregate statistics are returned. Nothing is
+// printed: to print progress every prEvery hands, use
+// CompareEvaluatorsWithOptions with a Printer, such as a
+// TextProgressPrinter.
 func CompareEvaluators(hero, villain HandEvaluator, n int, prEvery int) Comparison {
-	cards := append([]poker.Card{}, poker.Cards...)
+	return CompareEvaluatorsWithOptions(hero, villain, n, prEvery, CompareOptions{})
+}
+
+// CompareEvaluatorsWithOptions is like CompareEvaluators, but with
+// options that control the comparison. Progress is reported every
+// prEvery hands, unless prEvery is zero, to opts.Progress and
+// opts.Printer, and is only printed if there's a Printer. The
+// evaluators play as they would by themselves unless opts.ScoredPlay
+// is set.
+func CompareEvaluatorsWithOptions(hero, villain HandEvaluator, n int, prEvery int, opts CompareOptions) Comparison {
+	scoring := opts.Scoring
+	if scoring == nil {
+		scoring = Scoring2to4
+	}
+	var villHands [][3]int16
+	if opts.AdjustSamples > 0 {
+		villHands, _, _ = rollout(opts.Rand, nil, villain, opts.AdjustSamples)
+	}
+	// expected returns the mean score of a hand against the villain's
+	// sampled hands.
+	expected := func(h *Hand) float64 {
+		r, total := h.ranks(), 0
+		for _, v := range villHands {
+			score, _, _ := scoring.showdown(r, v)
+			total += score
+		}
+		return float64(total) / float64(len(villHands))
+	}
+	// Each deal is played twice, with the seats swapped, and the two
+	// scores are correlated, so standard errors are of the mean of each
+	// pair of scores rather than of the scores.
+	var pairs, adjusted lossStats
 	result := Comparison{}
-	total := float64(0)
+	net, predicted := float64(0), float64(0)
 	for hand := 0; hand < n; hand++ {
-		for i := 0; i < 26; i++ {
-			j := rand.Intn(52-i) + i
-			cards[i], cards[j] = cards[j], cards[i]
+		deal := DealPlayers(opts.Rand, 2)
+		hc, vc := deal[0], deal[1]
+		hero0, pred0, ok := opts.play(hc, hero, scoring)
+		hero1, pred1, _ := opts.play(vc, hero, scoring)
+		vill0, _, _ := opts.play(vc, villain, scoring)
+		vill1, _, _ := opts.play(hc, villain, scoring)
+		if ok {
+			predicted += pred0 + pred1
 		}
-		hc := cards[:13]
-		vc := cards[13:26]
-		hero0, _ := Play(hc, hero)
-		hero1, _ := Play(vc, hero)
-		vill0, _ := Play(vc, villain)
-		vill1, _ := Play(hc, villain)
-		score0 := CompareHands(&hero0, &vill0)
-		score1 := CompareHands(&hero1, &vill1)
+		heroPass0, heroPass1 := opts.passes(0, hc), opts.passes(0, vc)
+		villPass0, villPass1 := opts.passes(1, vc), opts.passes(1, hc)
+		score0, wins0, losses0 := scoring.passShowdown(hero0.ranks(), vill0.ranks(), heroPass0, villPass0)
+		score1, wins1, losses1 := scoring.passShowdown(hero1.ranks(), vill1.ranks(), heroPass1, villPass1)
 		result.Played += 2
-		if reflect.DeepEqual(hero0, vill1) {
+		result.HeroPassed += b2i(heroPass0) + b2i(heroPass1)
+		result.VillainPassed += b2i(villPass0) + b2i(villPass1)
+		if hero0.Key() == vill1.Key() {
 			result.Same += 1
 		}
-		if reflect.DeepEqual(hero1, vill0) {
+		if hero1.Key() == vill0.Key() {
 			result.Same += 1
 		}
-		total += float64(score0 + score1)
-		result.EVPerHand = total / float64(result.Played)
-		if score0 == 4 {
-			result.HeroScoops++
-		} else if score0 == -4 {
-			result.VillainScoops++
+		pairs.add(float64(score0+score1) / 2)
+		result.EVPerHand = pairs.mean()
+		result.PredictedPerHand = predicted / float64(result.Played)
+		result.StdErr = math.Sqrt(pairs.variance() / float64(pairs.n))
+		if opts.Stake != nil {
+			for _, score := range []int{score0, score1} {
+				heroNet, heroRake := opts.Stake.Settle(score)
+				_, villRake := opts.Stake.Settle(-score)
+				net += heroNet
+				result.RakePaid += heroRake + villRake
+			}
+			result.NetPerHand = net / float64(result.Played)
+		}
+		if opts.OnHand != nil {
+			opts.OnHand(HandRecord{Deal: hand, Seat: 0, Hero: hero0, Villain: vill0, Score: score0, Wins: wins0, Losses: losses0})
+			opts.OnHand(HandRecord{Deal: hand, Seat: 1, Hero: hero1, Villain: vill1, Score: score1, Wins: wins1, Losses: losses1})
 		}
-		if score1 == 4 {
-			result.HeroScoops++
-		} else if score1 == -4 {
-			result.VillainScoops++
+		if villHands != nil {
+			adjusted.add((expected(&hero0) - expected(&vill1) + expected(&hero1) - expected(&vill0)) / 2)
+			result.AdjustedEV = adjusted.mean()
+			result.AdjustedStdErr = math.Sqrt(adjusted.variance() / float64(adjusted.n))
 		}
-		if hand%prEvery == 0 {
-			fmt.Printf("hand %d\n", hand)
-			fmt.Printf("  %s\n", &hero0)
-			fmt.Printf("  %s\n", &vill0)
-			fmt.Printf("Played the other way:\n")
-			fmt.Printf("  %s\n", &hero1)
-			fmt.Printf("  %s\n", &vill1)
-			fmt.Printf("score: %d + %d\n", score0, score1)
-			fmt.Printf("comparison:\n%#v\n\n", result)
+		result.HeroScoops += b2i(wins0 == 3) + b2i(wins1 == 3)
+		result.VillainScoops += b2i(losses0 == 3) + b2i(losses1 == 3)
+		if prEvery > 0 && hand%prEvery == 0 {
+			if opts.Printer != nil {
+				opts.Printer.PrintProgress(&ComparisonProgress{
+					Hand:    hand,
+					Hero:    [2]Hand{hero0, hero1},
+					Villain: [2]Hand{vill0, vill1},
+					Scores:  [2]int{score0, score1},
+					Result:  result,
+				})
+			}
+			if opts.Progress != nil {
+				opts.Progress(hand, result)
+			}
 		}
 	}
 	return result
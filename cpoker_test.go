@@ -0,0 +1,65 @@
+package cpoker
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+func TestPlayWithDiscard(t *testing.T) {
+	// There are six hearts, so at most five can make a flush.
+	c := mustCards(t, "H2H5H7H9HJHKS8D8C8SAD3C4S6C2")
+	h, d, stats, err := PlayWithDiscard(c, MaxProdEvaluator{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	in, _ := NewCardSet(h.cards())
+	if in.Contains(d) || in.Len() != 13 {
+		t.Fatalf("played %v and discarded %s", &h, d)
+	}
+	all, _ := NewCardSet(c)
+	if in.Add(d) != all {
+		t.Errorf("played %v and discarded %s, which aren't the cards %v", &h, d, c)
+	}
+	if stats.Hands == 0 {
+		t.Errorf("no hands were evaluated")
+	}
+	// The discard is as good as any other.
+	ev := MaxProdEvaluator{}.Evaluator(nil)
+	r := h.ranks()
+	for i := range c {
+		rest := append(append([]poker.Card{}, c[:i]...), c[i+1:]...)
+		other, _ := Play(rest, MaxProdEvaluator{})
+		or := other.ranks()
+		if ev(or[0], or[1], or[2]) > ev(r[0], r[1], r[2]) {
+			t.Errorf("discarding %s is better than discarding %s", c[i], d)
+		}
+	}
+	for _, bad := range [][]poker.Card{nil, c[:13], append(c[:13:13], c[0])} {
+		if _, _, _, err := PlayWithDiscard(bad, MaxProdEvaluator{}); err == nil {
+			t.Errorf("PlayWithDiscard(%v) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestPlayWithBudget(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 10; i++ {
+		c := randomDeal(rnd)
+		h, stats, err := PlayWithBudget(c, MaxProdEvaluator{}, PlayBudget{MaxHands: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.Hands != 3 || !stats.Truncated {
+			t.Errorf("PlayWithBudget(%v) evaluated %d hands (truncated %v), want 3 (truncated)", c, stats.Hands, stats.Truncated)
+		}
+		if err := CheckHand(&h, c); err != nil {
+			t.Errorf("PlayWithBudget(%v) = %v: %s", c, &h, err)
+		}
+		_, full := Play(c, MaxProdEvaluator{})
+		if full.Truncated {
+			t.Errorf("Play(%v) was truncated", c)
+		}
+	}
+}
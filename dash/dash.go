@@ -0,0 +1,164 @@
+// Binary dash serves a web page charting the metrics written by
+// training and comparison runs (see the -metrics flag of train).
+// The metrics files are re-read on every request, so runs that are
+// still in progress are shown as they go, and files that don't exist
+// yet are treated as empty. For example:
+//
+//	dash -metrics metrics.jsonl -addr localhost:8080
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/paulhankin/cpoker"
+)
+
+var (
+	metricsFiles = flag.String("metrics", "metrics.jsonl", "comma-separated metrics files to chart")
+	addr         = flag.String("addr", "localhost:8080", "the address to serve on")
+	refresh      = flag.Int("refresh", 10, "how often the page reloads, in seconds")
+)
+
+const width, height, margin = 600, 240, 40
+
+// A chart is the SVG rendering of one metric of one run.
+type chart struct {
+	Title      string
+	Last       string
+	Line, Band string // SVG polyline and polygon points
+	Lo, Hi     string // The labels of the y axis
+	MaxStep    int
+}
+
+func readAll() ([]cpoker.MetricRecord, error) {
+	var recs []cpoker.MetricRecord
+	for _, fn := range strings.Split(*metricsFiles, ",") {
+		f, err := os.Open(fn)
+		if os.IsNotExist(err) {
+			// The run hasn't written any metrics yet.
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		r, err := cpoker.ReadMetrics(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", fn, err)
+		}
+		recs = append(recs, r...)
+	}
+	return recs, nil
+}
+
+// makeCharts groups records by run and metric, and renders each
+// series as a line, with a band of two standard errors either side.
+func makeCharts(recs []cpoker.MetricRecord) []chart {
+	series := map[string][]cpoker.MetricRecord{}
+	for _, r := range recs {
+		k := r.Run + ": " + r.Metric
+		series[k] = append(series[k], r)
+	}
+	var keys []string
+	for k := range series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var charts []chart
+	for _, k := range keys {
+		s := series[k]
+		sort.SliceStable(s, func(i, j int) bool { return s[i].Step < s[j].Step })
+		lo, hi, maxStep := math.Inf(1), math.Inf(-1), 1
+		for _, r := range s {
+			lo = math.Min(lo, r.Value-2*r.StdErr)
+			hi = math.Max(hi, r.Value+2*r.StdErr)
+			if r.Step > maxStep {
+				maxStep = r.Step
+			}
+		}
+		if hi-lo < 1e-9 {
+			lo, hi = lo-1, hi+1
+		}
+		x := func(step int) float64 { return margin + float64(width-2*margin)*float64(step)/float64(maxStep) }
+		y := func(v float64) float64 { return height - margin - float64(height-2*margin)*(v-lo)/(hi-lo) }
+		var line, upper, lower []string
+		for _, r := range s {
+			line = append(line, fmt.Sprintf("%.1f,%.1f", x(r.Step), y(r.Value)))
+			upper = append(upper, fmt.Sprintf("%.1f,%.1f", x(r.Step), y(r.Value+2*r.StdErr)))
+			lower = append([]string{fmt.Sprintf("%.1f,%.1f", x(r.Step), y(r.Value-2*r.StdErr))}, lower...)
+		}
+		last := s[len(s)-1]
+		c := chart{
+			Title:   k,
+			Last:    fmt.Sprintf("%.4f at step %d", last.Value, last.Step),
+			Line:    strings.Join(line, " "),
+			Band:    strings.Join(append(upper, lower...), " "),
+			Lo:      fmt.Sprintf("%.3f", lo),
+			Hi:      fmt.Sprintf("%.3f", hi),
+			MaxStep: maxStep,
+		}
+		if last.StdErr > 0 {
+			c.Last = fmt.Sprintf("%.4f ± %.4f at step %d", last.Value, last.StdErr, last.Step)
+		}
+		charts = append(charts, c)
+	}
+	return charts
+}
+
+var page = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html><head><title>cpoker dashboard</title>
+<meta http-equiv="refresh" content="{{.Refresh}}">
+<style>body { font-family: sans-serif; } svg { border: 1px solid #ccc; }</style>
+</head><body>
+<h1>cpoker dashboard</h1>
+{{if not .Charts}}<p>No metrics yet.</p>{{end}}
+{{range .Charts}}
+<h2>{{.Title}}</h2>
+<p>{{.Last}}</p>
+<svg width="600" height="240">
+<polygon points="{{.Band}}" fill="#cde" stroke="none"/>
+<polyline points="{{.Line}}" fill="none" stroke="#036" stroke-width="2"/>
+<text x="2" y="44" font-size="11">{{.Hi}}</text>
+<text x="2" y="200" font-size="11">{{.Lo}}</text>
+<text x="530" y="230" font-size="11">{{.MaxStep}}</text>
+</svg>
+{{end}}
+</body></html>
+`))
+
+func main() {
+	flag.Parse()
+	http.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		recs, err := readAll()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		data := struct {
+			Refresh int
+			Charts  []chart
+		}{*refresh, makeCharts(recs)}
+		if err := page.Execute(w, data); err != nil {
+			log.Printf("failed to render page: %s", err)
+		}
+	})
+	http.HandleFunc("/metrics.json", func(w http.ResponseWriter, req *http.Request) {
+		recs, err := readAll()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(recs)
+	})
+	log.Printf("serving on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
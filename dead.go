@@ -0,0 +1,98 @@
+package cpoker
+
+import (
+	"fmt"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// A DeadCardEvaluator is a HandEvaluator which can use knowledge of
+// cards that the opponent can't hold.
+type DeadCardEvaluator interface {
+	HandEvaluator
+
+	// EvaluatorWithDead is like Evaluator, but none of the dead
+	// cards are in play.
+	EvaluatorWithDead(c, dead []poker.Card) func(evf, evm, evb int16) float64
+}
+
+// fixedEvaluator is a HandEvaluator which uses the same evaluation
+// function whatever the cards.
+type fixedEvaluator func(evf, evm, evb int16) float64
+
+func (fe fixedEvaluator) Evaluator(_ []poker.Card) func(evf, evm, evb int16) float64 {
+	return fe
+}
+
+// AdviseWithDead plays the 13 cards c, knowing that the dead cards
+// (for example, cards exposed in a multi-way game) can't be held by
+// the opponent. If he is a DeadCardEvaluator, the dead cards are
+// excluded from its evaluation; otherwise they're ignored, and
+// AdviseWithDead is the same as PlayE.
+func AdviseWithDead(c, dead []poker.Card, he HandEvaluator) (Hand, EvalStats, error) {
+	if err := checkDeal(c); err != nil {
+		return Hand{}, EvalStats{}, err
+	}
+	if _, ok := NewCardSet(append(append([]poker.Card{}, c...), dead...)); !ok {
+		return Hand{}, EvalStats{}, fmt.Errorf("dead cards %v are invalid, repeated or dealt", dead)
+	}
+	if len(dead) > 52-26 {
+		return Hand{}, EvalStats{}, fmt.Errorf("got %d dead cards, which leaves too few to deal the opponent", len(dead))
+	}
+	dce, ok := he.(DeadCardEvaluator)
+	if !ok {
+		h, stats := Play(c, he)
+		return h, stats, nil
+	}
+	h, stats := Play(c, fixedEvaluator(dce.EvaluatorWithDead(c, dead)))
+	return h, stats, nil
+}
+
+// A DeadGroup is a named group of cards which might be dead,
+// for example "two aces".
+type DeadGroup struct {
+	Name  string
+	Cards []poker.Card
+}
+
+// A DeadCardEffect is how a deal is played when a group of cards is dead.
+type DeadCardEffect struct {
+	Group   DeadGroup
+	Hand    Hand
+	EV      float64 // The evaluator's value for Hand
+	Changed bool    // Whether the hand is arranged differently than with no dead cards
+	EVDelta float64 // The change in EV from having no dead cards
+}
+
+// DeadCardSensitivity plays the 13 cards c with no dead cards, and then
+// with each group of cards dead in turn, and reports how the hand played
+// and its value change. This shows how much it's worth knowing which
+// cards are dead. Since each evaluation is a separate rollout, small
+// changes in EV may be due to sampling.
+func DeadCardSensitivity(c []poker.Card, groups []DeadGroup, he DeadCardEvaluator) (base DeadCardEffect, effects []DeadCardEffect, err error) {
+	if err := checkDeal(c); err != nil {
+		return base, nil, err
+	}
+	play := func(g DeadGroup) (DeadCardEffect, error) {
+		if _, ok := NewCardSet(append(append([]poker.Card{}, c...), g.Cards...)); !ok {
+			return DeadCardEffect{}, fmt.Errorf("group %q: dead cards %v are invalid, repeated or dealt", g.Name, g.Cards)
+		}
+		ev := he.EvaluatorWithDead(c, g.Cards)
+		h, _ := Play(c, fixedEvaluator(ev))
+		r := h.ranks()
+		return DeadCardEffect{Group: g, Hand: h, EV: ev(r[0], r[1], r[2])}, nil
+	}
+	if base, err = play(DeadGroup{Name: "none"}); err != nil {
+		return base, nil, err
+	}
+	for _, g := range groups {
+		e, err := play(g)
+		if err != nil {
+			return base, nil, err
+		}
+		e.Changed = e.Hand.Key() != base.Hand.Key()
+		e.EVDelta = e.EV - base.EV
+		effects = append(effects, e)
+	}
+	return base, effects, nil
+}
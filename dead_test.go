@@ -0,0 +1,56 @@
+package cpoker
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+func TestAdviseWithDead(t *testing.T) {
+	rnd := rand.New(rand.NewSource(4))
+	cards := append([]poker.Card{}, poker.Cards...)
+	rnd.Shuffle(len(cards), func(i, j int) { cards[i], cards[j] = cards[j], cards[i] })
+	c, dead := cards[:13], cards[13:26]
+	re := &RolloutEvaluator{Separable: true, Opponent: MaxProdEvaluator{}, N: 50}
+	h, _, err := AdviseWithDead(c, dead, re)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckHand(&h, c); err != nil {
+		t.Errorf("AdviseWithDead(%v, %v) = %v: %s", c, dead, &h, err)
+	}
+	if _, _, err := AdviseWithDead(c, c[:1], re); err == nil {
+		t.Errorf("AdviseWithDead with a dealt card as dead succeeded")
+	}
+	if _, _, err := AdviseWithDead(c, cards[13:40], re); err == nil {
+		t.Errorf("AdviseWithDead with 27 dead cards succeeded")
+	}
+}
+
+func TestDeadCardSensitivity(t *testing.T) {
+	rnd := rand.New(rand.NewSource(5))
+	cards := append([]poker.Card{}, poker.Cards...)
+	rnd.Shuffle(len(cards), func(i, j int) { cards[i], cards[j] = cards[j], cards[i] })
+	c := cards[:13]
+	groups := []DeadGroup{{"one", cards[13:14]}, {"many", cards[13:30]}}
+	re := &RolloutEvaluator{Separable: true, Opponent: MaxProdEvaluator{}, N: 50}
+	base, effects, err := DeadCardSensitivity(c, groups, re)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(effects) != len(groups) {
+		t.Fatalf("got %d effects, want %d", len(effects), len(groups))
+	}
+	for _, e := range append(effects, base) {
+		if err := CheckHand(&e.Hand, c); err != nil {
+			t.Errorf("group %q: %s", e.Group.Name, err)
+		}
+		if e.EVDelta != e.EV-base.EV {
+			t.Errorf("group %q: EVDelta = %v, want %v", e.Group.Name, e.EVDelta, e.EV-base.EV)
+		}
+	}
+	if _, _, err := DeadCardSensitivity(c, []DeadGroup{{"dealt", c[:2]}}, re); err == nil {
+		t.Errorf("DeadCardSensitivity with dealt cards dead succeeded")
+	}
+}
@@ -0,0 +1,65 @@
+package cpoker
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// DealPlayers deals 13 cards to each of nPlayers players from a
+// freshly shuffled deck, using rnd, or the global random source if
+// it's nil. It panics if nPlayers isn't between 1 and 4.
+func DealPlayers(rnd *rand.Rand, nPlayers int) [][]poker.Card {
+	hands, err := DealConstrained(rnd, make([][]poker.Card, nPlayers))
+	if err != nil {
+		panic(err)
+	}
+	return hands
+}
+
+// DealConstrained is like DealPlayers, but each player i is dealt the
+// cards fixed[i] (which may be empty), and the rest of their 13 cards
+// at random from the cards nobody's fixed. The number of players is
+// len(fixed).
+func DealConstrained(rnd *rand.Rand, fixed [][]poker.Card) ([][]poker.Card, error) {
+	n := len(fixed)
+	if n < 1 || n > 4 {
+		return nil, errors.New("a deal must have between 1 and 4 players")
+	}
+	var used CardSet
+	for i, f := range fixed {
+		if len(f) > 13 {
+			return nil, fmt.Errorf("player %d has %d fixed cards, want at most 13", i, len(f))
+		}
+		for _, c := range f {
+			if !c.Valid() || used.Contains(c) {
+				return nil, fmt.Errorf("player %d has invalid or repeated card %s", i, c)
+			}
+			used = used.Add(c)
+		}
+	}
+	var deck []poker.Card
+	for _, c := range poker.Cards {
+		if !used.Contains(c) {
+			deck = append(deck, c)
+		}
+	}
+	intn := rand.Intn
+	if rnd != nil {
+		intn = rnd.Intn
+	}
+	// Only as much of the deck is shuffled as is dealt.
+	need := 13*n - used.Len()
+	for i := 0; i < need; i++ {
+		j := intn(len(deck)-i) + i
+		deck[i], deck[j] = deck[j], deck[i]
+	}
+	hands := make([][]poker.Card, n)
+	for i, f := range fixed {
+		hands[i] = append(append(make([]poker.Card, 0, 13), f...), deck[:13-len(f)]...)
+		deck = deck[13-len(f):]
+	}
+	return hands, nil
+}
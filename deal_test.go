@@ -0,0 +1,52 @@
+package cpoker
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+func TestDealPlayers(t *testing.T) {
+	rnd := rand.New(rand.NewSource(9))
+	for n := 1; n <= 4; n++ {
+		hands := DealPlayers(rnd, n)
+		var all []poker.Card
+		for _, h := range hands {
+			if len(h) != 13 {
+				t.Errorf("dealt %d cards, want 13", len(h))
+			}
+			all = append(all, h...)
+		}
+		if _, ok := NewCardSet(all); !ok || len(hands) != n {
+			t.Errorf("DealPlayers(%d) dealt %v, want %d hands of distinct cards", n, hands, n)
+		}
+	}
+	if a, b := DealPlayers(rand.New(rand.NewSource(1)), 2), DealPlayers(rand.New(rand.NewSource(1)), 2); !reflect.DeepEqual(a, b) {
+		t.Errorf("deals with the same seed differ")
+	}
+
+	fixed := [][]poker.Card{mustCards(t, "HAHK"), nil, mustCards(t, "S2S3S4")}
+	hands, err := DealConstrained(rnd, fixed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, f := range fixed {
+		in, _ := NewCardSet(hands[i])
+		for _, c := range f {
+			if !in.Contains(c) {
+				t.Errorf("player %d wasn't dealt fixed card %s", i, c)
+			}
+		}
+	}
+	for _, bad := range [][][]poker.Card{
+		{mustCards(t, "HAHK"), mustCards(t, "HA")},
+		make([][]poker.Card, 5),
+		{poker.Cards[:14]},
+	} {
+		if _, err := DealConstrained(rnd, bad); err == nil {
+			t.Errorf("DealConstrained(%v) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package cpoker
+
+// A DenseRanks maps the ranks which are reachable in a slot to
+// the dense range 0 to Len()-1, preserving their order. Arrays
+// indexed by dense ranks are smaller than those indexed by rank,
+// and dense ranks make better inputs to learned models.
+type DenseRanks struct {
+	toDense   []int
+	fromDense []int16
+}
+
+var denseRanks = [2]*DenseRanks{newDenseRanks(0), newDenseRanks(1)}
+
+func newDenseRanks(slot int) *DenseRanks {
+	d := &DenseRanks{fromDense: ReachableRanks(slot)}
+	d.toDense = make([]int, len(categories[0]))
+	for i := range d.toDense {
+		d.toDense[i] = -1
+	}
+	for i, e := range d.fromDense {
+		d.toDense[e] = i
+	}
+	return d
+}
+
+// DenseRanksFor returns the dense ranks of slot i (0, 1, 2 means front,
+// middle, back). The middle and back share the same dense ranks.
+func DenseRanksFor(i int) *DenseRanks {
+	if i == 0 {
+		return denseRanks[0]
+	}
+	return denseRanks[1]
+}
+
+// Len returns the number of reachable ranks.
+func (d *DenseRanks) Len() int {
+	return len(d.fromDense)
+}
+
+// Dense returns the dense rank of e, or -1 if e isn't reachable.
+func (d *DenseRanks) Dense(e int16) int {
+	if e < 0 || int(e) >= len(d.toDense) {
+		return -1
+	}
+	return d.toDense[e]
+}
+
+// Rank returns the rank with dense rank i.
+func (d *DenseRanks) Rank(i int) int16 {
+	return d.fromDense[i]
+}
@@ -0,0 +1,41 @@
+package cpoker
+
+import (
+	"testing"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+func TestDenseRanks(t *testing.T) {
+	for slot := 0; slot < 3; slot++ {
+		d := DenseRanksFor(slot)
+		if d.Len() != len(ReachableRanks(slot)) {
+			t.Errorf("slot %d has %d dense ranks, want %d", slot, d.Len(), len(ReachableRanks(slot)))
+		}
+		for i := 0; i < d.Len(); i++ {
+			if got := d.Dense(d.Rank(i)); got != i {
+				t.Errorf("slot %d: Dense(Rank(%d)) = %d", slot, i, got)
+			}
+			if i > 0 && d.Rank(i) <= d.Rank(i-1) {
+				t.Errorf("slot %d: dense rank %d has rank %d, not above %d", slot, i, d.Rank(i), d.Rank(i-1))
+			}
+		}
+		unreachable := 0
+		for e := int16(0); e <= poker.ScoreMax; e++ {
+			if !Reachable(slot, e) {
+				unreachable++
+				if got := d.Dense(e); got != -1 {
+					t.Errorf("slot %d: unreachable rank %d has dense rank %d", slot, e, got)
+				}
+			}
+		}
+		if slot == 0 && unreachable == 0 {
+			t.Errorf("every rank is reachable in the front")
+		}
+		for _, e := range []int16{-1, poker.ScoreMax + 1} {
+			if got := d.Dense(e); got != -1 {
+				t.Errorf("slot %d: Dense(%d) = %d, want -1", slot, e, got)
+			}
+		}
+	}
+}
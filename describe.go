@@ -0,0 +1,65 @@
+package cpoker
+
+import (
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+var (
+	descriptionsInit tableInit
+	descriptions     [2][]string // of 3-card and 5-card ranks
+)
+
+// rankDescription returns poker.Describe of a hand of rank e with 3
+// (if i is 0) or 5 cards. Descriptions only depend on rank, so they're
+// computed once for each rank, on first use.
+func rankDescription(i int, e int16) string {
+	descriptionsInit.Do(func() {
+		for j, toHand := range []func(int16) ([]poker.Card, bool){poker.EvalToHand3, poker.EvalToHand5} {
+			descriptions[j] = make([]string, poker.ScoreMax+1)
+			for r := range descriptions[j] {
+				if h, ok := toHand(int16(r)); ok {
+					descriptions[j][r], _ = poker.Describe(h)
+				}
+			}
+		}
+	})
+	return descriptions[i][e]
+}
+
+// DescribeAll returns poker.Describe of each of the hands, which may
+// have 3, 5 or 7 cards. Hands that can't be described (because they
+// have a different number of cards, or invalid or repeated cards) are
+// described as "". Describing a hand only needs its rank, so this is
+// much faster than calling Describe for each hand, and the
+// descriptions of hands of the same rank share their storage.
+func DescribeAll(hands [][]poker.Card) []string {
+	r := make([]string, len(hands))
+	for i, h := range hands {
+		if _, ok := NewCardSet(h); ok && (len(h) == 3 || len(h) == 5 || len(h) == 7) {
+			r[i] = describeValid(h)
+		}
+	}
+	return r
+}
+
+// DescribeAllValid is like DescribeAll, but skips checking the hands,
+// for hands which are already known to be valid. The descriptions of
+// invalid hands are meaningless.
+func DescribeAllValid(hands [][]poker.Card) []string {
+	r := make([]string, len(hands))
+	for i, h := range hands {
+		r[i] = describeValid(h)
+	}
+	return r
+}
+
+// describeValid describes a valid hand of 3, 5 or 7 cards.
+func describeValid(h []poker.Card) string {
+	switch len(h) {
+	case 3:
+		return rankDescription(0, poker.Eval3(&[3]poker.Card{h[0], h[1], h[2]}))
+	case 5:
+		return rankDescription(1, poker.Eval5(&[5]poker.Card{h[0], h[1], h[2], h[3], h[4]}))
+	}
+	return rankDescription(1, poker.Eval7(&[7]poker.Card{h[0], h[1], h[2], h[3], h[4], h[5], h[6]}))
+}
@@ -0,0 +1,32 @@
+package cpoker
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+func TestDescribeAll(t *testing.T) {
+	rnd := rand.New(rand.NewSource(6))
+	var hands [][]poker.Card
+	for i := 0; i < 300; i++ {
+		hands = append(hands, randomDeal(rnd)[:[]int{3, 5, 7}[i%3]])
+	}
+	got, gotValid := DescribeAll(hands), DescribeAllValid(hands)
+	for i, h := range hands {
+		want, err := poker.Describe(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got[i] != want || gotValid[i] != want {
+			t.Errorf("described %v as %q and %q, want %q", h, got[i], gotValid[i], want)
+		}
+	}
+	bad := [][]poker.Card{mustCards(t, "HAHA"), mustCards(t, "HAHAHK"), mustCards(t, "HAHKHQHJ"), nil}
+	for i, d := range DescribeAll(bad) {
+		if d != "" {
+			t.Errorf("described %v as %q, want \"\"", bad[i], d)
+		}
+	}
+}
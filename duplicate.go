@@ -0,0 +1,117 @@
+package cpoker
+
+import (
+	"math"
+	"sort"
+)
+
+// A DuplicateEntry is one player's arrangement of a duplicate hand.
+type DuplicateEntry struct {
+	Player string
+	Hand   Hand
+}
+
+// A DuplicateBoard is a deal played at many tables. At each table,
+// one player is dealt the first hand of cards (A) and their opponent
+// the second (B). Each player's arrangement is scored against every
+// arrangement of the other hand, so a player's result depends only
+// on their own decisions and the field's.
+type DuplicateBoard struct {
+	A, B []DuplicateEntry
+}
+
+// A DuplicateResult is a player's result on one board.
+type DuplicateResult struct {
+	Player      string
+	Raw         float64 // The average score against the field's arrangements of the other hand
+	Matchpoints float64 // The fraction of players with the same cards who scored less (ties count half)
+	IMPs        int     // The difference from the average raw score, on an IMP-like scale
+}
+
+// impThresholds are the differences in points from the field average
+// at which each extra IMP is won.
+var impThresholds = []float64{0.5, 1, 1.5, 2, 3, 4, 5, 6, 8, 10, 12, 15}
+
+// IMPs converts a difference in points to IMPs.
+func IMPs(diff float64) int {
+	n := sort.SearchFloat64s(impThresholds, math.Abs(diff)+1e-9)
+	if diff < 0 {
+		return -n
+	}
+	return n
+}
+
+// Score returns the results of every player on the board.
+func (b *DuplicateBoard) Score(s *Scoring) []DuplicateResult {
+	return append(scoreDirection(s, b.A, b.B), scoreDirection(s, b.B, b.A)...)
+}
+
+func scoreDirection(s *Scoring, us, them []DuplicateEntry) []DuplicateResult {
+	r := make([]DuplicateResult, len(us))
+	mean := 0.0
+	for i := range us {
+		r[i].Player = us[i].Player
+		for j := range them {
+			r[i].Raw += float64(s.Score(&us[i].Hand, &them[j].Hand))
+		}
+		if len(them) > 0 {
+			r[i].Raw /= float64(len(them))
+		}
+		mean += r[i].Raw / float64(len(us))
+	}
+	for i := range r {
+		if len(r) > 1 {
+			mp := 0.0
+			for j := range r {
+				if j != i && r[i].Raw > r[j].Raw {
+					mp++
+				} else if j != i && r[i].Raw == r[j].Raw {
+					mp += 0.5
+				}
+			}
+			r[i].Matchpoints = mp / float64(len(r)-1)
+		}
+		r[i].IMPs = IMPs(r[i].Raw - mean)
+	}
+	return r
+}
+
+// A DuplicateTotal is a player's aggregated result over many boards.
+type DuplicateTotal struct {
+	Player      string
+	Boards      int     // How many boards the player played
+	Raw         float64 // The total raw score
+	Matchpoints float64 // The average matchpoint fraction
+	IMPs        int     // The total IMPs
+}
+
+// DuplicateTotals aggregates the results of boards by player,
+// ordered by matchpoints, best first.
+func DuplicateTotals(boards ...[]DuplicateResult) []DuplicateTotal {
+	byPlayer := map[string]*DuplicateTotal{}
+	var r []DuplicateTotal
+	for _, b := range boards {
+		for _, res := range b {
+			t, ok := byPlayer[res.Player]
+			if !ok {
+				t = &DuplicateTotal{Player: res.Player}
+				byPlayer[res.Player] = t
+			}
+			t.Boards++
+			t.Raw += res.Raw
+			t.Matchpoints += res.Matchpoints
+			t.IMPs += res.IMPs
+		}
+	}
+	for _, t := range byPlayer {
+		t.Matchpoints /= float64(t.Boards)
+		r = append(r, *t)
+	}
+	sort.Slice(r, func(i, j int) bool {
+		if r[i].Matchpoints != r[j].Matchpoints {
+			return r[i].Matchpoints > r[j].Matchpoints
+		}
+		return r[i].Player < r[j].Player
+	})
+	return r
+}
@@ -0,0 +1,69 @@
+package cpoker
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestIMPs(t *testing.T) {
+	for _, tc := range []struct {
+		diff float64
+		want int
+	}{
+		{0, 0}, {0.4, 0}, {0.5, 1}, {0.99, 1}, {1, 2}, {-0.4, 0}, {-0.5, -1}, {-3, -5}, {15, 12}, {100, 12}, {-100, -12},
+	} {
+		if got := IMPs(tc.diff); got != tc.want {
+			t.Errorf("IMPs(%v) = %d, want %d", tc.diff, got, tc.want)
+		}
+	}
+}
+
+func TestDuplicateBoard(t *testing.T) {
+	deal := DealPlayers(rand.New(rand.NewSource(3)), 2)
+	a, _ := Play(deal[0], MaxProdEvaluator{})
+	b, _ := Play(deal[1], MaxProdEvaluator{})
+	foul := a
+	foul.Middle, foul.Back = a.Back, a.Middle
+	board := &DuplicateBoard{
+		A: []DuplicateEntry{{"x", a}, {"y", a}, {"z", foul}},
+		B: []DuplicateEntry{{"w", b}},
+	}
+	s := Scoring2to4
+	rs := board.Score(s)
+	if len(rs) != 4 {
+		t.Fatalf("got %d results, want 4", len(rs))
+	}
+	byPlayer := map[string]DuplicateResult{}
+	for _, r := range rs {
+		byPlayer[r.Player] = r
+	}
+	x, y, z, w := byPlayer["x"], byPlayer["y"], byPlayer["z"], byPlayer["w"]
+	if want := float64(s.Score(&a, &b)); x.Raw != want {
+		t.Errorf("x scored %f, want %f", x.Raw, want)
+	}
+	if z.Raw >= x.Raw {
+		t.Fatalf("the foul scored %f, at least the %f of the hand played", z.Raw, x.Raw)
+	}
+	// x and y tie, so each gets half a matchpoint from the other.
+	if x.Matchpoints != 0.75 || y.Matchpoints != 0.75 || z.Matchpoints != 0 {
+		t.Errorf("got matchpoints %f, %f, %f, want 0.75, 0.75, 0", x.Matchpoints, y.Matchpoints, z.Matchpoints)
+	}
+	// A player with nobody to compare with gets no matchpoints, and
+	// is the field average.
+	if w.Matchpoints != 0 || w.IMPs != 0 {
+		t.Errorf("w got %f matchpoints and %d IMPs, want 0", w.Matchpoints, w.IMPs)
+	}
+	mean := (x.Raw + y.Raw + z.Raw) / 3
+	if x.IMPs != IMPs(x.Raw-mean) || z.IMPs != IMPs(z.Raw-mean) || z.IMPs >= 0 {
+		t.Errorf("got IMPs %d and %d for raw scores %f and %f around %f", x.IMPs, z.IMPs, x.Raw, z.Raw, mean)
+	}
+
+	totals := DuplicateTotals(rs, rs)
+	if len(totals) != 4 || totals[0].Player != "x" || totals[1].Player != "y" {
+		t.Fatalf("got totals %+v, want x and y first", totals)
+	}
+	if tx := totals[0]; tx.Boards != 2 || tx.Raw != 2*x.Raw || math.Abs(tx.Matchpoints-x.Matchpoints) > 1e-9 || tx.IMPs != 2*x.IMPs {
+		t.Errorf("x has totals %+v, want twice %+v", tx, x)
+	}
+}
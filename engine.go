@@ -0,0 +1,366 @@
+package cpoker
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// The engine protocol lets programs which play Chinese poker talk to
+// each other over a pair of pipes, for example stdin and stdout of
+// an engine process. It's line-based, and cards are written as in
+// poker.NameToCard (for example HA or C8), separated by spaces.
+//
+// The controller first sends "cpoker 1", and the engine replies
+// "ok <name>". Then for each hand, the controller sends "deal"
+// followed by 13 cards, and the engine replies "hand" followed by
+// the 3 front cards, the 5 middle cards and the 5 back cards. The
+// controller ends the session by sending "quit" or closing the pipe.
+//
+// A deal may be followed by options of the form key=value, which
+// limit the work done for that hand: "hands=N" limits how many hands
+// are evaluated, "time=D" (for example time=200ms) limits how long is
+// spent, and "evaluator=NAME" selects one of the engine's evaluators.
+// An engine never spends more than its own budget, whatever the
+// options say. If a deal can't be parsed or played, the engine replies
+// "error" followed by a description of what went wrong, and carries
+// on with the next request.
+//
+// If the engine allows it, the controller may also send the admin
+// requests "load NAME FILE", which loads an evaluator from a file
+// (replacing any evaluator with the same name), "unload NAME", and
+// "reload NAME", which loads an evaluator again from the file it
+// came from. The engine replies "ok", or "error" followed by a
+// description of what went wrong.
+//
+// The controller may tell the engine how an opponent played a hand, by
+// sending "observe" followed by the 3 front cards, the 5 middle cards
+// and the 5 back cards. Evaluators which learn from opponents' hands
+// (see Observer) are updated, and the engine replies "ok".
+//
+// If the engine is running an A/B test, deals which don't select an
+// evaluator are played by one of the test's evaluators, chosen at
+// random. The controller then sends "result" followed by the points the
+// engine scored with that hand, and the engine replies "ok".
+//
+// In variants where players may pass a deal rather than play it, an
+// engine with a pass policy replies "pass" instead of a hand to the
+// deals it passes.
+//
+// The engine replies "error" to any request it doesn't understand (or,
+// if admin requests aren't allowed, an admin request), and carries on.
+
+// EngineProtocol is the first line sent by the controller.
+const EngineProtocol = "cpoker 1"
+
+// FormatEngineHand formats a hand as an engine's reply.
+func FormatEngineHand(h *Hand) string {
+	all := append(append(cardNames(h.Front[:]), cardNames(h.Middle[:])...), cardNames(h.Back[:])...)
+	return "hand " + strings.Join(all, " ")
+}
+
+// ErrPassed is returned by ParseEngineHand if the engine passed the
+// deal.
+var ErrPassed = errors.New("engine passed the deal")
+
+// ParseEngineHand parses an engine's reply.
+func ParseEngineHand(line string) (Hand, error) {
+	var h Hand
+	if line == "pass" {
+		return h, ErrPassed
+	}
+	if strings.HasPrefix(line, "error ") {
+		return h, fmt.Errorf("engine error: %s", line[len("error "):])
+	}
+	if !strings.HasPrefix(line, "hand ") {
+		return h, fmt.Errorf("want a hand, got %q", line)
+	}
+	c, err := ParseCards(line[len("hand "):])
+	if err != nil {
+		return h, err
+	}
+	if len(c) != 13 {
+		return h, fmt.Errorf("hand has %d cards, want 13", len(c))
+	}
+	copy(h.Front[:], c[:3])
+	copy(h.Middle[:], c[3:8])
+	copy(h.Back[:], c[8:])
+	return h, nil
+}
+
+// An Observer is a HandEvaluator which learns from hands that
+// opponents play, for example an OnlineEvaluator.
+type Observer interface {
+	HandEvaluator
+	Observe(h *Hand)
+}
+
+// An Engine plays hands using the engine protocol. It serves one
+// controller, and plays its deals one at a time, so the work it does at
+// once is bounded by its Budget. It isn't safe to Serve several
+// controllers at once: a deployment limits how many deals are played
+// concurrently by how many engines it runs.
+type Engine struct {
+	Name string
+
+	// Evaluators are the evaluators the engine can use, by name.
+	// The one named "default" is used unless a deal selects another.
+	Evaluators map[string]HandEvaluator
+
+	// Budget is the most work done for a single deal.
+	Budget PlayBudget
+
+	// Admin allows the admin requests.
+	Admin bool
+
+	// If AB is non-nil, deals are used for an A/B test.
+	AB *ABTest
+
+	// If Telemetry is non-nil, an anonymous TelemetryRecord of each hand
+	// the engine plays is written to it as a line of JSON. Deals are
+	// hashed with TelemetryKey, which should be kept secret. If it's
+	// empty, a random key is made, so only records from the same
+	// engine can be matched.
+	Telemetry    io.Writer
+	TelemetryKey []byte
+
+	// If Overlay is non-nil, each hand the engine plays is published
+	// to it.
+	Overlay *Overlay
+
+	// If Pass is non-nil, the engine passes the deals it chooses to,
+	// which aren't then part of any A/B test. Deals are judged by the
+	// evaluator which plays them, within the budget (see
+	// PassPolicy.PlayWithBudget), and the policy's own evaluator isn't
+	// used.
+	Pass *PassPolicy
+
+	abArm int // the arm of the A/B test which played the last deal, or -1
+
+	files map[string]string // the files evaluators were loaded from
+}
+
+// LoadEvaluator loads the named evaluator from a file, as with
+// LoadEvaluatorFile, replacing any evaluator with the same name.
+func (e *Engine) LoadEvaluator(name, filename string) error {
+	he, err := LoadEvaluatorFile(filename)
+	if err != nil {
+		return err
+	}
+	if e.Evaluators == nil {
+		e.Evaluators = map[string]HandEvaluator{}
+	}
+	if e.files == nil {
+		e.files = map[string]string{}
+	}
+	e.Evaluators[name] = he
+	e.files[name] = filename
+	return nil
+}
+
+// admin carries out an admin request.
+func (e *Engine) admin(args []string) error {
+	switch {
+	case args[0] == "load" && len(args) == 3:
+		return e.LoadEvaluator(args[1], args[2])
+	case args[0] == "unload" && len(args) == 2:
+		if _, ok := e.Evaluators[args[1]]; !ok {
+			return fmt.Errorf("no evaluator %q", args[1])
+		}
+		delete(e.Evaluators, args[1])
+		delete(e.files, args[1])
+		return nil
+	case args[0] == "reload" && len(args) == 2:
+		filename, ok := e.files[args[1]]
+		if !ok {
+			return fmt.Errorf("evaluator %q wasn't loaded from a file", args[1])
+		}
+		return e.LoadEvaluator(args[1], filename)
+	}
+	return fmt.Errorf("bad request %q", strings.Join(args, " "))
+}
+
+// ServeEngine acts as an engine with the given name, reading
+// requests from r and writing replies to w. Hands are played
+// with he. It returns when the controller quits.
+func ServeEngine(r io.Reader, w io.Writer, name string, he HandEvaluator) error {
+	e := &Engine{Name: name, Evaluators: map[string]HandEvaluator{"default": he}}
+	return e.Serve(r, w)
+}
+
+// minLimit returns the smaller of two limits, where 0 means no limit.
+func minLimit(a, b int64) int64 {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// parseDeal parses the arguments of a deal request: the cards, then
+// any options.
+func (e *Engine) parseDeal(args string) ([]poker.Card, HandEvaluator, PlayBudget, error) {
+	fields := strings.Fields(args)
+	n := 0
+	for n < len(fields) && !strings.Contains(fields[n], "=") {
+		n++
+	}
+	c, err := ParseCards(strings.Join(fields[:n], " "))
+	if err != nil {
+		return nil, nil, PlayBudget{}, err
+	}
+	he, b := e.Evaluators["default"], e.Budget
+	e.abArm = -1
+	if e.AB != nil {
+		e.abArm = e.AB.Assign()
+		he = e.AB.Arms[e.abArm]
+	}
+	for _, opt := range fields[n:] {
+		kv := strings.SplitN(opt, "=", 2)
+		if len(kv) != 2 {
+			return nil, nil, b, fmt.Errorf("bad option %q", opt)
+		}
+		switch kv[0] {
+		case "hands":
+			hands, err := strconv.Atoi(kv[1])
+			if err != nil || hands <= 0 {
+				return nil, nil, b, fmt.Errorf("bad option %q", opt)
+			}
+			b.MaxHands = int(minLimit(int64(b.MaxHands), int64(hands)))
+		case "time":
+			d, err := time.ParseDuration(kv[1])
+			if err != nil || d <= 0 {
+				return nil, nil, b, fmt.Errorf("bad option %q", opt)
+			}
+			b.Time = time.Duration(minLimit(int64(b.Time), int64(d)))
+		case "evaluator":
+			he = e.Evaluators[kv[1]]
+			e.abArm = -1
+		default:
+			return nil, nil, b, fmt.Errorf("unknown option %q", opt)
+		}
+	}
+	if he == nil {
+		return nil, nil, b, fmt.Errorf("no evaluator for %q", args)
+	}
+	return c, he, b, nil
+}
+
+// Serve reads requests from r and writes replies to w. It returns
+// when the controller quits.
+func (e *Engine) Serve(r io.Reader, w io.Writer) error {
+	s := bufio.NewScanner(r)
+	if !s.Scan() {
+		return s.Err()
+	}
+	if s.Text() != EngineProtocol {
+		return fmt.Errorf("unknown protocol %q", s.Text())
+	}
+	if _, err := fmt.Fprintf(w, "ok %s\n", e.Name); err != nil {
+		return err
+	}
+	for s.Scan() {
+		line := s.Text()
+		if line == "quit" {
+			return nil
+		}
+		if args := strings.Fields(line); e.Admin && len(args) > 0 && (args[0] == "load" || args[0] == "unload" || args[0] == "reload") {
+			reply := "ok"
+			if err := e.admin(args); err != nil {
+				reply = "error " + err.Error()
+			}
+			if _, err := fmt.Fprintln(w, reply); err != nil {
+				return err
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "result ") {
+			reply := "ok"
+			if score, err := strconv.ParseFloat(line[len("result "):], 64); err != nil {
+				reply = "error bad result " + line
+			} else if e.AB == nil || e.abArm < 0 {
+				reply = "error no A/B test result expected"
+			} else {
+				e.AB.Record(e.abArm, score)
+				e.abArm = -1
+			}
+			if _, err := fmt.Fprintln(w, reply); err != nil {
+				return err
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "observe ") {
+			reply := "ok"
+			if h, err := ParseEngineHand("hand " + line[len("observe "):]); err != nil {
+				reply = "error " + err.Error()
+			} else {
+				for _, he := range e.Evaluators {
+					if o, ok := he.(Observer); ok {
+						o.Observe(&h)
+					}
+				}
+			}
+			if _, err := fmt.Fprintln(w, reply); err != nil {
+				return err
+			}
+			continue
+		}
+		if !strings.HasPrefix(line, "deal ") {
+			e.abArm = -1
+			if _, err := fmt.Fprintf(w, "error unknown request %q\n", line); err != nil {
+				return err
+			}
+			continue
+		}
+		c, he, b, err := e.parseDeal(line[len("deal "):])
+		if err != nil {
+			e.abArm = -1
+			if _, err := fmt.Fprintln(w, "error "+err.Error()); err != nil {
+				return err
+			}
+			continue
+		}
+		h, stats, pass, err := e.Pass.PlayWithBudget(c, he, b)
+		if err != nil {
+			e.abArm = -1
+			if _, err := fmt.Fprintln(w, "error "+err.Error()); err != nil {
+				return err
+			}
+			continue
+		}
+		if pass {
+			e.abArm = -1
+			if _, err := fmt.Fprintln(w, "pass"); err != nil {
+				return err
+			}
+			continue
+		}
+		if e.Telemetry != nil {
+			if len(e.TelemetryKey) == 0 {
+				e.TelemetryKey = make([]byte, 32)
+				if _, err := rand.Read(e.TelemetryKey); err != nil {
+					return err
+				}
+			}
+			if err := json.NewEncoder(e.Telemetry).Encode(NewTelemetryRecord(e.TelemetryKey, c, &h, stats)); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(w, FormatEngineHand(&h)); err != nil {
+			return err
+		}
+		if e.Overlay != nil {
+			if err := e.Overlay.Publish(c, &h, he); err != nil {
+				return err
+			}
+		}
+	}
+	return s.Err()
+}
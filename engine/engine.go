@@ -0,0 +1,168 @@
+// Binary engine plays chinese poker hands using the engine protocol
+// on stdin and stdout, so it can be matched against other engines.
+// For example:
+//  engine -from coefficients.data
+package main
+
+import (
+	"flag"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/paulhankin/cpoker"
+)
+
+var (
+	fromFile = flag.String("from", "", "file to load coefficients from")
+	name     = flag.String("name", "cpoker", "the name of the engine")
+	warmup   = flag.Int("warmup", 0, "how many random deals to play before accepting requests")
+	maxP99   = flag.Duration("max_p99", 0, "if non-zero, fail to start if the p99 latency of the warmup deals is larger than this")
+	maxHands = flag.Int("max_hands", 0, "if non-zero, the most hands to evaluate for a single deal")
+	maxTime  = flag.Duration("max_time", 0, "if non-zero, the most time to spend on a single deal")
+	others   = flag.String("evaluators", "", "comma-separated name=file pairs of other evaluators which deals may select")
+	admin    = flag.Bool("admin", false, "allow the controller to load, unload and reload evaluators")
+	rate     = flag.Float64("learn_rate", 0, "if non-zero, adapt the default evaluator to the opponents' hands the controller reports, at this rate")
+	abWith   = flag.String("ab", "", "if set, the name of an evaluator (from -evaluators) to A/B test against the default evaluator")
+	abAlpha  = flag.Float64("ab_alpha", 0.05, "with -ab, the significance level of the test")
+	abReport = flag.Duration("ab_report", 10*time.Minute, "with -ab, how often to log the results of the test so far, or 0 to only log them when the engine stops")
+	telem    = flag.String("telemetry", "", "if set, append an anonymous record of each hand played to this file")
+	telemKey = flag.String("telemetry_key", "", "with -telemetry, a secret key to hash deals with, so records from different runs can be matched; if empty, a random key is used")
+	maxDrift = flag.Float64("max_drift", 0.05, "with -learn_rate, the most any win probability may move from the loaded coefficients")
+	overlay  = flag.String("overlay", "", "if set, the address to serve an overlay feed of the hands played on, as Server-Sent Events at /events")
+	oddsN    = flag.Int("overlay_odds", 200, "with -overlay, how many deals to estimate each hand's odds from, or 0 for no odds")
+	passCost = flag.Int("surrender", 0, "if positive, the points a player pays each opponent for passing a deal; the engine then passes deals when it pays to, with a policy trained at startup")
+	passN    = flag.Int("pass_deals", 2000, "with -surrender, how many 3-player deals to train the pass policy on")
+	scoring  = flag.String("scoring", "", "with -surrender, how hands are scored ("+strings.Join(cpoker.ScoringNames(), ", ")+"); if empty, the scoring the default evaluator was saved with, or 2-4")
+)
+
+func main() {
+	flag.Parse()
+	if *fromFile == "" {
+		log.Fatalf("-from must be specified")
+	}
+	e := &cpoker.Engine{
+		Name:   *name,
+		Budget: cpoker.PlayBudget{MaxHands: *maxHands, Time: *maxTime},
+		Admin:  *admin,
+	}
+	if err := e.LoadEvaluator("default", *fromFile); err != nil {
+		log.Fatalf("failed to load coefficients: %s", err)
+	}
+	if *rate != 0 {
+		se, ok := e.Evaluators["default"].(*cpoker.SampledEvaluator)
+		if !ok {
+			log.Fatalf("-learn_rate needs a sampled evaluator")
+		}
+		oe, err := cpoker.NewOnlineEvaluator(se, *rate, *maxDrift)
+		if err != nil {
+			log.Fatalf("bad -learn_rate or -max_drift: %s", err)
+		}
+		e.Evaluators["default"] = oe
+	}
+	if *others != "" {
+		for _, nf := range strings.Split(*others, ",") {
+			kv := strings.SplitN(nf, "=", 2)
+			if len(kv) != 2 {
+				log.Fatalf("bad -evaluators entry %q: want name=file", nf)
+			}
+			if err := e.LoadEvaluator(kv[0], kv[1]); err != nil {
+				log.Fatalf("failed to load evaluator %q: %s", kv[0], err)
+			}
+		}
+	}
+	if *warmup > 0 {
+		lat := cpoker.WarmUp(rand.New(rand.NewSource(time.Now().UnixNano())), e.Evaluators["default"], *warmup)
+		log.Printf("warmed up: %s", lat)
+		if *maxP99 > 0 && lat.P99 > *maxP99 {
+			log.Fatalf("p99 latency %s is more than -max_p99 %s", lat.P99, *maxP99)
+		}
+	}
+	if *telem != "" {
+		f, err := os.OpenFile(*telem, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Fatalf("failed to open telemetry: %s", err)
+		}
+		defer f.Close()
+		e.Telemetry = f
+		e.TelemetryKey = []byte(*telemKey)
+	}
+	if *overlay != "" {
+		e.Overlay = &cpoker.Overlay{OddsSamples: *oddsN}
+		mux := http.NewServeMux()
+		mux.Handle("/events", e.Overlay)
+		go func() {
+			log.Fatal(http.ListenAndServe(*overlay, mux))
+		}()
+	}
+	if *passCost > 0 {
+		ep, ok := e.Evaluators["default"].(cpoker.EvaluatorPoints)
+		if !ok {
+			log.Fatalf("-surrender needs an evaluator which can value hands in points")
+		}
+		sc := cpoker.Scoring2to4
+		if se, ok := ep.(*cpoker.SampledEvaluator); ok && se.Scoring != nil {
+			sc = se.Scoring
+		} else if oe, ok := ep.(*cpoker.OnlineEvaluator); ok {
+			if s := oe.Snapshot().Scoring; s != nil {
+				sc = s
+			}
+		}
+		if *scoring != "" {
+			var err error
+			if sc, err = cpoker.ScoringByName(*scoring); err != nil {
+				log.Fatalf("bad -scoring: %s", err)
+			}
+		}
+		s := *sc
+		s.Surrender = *passCost
+		p, err := cpoker.TrainPassPolicy(rand.New(rand.NewSource(time.Now().UnixNano())), ep, ep, 3, *passN, &s)
+		if err != nil {
+			log.Fatalf("failed to train pass policy: %s", err)
+		}
+		log.Printf("passing deals with strength below %.3f", p.Threshold)
+		e.Pass = p
+	}
+	if *abWith != "" {
+		b, ok := e.Evaluators[*abWith]
+		if !ok {
+			log.Fatalf("-ab: no evaluator %q", *abWith)
+		}
+		e.AB = cpoker.NewABTest(nil, "default", e.Evaluators["default"], *abWith, b)
+		e.AB.Alpha = *abAlpha
+		go reportAB(e.AB, *abReport)
+	}
+	err := e.Serve(os.Stdin, os.Stdout)
+	if e.AB != nil {
+		log.Printf("A/B test: %s", e.AB)
+	}
+	if err != nil {
+		log.Fatalf("engine failed: %s", err)
+	}
+}
+
+// reportAB logs the results of the A/B test every so often (unless
+// every is zero), and when the engine is interrupted or terminated,
+// before it exits.
+func reportAB(ab *cpoker.ABTest, every time.Duration) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	var tick <-chan time.Time
+	if every > 0 {
+		tick = time.NewTicker(every).C
+	}
+	for {
+		select {
+		case <-tick:
+			log.Printf("A/B test so far: %s", ab)
+		case s := <-sigs:
+			log.Printf("A/B test: %s", ab)
+			log.Fatalf("stopped by %s", s)
+		}
+	}
+}
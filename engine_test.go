@@ -0,0 +1,123 @@
+package cpoker
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestEngineDealOptions(t *testing.T) {
+	c := randomDeal(rand.New(rand.NewSource(2)))
+	deal := "deal " + strings.Join(cardNames(c), " ")
+	e := &Engine{Name: "test", Evaluators: map[string]HandEvaluator{"default": MaxProdEvaluator{}, "other": MaxProdEvaluator{}}}
+	for _, tc := range []struct {
+		opts    string
+		wantErr bool
+	}{
+		{"", false},
+		{" hands=1 time=1s evaluator=other", false},
+		{" evaluator=missing", true},
+		{" hands=0", true},
+		{" colour=blue", true},
+		{" time=1s hands", true},
+		{" HA", true},
+	} {
+		// A deal that can't be played gets an error reply, and the
+		// engine carries on with the next deal.
+		in := strings.NewReader(EngineProtocol + "\n" + deal + tc.opts + "\n" + deal + "\nquit\n")
+		var out bytes.Buffer
+		if err := e.Serve(in, &out); err != nil {
+			t.Fatalf("deal with options %q: %s", tc.opts, err)
+		}
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("deal with options %q: got replies %q", tc.opts, lines)
+		}
+		if got := strings.HasPrefix(lines[1], "error "); got != tc.wantErr {
+			t.Errorf("deal with options %q: got reply %q, want an error %v", tc.opts, lines[1], tc.wantErr)
+			continue
+		}
+		for _, line := range lines[1+b2i(tc.wantErr):] {
+			h, err := ParseEngineHand(line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := CheckHand(&h, c); err != nil {
+				t.Errorf("deal with options %q: %s", tc.opts, err)
+			}
+		}
+	}
+}
+
+func TestEnginePass(t *testing.T) {
+	c := randomDeal(rand.New(rand.NewSource(2)))
+	deal := "deal " + strings.Join(cardNames(c), " ")
+	s := &Scoring{Name: "surrender", Slot: 1, Majority: 1, Surrender: 2}
+	se := smallSampledEvaluator(t, 100)
+	// The deal is judged by the evaluator which plays it, so the
+	// policy doesn't need one, and an evaluator which can't value hands
+	// in points never passes.
+	for _, tc := range []struct {
+		threshold float64
+		opts      string
+		pass      bool
+	}{{math.Inf(1), "", true}, {math.Inf(-1), "", false}, {math.Inf(1), " evaluator=maxprod", false}} {
+		e := &Engine{Name: "test", Evaluators: map[string]HandEvaluator{"default": se, "maxprod": MaxProdEvaluator{}}, Pass: &PassPolicy{Scoring: s, Threshold: tc.threshold}}
+		var out bytes.Buffer
+		if err := e.Serve(strings.NewReader(EngineProtocol+"\n"+deal+tc.opts+"\nquit\n"), &out); err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("got replies %q", lines)
+		}
+		if _, err := ParseEngineHand(lines[1]); (err == ErrPassed) != tc.pass {
+			t.Errorf("with threshold %f and options %q, the engine replied %q", tc.threshold, tc.opts, lines[1])
+		}
+	}
+	// The strength is the points expected from the hand played.
+	want, points, _ := PlayForPoints(c, se, s)
+	for _, tc := range []struct {
+		threshold float64
+		pass      bool
+	}{{points + 1e-9, true}, {points, false}} {
+		p := &PassPolicy{Scoring: s, Threshold: tc.threshold}
+		h, _, pass, err := p.PlayWithBudget(c, se, PlayBudget{})
+		if err != nil || h != want || pass != tc.pass {
+			t.Errorf("with threshold %f, played %s, passed %v, error %v; want %s, %v", tc.threshold, &h, pass, err, &want, tc.pass)
+		}
+	}
+}
+
+func TestEngineAdmin(t *testing.T) {
+	e := &Engine{Name: "test", Evaluators: map[string]HandEvaluator{"default": MaxProdEvaluator{}}, Admin: true}
+	in := strings.NewReader(EngineProtocol + `
+load trained coefficients.data
+reload trained
+reload default
+unload trained
+unload trained
+load bad no-such-file.data
+frobnicate trained
+quit
+`)
+	var out bytes.Buffer
+	if err := e.Serve(in, &out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	want := []string{"ok test", "ok", "ok", "error", "ok", "error", "error", "error unknown request"}
+	if len(lines) != len(want) {
+		t.Fatalf("got replies %q, want %q", lines, want)
+	}
+	for i := range want {
+		if !strings.HasPrefix(lines[i], want[i]) {
+			t.Errorf("reply %d = %q, want %q", i, lines[i], want[i])
+		}
+	}
+	if _, ok := e.Evaluators["trained"]; ok {
+		t.Errorf("evaluator still present after unload")
+	}
+}
@@ -0,0 +1,70 @@
+package cpoker
+
+import (
+	"errors"
+	"math/rand"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// An Ensemble is a set of sampled evaluators trained on bootstrap
+// resamples of the same rollout. How much the members agree shows how
+// much a decision depends on the sampling noise in the training data.
+type Ensemble struct {
+	Members []*SampledEvaluator
+}
+
+// NewBootstrapEnsemble makes an ensemble of k evaluators from a separable,
+// pre-rolled-out RolloutEvaluator. The members are the rollout's
+// SampledEvaluator resampled with Bootstrap, using rnd (or the global
+// random source if it's nil).
+func NewBootstrapEnsemble(re *RolloutEvaluator, k int, rnd *rand.Rand) (*Ensemble, error) {
+	if k < 1 {
+		return nil, errors.New("an ensemble needs at least one member")
+	}
+	se, err := NewSampledEvaluatorFromRollout(re)
+	if err != nil {
+		return nil, err
+	}
+	members, err := se.Bootstrap(rnd, k)
+	if err != nil {
+		return nil, err
+	}
+	return &Ensemble{Members: members}, nil
+}
+
+// TrainBootstrapEnsemble samples N hands played by opp, and makes an
+// ensemble of k evaluators from them, as NewBootstrapEnsemble. The
+// evaluators value hands using the given scoring (or Scoring2to4 if
+// it's nil). Both the hands and the resamples are drawn using rnd.
+func TrainBootstrapEnsemble(opp HandEvaluator, N, k int, s *Scoring, rnd *rand.Rand) (*Ensemble, error) {
+	re := &RolloutEvaluator{PreRollout: true, Separable: true, Opponent: opp, N: N, Scoring: s, Rand: rnd}
+	re.Init()
+	return NewBootstrapEnsemble(re, k, rnd)
+}
+
+// Evaluator returns a hand evaluator for the given set of cards, which
+// values hands at the average of the members' values.
+func (e *Ensemble) Evaluator(cs []poker.Card) func(f, m, b int16) float64 {
+	evs := make([]func(f, m, b int16) float64, len(e.Members))
+	for i, se := range e.Members {
+		evs[i] = se.Evaluator(cs)
+	}
+	return func(f, m, b int16) float64 {
+		t := 0.0
+		for _, ev := range evs {
+			t += ev(f, m, b)
+		}
+		return t / float64(len(evs))
+	}
+}
+
+// DecisionConfidence plays the cards with the ensemble, and returns the
+// arrangement chosen and the fraction of members which agree with it,
+// as ArrangementStability reports. A fraction near 1 means the decision
+// is robust to the sampling noise in the training data; a small
+// fraction means it's a coin flip between arrangements.
+func (e *Ensemble) DecisionConfidence(c []poker.Card) (Hand, float64) {
+	s := ArrangementStability(c, e, e.Members)
+	return s.Hand, s.Agreement
+}
@@ -0,0 +1,44 @@
+package cpoker
+
+import (
+	"fmt"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// eval returns the rank of a 3- or 5-card hand.
+func eval(c []poker.Card) int16 {
+	if len(c) == 3 {
+		return poker.Eval3(&[3]poker.Card{c[0], c[1], c[2]})
+	}
+	return poker.Eval5(&[5]poker.Card{c[0], c[1], c[2], c[3], c[4]})
+}
+
+// EvalN returns the rank of the best hand that can be made from c:
+// 3 or 4 cards are ranked as the best 3-card hand (as Eval3 would),
+// and 5 to 7 cards as the best 5-card hand (as Eval5 would), so
+// partial rows and hands with spare cards can be compared with the
+// ranks of complete hands. It's an error if there are fewer than 3 or
+// more than 7 cards.
+func EvalN(c []poker.Card) (int16, error) {
+	switch len(c) {
+	case 3, 5:
+		return eval(c), nil
+	case 7:
+		return poker.Eval7(&[7]poker.Card{c[0], c[1], c[2], c[3], c[4], c[5], c[6]}), nil
+	case 4, 6:
+		size := len(c) - 1
+		best := int16(0)
+		h := make([]poker.Card, size)
+		forEachCombination(len(c), size, func(idx []int) {
+			for i, j := range idx {
+				h[i] = c[j]
+			}
+			if e := eval(h); e > best {
+				best = e
+			}
+		})
+		return best, nil
+	}
+	return 0, fmt.Errorf("can't rank %d cards, want 3 to 7", len(c))
+}
@@ -0,0 +1,45 @@
+package cpoker
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+func TestEvalN(t *testing.T) {
+	for _, tc := range []struct {
+		cards, best string
+	}{
+		{"HAHKSA", "HAHKSA"},
+		{"HAHKSAD2", "HAHKSA"},
+		{"C2D2H2S2", "C2D2H2"},
+		{"H2H3H4H5H7", "H2H3H4H5H7"},
+		{"H2H3H4H5H7C6", "H2H3H4H5H7"},
+		{"S2H3H4H5H7C6", "H3H4H5H7C6"},
+		{"H2H3H4H5H7C6H6", "H3H4H5H7H6"},
+	} {
+		got, err := EvalN(mustCards(t, tc.cards))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := eval(mustCards(t, tc.best)); got != want {
+			t.Errorf("EvalN(%s) = %d, want %d (%s)", tc.cards, got, want, tc.best)
+		}
+	}
+	// The best 5 of 7 cards agrees with ranking every 5 of them.
+	rnd := rand.New(rand.NewSource(12))
+	for i := 0; i < 100; i++ {
+		c := randomDeal(rnd)[:7]
+		got, _ := EvalN(c)
+		_, want, _ := RankBounds5(nil, c)
+		if got != want {
+			t.Errorf("EvalN(%v) = %d, want %d", c, got, want)
+		}
+	}
+	for _, n := range []int{0, 2, 8} {
+		if _, err := EvalN(poker.Cards[:n]); err == nil {
+			t.Errorf("EvalN of %d cards succeeded", n)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package cpoker
+
+import (
+	"sort"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// EvalToHands5 returns every 5-card hand with the given Eval5 rank,
+// with the cards of each in increasing order, or nil if there's no
+// hand with that rank. Hands with the same rank are the ways of
+// giving suits to the same ranks that are, or aren't, a flush.
+func EvalToHands5(e int16) [][5]poker.Card {
+	h, ok := poker.EvalToHand5(e)
+	if !ok {
+		return nil
+	}
+	var r [][5]poker.Card
+	forEachSuiting(h, func(c []poker.Card) {
+		var hand [5]poker.Card
+		copy(hand[:], c)
+		r = append(r, hand)
+	})
+	return r
+}
+
+// EvalToHands3 returns every 3-card hand with the given Eval3 rank,
+// with the cards of each in increasing order, or nil if there's no
+// hand with that rank.
+func EvalToHands3(e int16) [][3]poker.Card {
+	h, ok := poker.EvalToHand3(e)
+	if !ok {
+		return nil
+	}
+	var r [][3]poker.Card
+	forEachSuiting(h, func(c []poker.Card) {
+		var hand [3]poker.Card
+		copy(hand[:], c)
+		r = append(r, hand)
+	})
+	return r
+}
+
+// forEachSuiting calls f with every hand of distinct cards with the same
+// ranks as h which ranks the same: for 5 cards, it's a flush if and only
+// if h is. The cards passed to f are in increasing order, and are
+// overwritten after f returns.
+func forEachSuiting(h []poker.Card, f func(c []poker.Card)) {
+	ranks := make([]poker.Rank, len(h))
+	flush := len(h) == 5
+	for i, c := range h {
+		ranks[i] = c.Rank()
+		flush = flush && c.Suit() == h[0].Suit()
+	}
+	sort.Slice(ranks, func(i, j int) bool { return ranks[i] < ranks[j] })
+	c := make([]poker.Card, len(h))
+	var rec func(i int, lastSuit poker.Suit)
+	rec = func(i int, lastSuit poker.Suit) {
+		if i == len(c) {
+			same := true
+			for _, ci := range c {
+				same = same && ci.Suit() == c[0].Suit()
+			}
+			if len(c) != 5 || same == flush {
+				f(c)
+			}
+			return
+		}
+		s := poker.Suit(0)
+		if i > 0 && ranks[i] == ranks[i-1] {
+			// Cards of the same rank get increasing suits, so each hand
+			// is only made once.
+			s = lastSuit + 1
+		}
+		for ; s < 4; s++ {
+			c[i], _ = poker.MakeCard(s, ranks[i])
+			rec(i+1, s)
+		}
+	}
+	rec(0, 0)
+}
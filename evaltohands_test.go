@@ -0,0 +1,27 @@
+package cpoker
+
+import (
+	"testing"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+func TestEvalToHands(t *testing.T) {
+	counts := [2][]int{countHands(0), countHands(1)}
+	for e := int16(0); e <= poker.ScoreMax; e++ {
+		hands3, hands5 := EvalToHands3(e), EvalToHands5(e)
+		if len(hands3) != counts[0][e] || len(hands5) != counts[1][e] {
+			t.Fatalf("rank %d has %d 3-card and %d 5-card hands, want %d and %d", e, len(hands3), len(hands5), counts[0][e], counts[1][e])
+		}
+		for i := range hands3 {
+			if s, ok := NewCardSet(hands3[i][:]); !ok || s.Len() != 3 || poker.Eval3(&hands3[i]) != e {
+				t.Fatalf("EvalToHands3(%d) returned %v", e, hands3[i])
+			}
+		}
+		for i := range hands5 {
+			if s, ok := NewCardSet(hands5[i][:]); !ok || s.Len() != 5 || poker.Eval5(&hands5[i]) != e {
+				t.Fatalf("EvalToHands5(%d) returned %v", e, hands5[i])
+			}
+		}
+	}
+}
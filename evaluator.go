@@ -2,14 +2,24 @@ package cpoker
 
 import (
 	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
+	"math"
 	"math/rand"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 
+	"github.com/paulhankin/cpoker/internal/ranks"
 	"github.com/paulhankin/poker/v2/poker"
 )
 
@@ -20,15 +30,44 @@ type RolloutEvaluator struct {
 	PreRollout bool
 	Separable  bool // score hand by treating f/m/b as independent.
 	Opponent   HandEvaluator
-	N          int // how many rollouts we do
+	N          int      // how many rollouts we do
+	Scoring    *Scoring // how showdowns are scored. If nil, Scoring2to4 is used.
 	played     [][3]int16
+	counts     [3][]int
 	wins       [3][]float64
+
+	// If MinCount is positive, the rollout continues (in batches of N)
+	// until, in each slot, at least the fraction Coverage (or 0.99, if
+	// it's zero) of the sampled hands have ranks which have been seen
+	// at least MinCount times, or MaxN hands have been sampled. Ranks
+	// the opponent rarely or never plays don't hold up the rollout. If
+	// MaxN is zero, it's 10 times N.
+	MinCount int
+	Coverage float64
+	MaxN     int
+
+	// If Cache is non-nil, rollouts for each hand (when the evaluator
+	// isn't pre-rolled-out, or there are dead cards) are kept in it.
+	Cache *RolloutCache
+
+	// If non-nil, rollouts deal from sources seeded from Rand rather
+	// than the global random source, so that they can be repeated. An
+	// evaluator with a Rand can't evaluate hands from several
+	// goroutines at once.
+	Rand *rand.Rand
 }
 
 // A SampledEvaluator evaluates hands based on independent probabilities the
 // front, middle, and back hands will win.
 type SampledEvaluator struct {
-	wins [3][]float64
+	// Scoring is how hands are valued. If nil, Scoring2to4 is used.
+	// It isn't saved with the evaluator.
+	Scoring *Scoring
+
+	wins    [3][]float64
+	counts  [3][]int // how many samples had each rank, or nil if unknown
+	samples int      // how many hands the probabilities were estimated from, or 0 if unknown
+	table   string   // the TableChecksum of the file the evaluator was read from, if any
 }
 
 // WinProbabilities returns a mapping from rank (from Eval) to
@@ -40,6 +79,34 @@ func (se *SampledEvaluator) WinProbabilities(i int) []float64 {
 	return se.wins[i]
 }
 
+// Counts returns a mapping from rank to how many of the sampled hands
+// had that rank. i=0,1,2 means front,middle,back. It returns nil
+// if the counts aren't known, for example if the win probabilities
+// are an average of several evaluators.
+func (se *SampledEvaluator) Counts(i int) []int {
+	if i < 0 || i > 2 {
+		return nil
+	}
+	return se.counts[i]
+}
+
+// Samples returns the (effective) number of hands the win probabilities
+// were estimated from, or 0 if this is unknown.
+func (se *SampledEvaluator) Samples() int {
+	return se.samples
+}
+
+// WinStdErr returns the sampling standard error of the probability
+// that a hand of rank e wins in slot i. It returns 0 if the number of
+// samples is unknown.
+func (se *SampledEvaluator) WinStdErr(i int, e int16) float64 {
+	if se.samples == 0 {
+		return 0
+	}
+	p := se.WinProbabilities(i)[e]
+	return math.Sqrt(p * (1 - p) / float64(se.samples))
+}
+
 // NewSampledEvaluatorFromRollout converts a separable, pre-rolled out
 // RolloutEvaluator into a SampledEvaluator. The RolloutEvaluator must
 // have already have sampled hands.
@@ -54,22 +121,115 @@ func NewSampledEvaluatorFromRollout(re *RolloutEvaluator) (*SampledEvaluator, er
 		return nil, errors.New("rollout evaluator hasn't been prepared")
 	}
 	return &SampledEvaluator{
+		Scoring: re.Scoring,
 		wins: [3][]float64{
 			append([]float64{}, re.wins[0]...),
 			append([]float64{}, re.wins[1]...),
 			append([]float64{}, re.wins[2]...),
 		},
+		counts: [3][]int{
+			append([]int{}, re.counts[0]...),
+			append([]int{}, re.counts[1]...),
+			append([]int{}, re.counts[2]...),
+		},
+		samples: len(re.played),
 	}, nil
 }
 
+// NewSampledEvaluatorFromCounts constructs a SampledEvaluator from
+// counts of how often the opponent plays a hand of each rank.
+// counts[i][r] is the number of sampled hands with rank r in slot i
+// (0, 1, 2 means front, middle, back). Each slot must have ScoreMax+1
+// non-negative counts, and the same total number of samples.
+func NewSampledEvaluatorFromCounts(counts [3][]int) (*SampledEvaluator, error) {
+	se := &SampledEvaluator{}
+	for i := 0; i < 3; i++ {
+		if len(counts[i]) != poker.ScoreMax+1 {
+			return nil, fmt.Errorf("slot %d has %d counts, want %d", i, len(counts[i]), poker.ScoreMax+1)
+		}
+		total := 0
+		for r, c := range counts[i] {
+			if c < 0 {
+				return nil, fmt.Errorf("slot %d has negative count %d for rank %d", i, c, r)
+			}
+			total += c
+		}
+		if total == 0 {
+			return nil, fmt.Errorf("slot %d has no samples", i)
+		}
+		if i > 0 && total != se.samples {
+			return nil, fmt.Errorf("slot %d has %d samples, but slot 0 has %d", i, total, se.samples)
+		}
+		se.samples = total
+		se.counts[i] = append([]int{}, counts[i]...)
+	}
+	se.wins = winsFromCounts(se.counts, se.samples)
+	return se, nil
+}
+
+// NewSampledEvaluatorFromProbabilities constructs a SampledEvaluator
+// from win probabilities, as returned by WinProbabilities. Each slot must
+// have ScoreMax+1 probabilities, which must be between 0 and 1 and
+// non-decreasing with rank.
+func NewSampledEvaluatorFromProbabilities(p [3][]float64) (*SampledEvaluator, error) {
+	se := &SampledEvaluator{}
+	for i := 0; i < 3; i++ {
+		if len(p[i]) != poker.ScoreMax+1 {
+			return nil, fmt.Errorf("slot %d has %d probabilities, want %d", i, len(p[i]), poker.ScoreMax+1)
+		}
+		last := 0.0
+		for r, x := range p[i] {
+			if !(x >= 0 && x <= 1) {
+				return nil, fmt.Errorf("slot %d has probability %f for rank %d, want a value between 0 and 1", i, x, r)
+			}
+			if x < last {
+				return nil, fmt.Errorf("slot %d has decreasing probability at rank %d", i, r)
+			}
+			last = x
+		}
+		se.wins[i] = append([]float64{}, p[i]...)
+	}
+	return se, nil
+}
+
+// Merge returns a SampledEvaluator whose counts are the sum of
+// the counts of se and other. Both evaluators must have counts.
+func (se *SampledEvaluator) Merge(other *SampledEvaluator) (*SampledEvaluator, error) {
+	r := &SampledEvaluator{Scoring: se.Scoring, samples: se.samples + other.samples}
+	for i := 0; i < 3; i++ {
+		if se.counts[i] == nil || other.counts[i] == nil {
+			return nil, errors.New("can't merge evaluators without sample counts")
+		}
+		if len(se.counts[i]) != len(other.counts[i]) {
+			return nil, fmt.Errorf("can't merge evaluators with %d and %d ranks", len(se.counts[i]), len(other.counts[i]))
+		}
+		r.counts[i] = make([]int, len(se.counts[i]))
+		for j := range r.counts[i] {
+			r.counts[i][j] = se.counts[i][j] + other.counts[i][j]
+		}
+	}
+	r.wins = winsFromCounts(r.counts, r.samples)
+	return r, nil
+}
+
 // Evaluator returns a hand evaluator for the given set of cards.
 func (se *SampledEvaluator) Evaluator(cs []poker.Card) func(f, m, b int16) float64 {
 	return se.evaluateHand
 }
 
 // evaluateHand returns an expected value for playing a hand with
-// the given ranks for the front, middle, and back hands.
+// the given ranks for the front, middle, and back hands. The expected
+// royalties of the opponent are left out, since they're the same
+// whichever hand is played.
 func (se *SampledEvaluator) evaluateHand(f, m, b int16) float64 {
+	return se.evaluate(se.Scoring, f, m, b)
+}
+
+// evaluate is evaluateHand, valuing hands with s rather than se.Scoring.
+func (se *SampledEvaluator) evaluate(s *Scoring, f, m, b int16) float64 {
+	if s == nil {
+		s = Scoring2to4
+	}
 	pf := se.wins[0][f]
 	pm := se.wins[1][m]
 	pb := se.wins[2][b]
@@ -78,7 +238,14 @@ func (se *SampledEvaluator) evaluateHand(f, m, b int16) float64 {
 	qb := 1 - pb
 	pbon := pf*pm + pf*pb + pm*pb - 2*pf*pm*pb
 	qbon := qf*qm + qf*qb + qm*qb - 2*qf*qm*qb
-	return pf + pm + pb - qf - qm - qb + pbon - qbon
+	r := float64(s.Slot)*(pf+pm+pb-qf-qm-qb) + float64(s.Majority)*pbon - float64(s.Majority)*qbon
+	if s.Scoop != 0 {
+		r += float64(s.Scoop) * (pf*pm*pb - qf*qm*qb)
+	}
+	if s.Royalties != nil {
+		r += float64(s.Royalties[0][f] + s.Royalties[1][m] + s.Royalties[2][b])
+	}
+	return r
 }
 
 // NewTrainedSampledEvaluator constructs a SampledEvaluator based
@@ -87,15 +254,50 @@ func (se *SampledEvaluator) evaluateHand(f, m, b int16) float64 {
 // then the win probabilities are averaged between the opponent and
 // the exploiting probabilities found.
 func NewTrainedSampledEvaluator(opp HandEvaluator, N int) *SampledEvaluator {
-	e := &RolloutEvaluator{PreRollout: true, Separable: true, Opponent: opp, N: N}
-	e.Init()
+	return NewTrainedSampledEvaluatorWithScoring(opp, N, nil)
+}
+
+// NewTrainedSampledEvaluatorWithScoring is like NewTrainedSampledEvaluator,
+// but the evaluator values hands using the given scoring (or
+// Scoring2to4 if it's nil), so that it's trained for that game.
+// Like NewTrainedSampledEvaluator, it exits if the opponent's
+// probabilities can't be averaged with the new ones; use
+// TrainSampledEvaluator to get an error instead.
+func NewTrainedSampledEvaluatorWithScoring(opp HandEvaluator, N int, s *Scoring) *SampledEvaluator {
+	r, err := TrainSampledEvaluator(opp, N, s, nil)
+	if err != nil {
+		log.Fatalf("failed to train evaluator: %s", err)
+	}
+	return r
+}
+
+// TrainSampledEvaluator is like NewTrainedSampledEvaluatorWithScoring,
+// but returns an error if the opponent's win probabilities can't be
+// averaged with the exploiting probabilities: if they have a different
+// number of ranks, or were read from a file made with different rank
+// tables. The opponent's hands are dealt using rnd, or the global
+// random source if it's nil (see RolloutEvaluator.Rand).
+func TrainSampledEvaluator(opp HandEvaluator, N int, s *Scoring, rnd *rand.Rand) (*SampledEvaluator, error) {
 	var oppWins *[3][]float64
+	oppSamples, oppTable := 0, ""
 	if se, ok := opp.(*SampledEvaluator); ok {
-		oppWins = &se.wins
+		oppWins, oppSamples, oppTable = &se.wins, se.samples, se.table
 	}
 	if re, ok := opp.(*RolloutEvaluator); ok && re.PreRollout && re.Separable && len(re.wins) > 0 {
-		oppWins = &re.wins
+		oppWins, oppSamples = &re.wins, len(re.played)
+	}
+	if oppTable != "" && oppTable != TableChecksum() {
+		return nil, fmt.Errorf("opponent was made with different rank tables (checksum %s, want %s)", oppTable, TableChecksum())
 	}
+	if oppWins != nil {
+		for i := 0; i < 3; i++ {
+			if len((*oppWins)[i]) != poker.ScoreMax+1 {
+				return nil, fmt.Errorf("opponent has %d ranks in slot %d, want %d", len((*oppWins)[i]), i, poker.ScoreMax+1)
+			}
+		}
+	}
+	e := &RolloutEvaluator{PreRollout: true, Separable: true, Opponent: opp, N: N, Scoring: s, Rand: rnd}
+	e.Init()
 	if oppWins != nil {
 		for i := 0; i < 3; i++ {
 			for j := range (*oppWins)[i] {
@@ -105,54 +307,194 @@ func NewTrainedSampledEvaluator(opp HandEvaluator, N int) *SampledEvaluator {
 	}
 	r, err := NewSampledEvaluatorFromRollout(e)
 	if err != nil {
-		log.Fatalf("internal error: %s", err)
+		return nil, err
 	}
-	return r
+	if oppWins != nil {
+		// The variance of the average of two independent estimates
+		// is a quarter of the sum of their variances.
+		r.counts, r.samples = [3][]int{}, 0
+		if oppSamples > 0 {
+			n := len(e.played)
+			r.samples = 4 * n * oppSamples / (n + oppSamples)
+		}
+	}
+	return r, nil
+}
+
+var (
+	tableChecksumInit tableInit
+	tableChecksum     string
+)
+
+// TableChecksum identifies the poker package's rank tables: ScoreMax,
+// and which hand each rank stands for. Coefficients files record it,
+// so that files made with different tables aren't silently misread.
+func TableChecksum() string {
+	tableChecksumInit.Do(func() {
+		tableChecksum = ranks.Checksum()
+	})
+	return tableChecksum
 }
 
+// A TableMetadata describes the poker package's rank tables, so that
+// code can size arrays indexed by rank without hard-coding ScoreMax.
+type TableMetadata struct {
+	ScoreMax int    `json:"score_max"` // The largest rank; arrays indexed by rank have ScoreMax+1 entries
+	Ranks3   int    `json:"ranks3"`    // How many distinct ranks 3-card hands have
+	Ranks5   int    `json:"ranks5"`    // How many distinct ranks 5-card hands have
+	Checksum string `json:"checksum"`  // The TableChecksum
+}
+
+// TableInfo returns the metadata of the rank tables.
+func TableInfo() TableMetadata {
+	ti := TableMetadata{ScoreMax: poker.ScoreMax, Checksum: TableChecksum()}
+	for e := 0; e <= poker.ScoreMax; e++ {
+		ti.Ranks3 += b2i(categories[0][e] >= 0)
+		ti.Ranks5 += b2i(categories[1][e] >= 0)
+	}
+	return ti
+}
+
+// tableHeader starts coefficients files, followed by the TableChecksum.
+const tableHeader = "table:"
+
 // Marshal writes a SampledEvaluator to the given file.
+// The format is a header recording the TableChecksum, and then
+// the length and values of the win probabilities
+// for each of the front, middle and back, followed by the number
+// of samples, and then (if known) the length and values of the
+// sample counts for each of the front, middle and back.
+// The header is always for the poker package's tables, and there's
+// no record of the variant, so the file is for the standard game:
+// evaluators for other variants should be saved in a Bundle.
 func (se *SampledEvaluator) Marshal(w io.Writer) error {
 	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%s%s ", tableHeader, TableChecksum())
 	for i := 0; i < 3; i++ {
 		fmt.Fprintf(bw, "%d ", len(se.wins[i]))
 		for _, c := range se.wins[i] {
 			fmt.Fprintf(bw, "%f ", c)
 		}
 	}
+	fmt.Fprintf(bw, "%d ", se.samples)
+	for i := 0; i < 3 && se.counts[i] != nil; i++ {
+		fmt.Fprintf(bw, "%d ", len(se.counts[i]))
+		for _, c := range se.counts[i] {
+			fmt.Fprintf(bw, "%d ", c)
+		}
+	}
 	return bw.Flush()
 }
 
-// Save writes a SampledEvaluator to a named file.
+// checksumTrailer ends files written by Save, followed by the
+// SHA-256 of everything before it.
+const checksumTrailer = "checksum:"
+
+// Save writes a SampledEvaluator to a named file, followed by a
+// checksum. The file is replaced atomically, so if the save fails
+// part way through, any previous file is left as it was.
 func (se *SampledEvaluator) Save(filename string) error {
-	f, err := os.Create(filename)
+	var b bytes.Buffer
+	if err := se.Marshal(&b); err != nil {
+		return err
+	}
+	sum := sha256.Sum256(b.Bytes())
+	fmt.Fprintf(&b, "\n%s%s\n", checksumTrailer, hex.EncodeToString(sum[:]))
+	return writeFileAtomic(filename, b.Bytes())
+}
+
+// writeFileAtomic writes data to a temporary file in the same
+// directory as filename, syncs it to disk, and renames it over
+// filename.
+func writeFileAtomic(filename string, data []byte) error {
+	dir := filepath.Dir(filename)
+	f, err := ioutil.TempFile(dir, filepath.Base(filename)+".tmp")
 	if err != nil {
 		return err
 	}
-	if err := se.Marshal(f); err != nil {
+	tmp := f.Name()
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chmod(tmp, 0644); err != nil {
+		os.Remove(tmp)
 		return err
 	}
-	return f.Close()
+	if err := os.Rename(tmp, filename); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	// Sync the directory so the rename is durable too. Not every
+	// system supports this, so errors are ignored.
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
 }
 
-// LoadSampledEvaluator reads a SampledEvaluator from a named file.
+// LoadSampledEvaluator reads a SampledEvaluator from a named file. If
+// the file ends with a checksum (as written by Save), it's verified.
 func LoadSampledEvaluator(filename string) (*SampledEvaluator, error) {
-	f, err := os.Open(filename)
+	b, err := ioutil.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
-	return UnmarshalSampledEvaluator(f)
+	body := bytes.TrimRight(b, "\n")
+	if i := bytes.LastIndex(body, []byte(checksumTrailer)); i >= 0 {
+		// The newline Save writes before the checksum isn't part of
+		// the evaluator, so it's neither checksummed nor unmarshaled.
+		data := bytes.TrimRight(body[:i], "\n")
+		sum := sha256.Sum256(data)
+		if string(body[i+len(checksumTrailer):]) != hex.EncodeToString(sum[:]) {
+			return nil, fmt.Errorf("%s: checksum doesn't match, so the file is damaged", filename)
+		}
+		body = data
+	}
+	return UnmarshalSampledEvaluator(bytes.NewReader(body))
 }
 
 // UnmarshalSampledEvaluator reads weights from the given
-// file, constructing a SampledEvaluator.
+// file, constructing a SampledEvaluator. Older files without
+// sample counts or a table checksum are accepted, but it's an
+// error if the file was made with different rank tables.
 func UnmarshalSampledEvaluator(r io.Reader) (*SampledEvaluator, error) {
 	se := SampledEvaluator{}
+	var first string
+	if _, err := fmt.Fscan(r, &first); err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(first, tableHeader) {
+		if sum := first[len(tableHeader):]; sum != TableChecksum() {
+			return nil, fmt.Errorf("coefficients were made with different rank tables (checksum %s, want %s): retrain them with this version of the poker package", sum, TableChecksum())
+		}
+		se.table, first = first[len(tableHeader):], ""
+	}
 	for i := 0; i < 3; i++ {
 		length := 0
-		if _, err := fmt.Fscanf(r, "%d", &length); err != nil {
+		if first != "" {
+			var err error
+			if length, err = strconv.Atoi(first); err != nil {
+				return nil, fmt.Errorf("bad length %q: %s", first, err)
+			}
+			first = ""
+		} else if _, err := fmt.Fscanf(r, "%d", &length); err != nil {
 			return nil, err
 		}
+		if length != poker.ScoreMax+1 {
+			return nil, fmt.Errorf("slot %d has %d ranks, want %d: the coefficients were made with different rank tables", i, length, poker.ScoreMax+1)
+		}
 		se.wins[i] = make([]float64, length)
 		for j := range se.wins[i] {
 			if _, err := fmt.Fscanf(r, "%f", &se.wins[i][j]); err != nil {
@@ -160,10 +502,40 @@ func UnmarshalSampledEvaluator(r io.Reader) (*SampledEvaluator, error) {
 			}
 		}
 	}
+	if _, err := fmt.Fscanf(r, "%d", &se.samples); err == io.EOF {
+		return &se, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for i := 0; i < 3; i++ {
+		length := 0
+		if _, err := fmt.Fscanf(r, "%d", &length); err == io.EOF && i == 0 {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if length != poker.ScoreMax+1 {
+			return nil, fmt.Errorf("slot %d has %d counts, want %d", i, length, poker.ScoreMax+1)
+		}
+		se.counts[i] = make([]int, length)
+		for j := range se.counts[i] {
+			if _, err := fmt.Fscanf(r, "%d", &se.counts[i][j]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if se.counts[0] != nil && se.samples > 0 {
+		// The probabilities are written rounded, so they're recomputed
+		// from the counts, to be exactly as they were.
+		se.wins = winsFromCounts(se.counts, se.samples)
+	}
 	return &se, nil
 }
 
-func rollout(cs []poker.Card, opp HandEvaluator, N int) (played [][3]int16, wins [3][]float64) {
+// rollout deals N hands from the cards other than cs, and plays them
+// with opp, using rolloutDeals, so the rollout can be repeated by
+// seeding rnd the same way.
+func rollout(rnd *rand.Rand, cs []poker.Card, opp HandEvaluator, N int) (played [][3]int16, counts [3][]int, wins [3][]float64) {
 	deck := make([]poker.Card, 0, 52-len(cs))
 	h := map[poker.Card]bool{}
 	for _, c := range cs {
@@ -174,48 +546,69 @@ func rollout(cs []poker.Card, opp HandEvaluator, N int) (played [][3]int16, wins
 			deck = append(deck, c)
 		}
 	}
-	played = make([][3]int16, N)
-	cases := make(chan int, 16)
+	played = rolloutDeals(rnd, opp, N, func() func(int, *rand.Rand) []poker.Card {
+		mydeck := append([]poker.Card{}, deck...)
+		return func(_ int, myrnd *rand.Rand) []poker.Card {
+			for i := 0; i < 13; i++ {
+				j := myrnd.Intn(len(mydeck)-i) + i
+				mydeck[i], mydeck[j] = mydeck[j], mydeck[i]
+			}
+			return mydeck[:13]
+		}
+	})
+	for i := 0; i < 3; i++ {
+		counts[i] = make([]int, poker.ScoreMax+1)
+	}
+	for _, s := range played {
+		for i := 0; i < 3; i++ {
+			counts[i][s[i]]++
+		}
+	}
+	return played, counts, winsFromCounts(counts, N)
+}
+
+// rolloutDeals plays N hands with opp, and returns the ranks of each.
+// The hands are dealt by 16 workers, each with its own random source
+// seeded from rnd (or the global random source if it's nil), and its
+// own dealing function made by newDeal, which is called with the
+// number of the hand and the worker's random source. Each worker plays
+// a fixed share of the hands, so the results depend only on rnd.
+func rolloutDeals(rnd *rand.Rand, opp HandEvaluator, N int, newDeal func() func(int, *rand.Rand) []poker.Card) [][3]int16 {
+	played := make([][3]int16, N)
+	int63 := rand.Int63
+	if rnd != nil {
+		int63 = rnd.Int63
+	}
 	workers := 16
 	var wg sync.WaitGroup
 	wg.Add(workers)
 	for w := 0; w < workers; w++ {
-		go func() {
-			mydeck := append([]poker.Card{}, deck...)
-			for c := range cases {
-				for i := 0; i < 13; i++ {
-					j := rand.Intn(len(mydeck)-i) + i
-					mydeck[i], mydeck[j] = mydeck[j], mydeck[i]
-				}
-				hand, _ := Play(mydeck[:13], opp)
+		go func(w int, myrnd *rand.Rand, deal func(int, *rand.Rand) []poker.Card) {
+			for c := w; c < N; c += workers {
+				hand, _ := Play(deal(c, myrnd), opp)
 				played[c] = [3]int16{
 					poker.Eval3(&hand.Front), poker.Eval5(&hand.Middle), poker.Eval5(&hand.Back),
 				}
 			}
 			wg.Done()
-		}()
+		}(w, rand.New(rand.NewSource(int63())), newDeal())
 	}
-	for i := range played {
-		cases <- i
-	}
-	close(cases)
 	wg.Wait()
+	return played
+}
+
+// winsFromCounts converts counts of how often each rank was
+// seen into probabilities that a hand of each rank wins.
+func winsFromCounts(counts [3][]int, N int) (wins [3][]float64) {
 	for i := 0; i < 3; i++ {
-		wins[i] = make([]float64, poker.ScoreMax+1)
-	}
-	for _, s := range played {
-		for i := 0; i < 3; i++ {
-			wins[i][s[i]]++
-		}
-	}
-	for i := 0; i < 3; i++ {
-		t := 0.0
-		for j := range wins[i] {
-			t += wins[i][j]
-			wins[i][j] = t / float64(N)
+		wins[i] = make([]float64, len(counts[i]))
+		t := 0
+		for j := range counts[i] {
+			t += counts[i][j]
+			wins[i][j] = float64(t) / float64(N)
 		}
 	}
-	return played, wins
+	return wins
 }
 
 // Init pre-rolls-out the rollout evaluator if necessary.
@@ -223,7 +616,51 @@ func (re *RolloutEvaluator) Init() {
 	if !re.PreRollout {
 		return
 	}
-	re.played, re.wins = rollout(nil, re.Opponent, re.N)
+	re.played, re.counts, re.wins = re.rollout(nil)
+}
+
+// rollout samples the opponent's play, continuing until the ranks
+// are covered if MinCount is set.
+func (re *RolloutEvaluator) rollout(cs []poker.Card) (played [][3]int16, counts [3][]int, wins [3][]float64) {
+	played, counts, wins = rollout(re.Rand, cs, re.Opponent, re.N)
+	maxN := re.MaxN
+	if maxN == 0 {
+		maxN = 10 * re.N
+	}
+	coverage := re.Coverage
+	if coverage == 0 {
+		coverage = 0.99
+	}
+	for re.MinCount > 0 && len(played) < maxN && !covered(counts, re.MinCount, coverage) {
+		more, moreCounts, _ := rollout(re.Rand, cs, re.Opponent, re.N)
+		played = append(played, more...)
+		for i := 0; i < 3; i++ {
+			for j := range counts[i] {
+				counts[i][j] += moreCounts[i][j]
+			}
+		}
+		wins = winsFromCounts(counts, len(played))
+	}
+	return played, counts, wins
+}
+
+// covered reports whether, in each slot, at least the given fraction
+// of the counted hands have ranks which were counted at least minCount
+// times.
+func covered(counts [3][]int, minCount int, coverage float64) bool {
+	for i := 0; i < 3; i++ {
+		total, enough := 0, 0
+		for _, c := range counts[i] {
+			total += c
+			if c >= minCount {
+				enough += c
+			}
+		}
+		if float64(enough) < coverage*float64(total) {
+			return false
+		}
+	}
+	return true
 }
 
 // Evaluator returns a hand evaluator for the given set of cards. Depending
@@ -232,16 +669,91 @@ func (re *RolloutEvaluator) Init() {
 func (re *RolloutEvaluator) Evaluator(cs []poker.Card) func(f, m, b int16) float64 {
 	played, wins := re.played, re.wins
 	if !re.PreRollout {
-		played, wins = rollout(cs, re.Opponent, re.N)
+		played, wins = re.cachedRollout(cs, nil)
+	}
+	return re.evaluator(played, wins)
+}
+
+// EvaluatorWithDead is like Evaluator, but the opponent's hands are
+// also never dealt any of the dead cards. It always performs a rollout,
+// even if the evaluator has been pre-rolled-out.
+func (re *RolloutEvaluator) EvaluatorWithDead(cs, dead []poker.Card) func(f, m, b int16) float64 {
+	played, wins := re.cachedRollout(cs, dead)
+	return re.evaluator(played, wins)
+}
+
+// An OpponentPlay is a way the opponent arranged their cards in a
+// rollout, and how often they did.
+type OpponentPlay struct {
+	Ranks     [3]int16 // The ranks of the front, middle and back
+	Frequency float64  // The fraction of the rollout's hands played this way
+}
+
+func (op *OpponentPlay) String() string {
+	var parts [3]string
+	for i, e := range op.Ranks {
+		toHand := poker.EvalToHand5
+		if i == 0 {
+			toHand = poker.EvalToHand3
+		}
+		h, _ := toHand(e)
+		parts[i], _ = poker.DescribeShort(h)
+	}
+	return fmt.Sprintf("%s / %s / %s: %.2f%%", parts[0], parts[1], parts[2], 100*op.Frequency)
+}
+
+// OpponentDistribution returns the top most frequent arrangements the
+// opponent played in the rollout for the cards cs, with the dead
+// cards never dealt to the opponent, most frequent first. It's the
+// rollout the evaluator uses to value cs, so if the evaluator is
+// pre-rolled-out and there are no dead cards, it's the shared rollout,
+// which is dealt from the whole deck.
+func (re *RolloutEvaluator) OpponentDistribution(cs, dead []poker.Card, top int) []OpponentPlay {
+	played := re.played
+	if !re.PreRollout || len(dead) > 0 {
+		played, _ = re.cachedRollout(cs, dead)
+	}
+	counts := map[[3]int16]int{}
+	for _, p := range played {
+		counts[p]++
+	}
+	var r []OpponentPlay
+	for p, n := range counts {
+		r = append(r, OpponentPlay{Ranks: p, Frequency: float64(n) / float64(len(played))})
 	}
+	sort.Slice(r, func(i, j int) bool {
+		if r[i].Frequency != r[j].Frequency {
+			return r[i].Frequency > r[j].Frequency
+		}
+		// Break ties by the strongest back, then middle, then front.
+		for k := 2; k >= 0; k-- {
+			if r[i].Ranks[k] != r[j].Ranks[k] {
+				return r[i].Ranks[k] > r[j].Ranks[k]
+			}
+		}
+		return false
+	})
+	if len(r) > top {
+		r = r[:top]
+	}
+	return r
+}
+
+// evaluator returns a hand evaluator, given the opponent's sampled hands.
+func (re *RolloutEvaluator) evaluator(played [][3]int16, wins [3][]float64) func(f, m, b int16) float64 {
 	if re.Separable {
-		se := &SampledEvaluator{wins}
+		se := &SampledEvaluator{Scoring: re.Scoring, wins: wins}
 		return se.Evaluator(nil)
 	}
+	s := re.Scoring
+	if s == nil {
+		s = Scoring2to4
+	}
 	return func(f, m, b int16) float64 {
 		score := 0
 		for _, p := range played {
-			score += cmp(f, p[0], m, p[1], b, p[2])
+			sc, _, _ := s.showdown([3]int16{f, m, b}, p)
+			score += sc
 		}
 		return float64(score) + float64(f+m+b)/10000.0
 	}
@@ -254,19 +766,11 @@ func b2i(b bool) int {
 	return 0
 }
 
-// cmp takes interleaved ranks of three hands, and returns a score for the
-// first player.
-func cmp(a0, b0, a1, b1, a2, b2 int16) int {
-	wins := b2i(a0 > b0) + b2i(a1 > b1) + b2i(a2 > b2)
-	losses := b2i(b0 > a0) + b2i(b1 > a1) + b2i(b2 > a2)
-	bonus := b2i(wins > losses) - b2i(losses > wins)
-	return wins - losses + bonus
-}
-
 // CompareHands returns a score for player 0, assuming player 0 plays h0 and
 // player 1 plays h1. The function assumes both hands are legal.
 // The scoring used is 2-4 scoring: one point for each place won, and one point
 // for winning the majority of the places.
+// Use Scoring.Score to score hands in other ways.
 func CompareHands(h0, h1 *Hand) int {
-	return cmp(poker.Eval3(&h0.Front), poker.Eval3(&h1.Front), poker.Eval5(&h0.Middle), poker.Eval5(&h1.Middle), poker.Eval5(&h0.Back), poker.Eval5(&h1.Back))
+	return Scoring2to4.Score(h0, h1)
 }
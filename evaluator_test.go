@@ -0,0 +1,363 @@
+package cpoker
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+func smallSampledEvaluator(t *testing.T, n int) *SampledEvaluator {
+	t.Helper()
+	re := &RolloutEvaluator{PreRollout: true, Separable: true, Opponent: MaxProdEvaluator{}, N: n}
+	re.Init()
+	se, err := NewSampledEvaluatorFromRollout(re)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return se
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	se := smallSampledEvaluator(t, 100)
+	var b bytes.Buffer
+	if err := se.Marshal(&b); err != nil {
+		t.Fatal(err)
+	}
+	got, err := UnmarshalSampledEvaluator(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Samples() != 100 {
+		t.Errorf("got %d samples, want 100", got.Samples())
+	}
+	for i := 0; i < 3; i++ {
+		if !reflect.DeepEqual(got.Counts(i), se.Counts(i)) {
+			t.Errorf("slot %d: counts differ after round-trip", i)
+		}
+	}
+}
+
+func TestUnmarshalOtherTables(t *testing.T) {
+	se := smallSampledEvaluator(t, 100)
+	var b bytes.Buffer
+	if err := se.Marshal(&b); err != nil {
+		t.Fatal(err)
+	}
+	data := b.String()
+	if !strings.HasPrefix(data, tableHeader+TableChecksum()+" ") {
+		t.Fatalf("marshaled evaluator starts %q, want the table checksum", data[:30])
+	}
+	legacy := strings.TrimPrefix(data, tableHeader+TableChecksum()+" ")
+	if _, err := UnmarshalSampledEvaluator(strings.NewReader(legacy)); err != nil {
+		t.Errorf("reading a file without a checksum failed: %s", err)
+	}
+	other := tableHeader + "0123456789abcdef " + legacy
+	if _, err := UnmarshalSampledEvaluator(strings.NewReader(other)); err == nil || !strings.Contains(err.Error(), "different rank tables") {
+		t.Errorf("reading a file with another checksum gave error %v, want different rank tables", err)
+	}
+	short := "3 0.1 0.5 1 " + legacy
+	if _, err := UnmarshalSampledEvaluator(strings.NewReader(short)); err == nil || !strings.Contains(err.Error(), "different rank tables") {
+		t.Errorf("reading a file with 3 ranks gave error %v, want different rank tables", err)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "save")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "coefficients.data")
+	se := smallSampledEvaluator(t, 100)
+	for i := 0; i < 2; i++ {
+		if err := se.Save(filename); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := LoadSampledEvaluator(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Counts(2), se.Counts(2)) || got.Samples() != se.Samples() {
+		t.Errorf("loaded evaluator differs from the saved one")
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("got %d files after saving, want 1", len(files))
+	}
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[len(b)/2] ^= 1
+	if err := ioutil.WriteFile(filename, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSampledEvaluator(filename); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Errorf("loading a damaged file gave error %v, want a checksum error", err)
+	}
+	// Evaluators without sample counts, such as those read from legacy
+	// files, can be saved and loaded too.
+	noCounts, err := NewSampledEvaluatorFromProbabilities([3][]float64{se.WinProbabilities(0), se.WinProbabilities(1), se.WinProbabilities(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := noCounts.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+	got, err = LoadSampledEvaluator(filename)
+	if err != nil {
+		t.Fatalf("loading an evaluator without counts failed: %s", err)
+	}
+	if got.Counts(2) != nil {
+		t.Errorf("loaded evaluator without counts has counts")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	a := smallSampledEvaluator(t, 100)
+	b := smallSampledEvaluator(t, 300)
+	m, err := a.Merge(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Samples() != 400 {
+		t.Errorf("got %d samples, want 400", m.Samples())
+	}
+	for i := 0; i < 3; i++ {
+		w := m.WinProbabilities(i)
+		if w[len(w)-1] != 1 {
+			t.Errorf("slot %d: best rank wins with probability %f, want 1", i, w[len(w)-1])
+		}
+	}
+}
+
+func TestNewSampledEvaluatorFromCounts(t *testing.T) {
+	se := smallSampledEvaluator(t, 100)
+	got, err := NewSampledEvaluatorFromCounts([3][]int{se.Counts(0), se.Counts(1), se.Counts(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if !reflect.DeepEqual(got.WinProbabilities(i), se.WinProbabilities(i)) {
+			t.Errorf("slot %d: win probabilities differ", i)
+		}
+	}
+	bad := [3][]int{se.Counts(0), se.Counts(1), se.Counts(2)[1:]}
+	if _, err := NewSampledEvaluatorFromCounts(bad); err == nil {
+		t.Errorf("NewSampledEvaluatorFromCounts succeeded with short counts")
+	}
+}
+
+func TestNewSampledEvaluatorFromProbabilities(t *testing.T) {
+	se := smallSampledEvaluator(t, 100)
+	p := [3][]float64{se.WinProbabilities(0), se.WinProbabilities(1), se.WinProbabilities(2)}
+	if _, err := NewSampledEvaluatorFromProbabilities(p); err != nil {
+		t.Fatal(err)
+	}
+	p[1] = append([]float64{}, p[1]...)
+	p[1][len(p[1])-1] = 0.5
+	if _, err := NewSampledEvaluatorFromProbabilities(p); err == nil {
+		t.Errorf("NewSampledEvaluatorFromProbabilities succeeded with decreasing probabilities")
+	}
+}
+
+func TestPredictedPoints(t *testing.T) {
+	se := smallSampledEvaluator(t, 1000)
+	for _, s := range []*Scoring{Scoring2to4, ScoringHK} {
+		c := CompareEvaluatorsWithOptions(se, MaxProdEvaluator{}, 100, 0, CompareOptions{Scoring: s, Rand: rand.New(rand.NewSource(1)), ScoredPlay: true})
+		if math.Abs(c.PredictedPerHand-c.EVPerHand) > 4*c.StdErr {
+			t.Errorf("%s: predicted %f points per hand, but scored %f +/- %f", s.Name, c.PredictedPerHand, c.EVPerHand, c.StdErr)
+		}
+	}
+	if c := CompareEvaluatorsWithOptions(MaxProdEvaluator{}, se, 10, 0, CompareOptions{ScoredPlay: true}); c.PredictedPerHand != 0 {
+		t.Errorf("MaxProdEvaluator predicted %f points per hand, want none", c.PredictedPerHand)
+	}
+	if c := CompareEvaluatorsWithOptions(se, MaxProdEvaluator{}, 10, 0, CompareOptions{Scoring: ScoringHK}); c.PredictedPerHand != 0 {
+		t.Errorf("without ScoredPlay, predicted %f points per hand, want none", c.PredictedPerHand)
+	}
+}
+
+type countingPrinter []ComparisonProgress
+
+func (cp *countingPrinter) PrintProgress(p *ComparisonProgress) {
+	*cp = append(*cp, *p)
+}
+
+func TestProgressPrinter(t *testing.T) {
+	var cp countingPrinter
+	c := CompareEvaluatorsWithOptions(MaxProdEvaluator{}, MaxProdEvaluator{}, 6, 2, CompareOptions{Printer: &cp, Rand: rand.New(rand.NewSource(1))})
+	if len(cp) != 3 {
+		t.Fatalf("progress was printed %d times, want 3", len(cp))
+	}
+	for i, p := range cp {
+		if p.Hand != 2*i || p.Result.Played != 2*(p.Hand+1) {
+			t.Errorf("progress %d is for hand %d after %d hands played", i, p.Hand, p.Result.Played)
+		}
+	}
+	if c.Played != 12 {
+		t.Errorf("played %d hands, want 12", c.Played)
+	}
+
+	var buf bytes.Buffer
+	CompareEvaluatorsWithOptions(MaxProdEvaluator{}, MaxProdEvaluator{}, 2, 1, CompareOptions{Printer: TextProgressPrinter{&buf}, Rand: rand.New(rand.NewSource(1))})
+	if s := buf.String(); !strings.Contains(s, "hand 0\n") || !strings.Contains(s, "hand 1\n") {
+		t.Errorf("printed %q, want progress for hands 0 and 1", s)
+	}
+}
+
+func TestAdjustedComparison(t *testing.T) {
+	se := smallSampledEvaluator(t, 1000)
+	c := CompareEvaluatorsWithOptions(MaxProdEvaluator{}, MaxProdEvaluator{}, 50, 0, CompareOptions{AdjustSamples: 200, Rand: rand.New(rand.NewSource(1))})
+	if c.AdjustedEV != 0 || c.AdjustedStdErr != 0 {
+		t.Errorf("adjusted result against itself is %f +/- %f, want 0", c.AdjustedEV, c.AdjustedStdErr)
+	}
+	c = CompareEvaluatorsWithOptions(se, MaxProdEvaluator{}, 100, 0, CompareOptions{AdjustSamples: 300, Rand: rand.New(rand.NewSource(1))})
+	if c.AdjustedStdErr <= 0 || c.AdjustedStdErr >= c.StdErr {
+		t.Errorf("adjusted stderr is %f, want less than the unadjusted %f", c.AdjustedStdErr, c.StdErr)
+	}
+	if math.Abs(c.AdjustedEV-c.EVPerHand) > 4*c.StdErr {
+		t.Errorf("adjusted EV is %f, but scored %f +/- %f", c.AdjustedEV, c.EVPerHand, c.StdErr)
+	}
+}
+
+func TestRolloutCoverage(t *testing.T) {
+	re := &RolloutEvaluator{Opponent: MaxProdEvaluator{}, N: 100, MinCount: 2, Coverage: 0.25, MaxN: 100000}
+	played, counts, _ := re.rollout(nil)
+	// After one batch, fewer than a quarter of the back hands have ranks
+	// seen twice, but the rollout stops long before MaxN.
+	if len(played) <= re.N || len(played) >= re.MaxN {
+		t.Errorf("rolled out %d hands, want more than %d and fewer than %d", len(played), re.N, re.MaxN)
+	}
+	if !covered(counts, re.MinCount, re.Coverage) {
+		t.Errorf("rollout stopped after %d hands without covering the ranks", len(played))
+	}
+	if covered(counts, len(played)+1, 0.01) {
+		t.Errorf("ranks are covered when none has been seen often enough")
+	}
+}
+
+func TestRolloutRepeatable(t *testing.T) {
+	trained := func(seed int64) *SampledEvaluator {
+		se, err := TrainSampledEvaluator(MaxProdEvaluator{}, 100, nil, rand.New(rand.NewSource(seed)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return se
+	}
+	if a, b := trained(1), trained(1); !reflect.DeepEqual(a.counts, b.counts) {
+		t.Errorf("training twice with the same seed gave different counts")
+	}
+	if a, b := trained(1), trained(2); reflect.DeepEqual(a.counts, b.counts) {
+		t.Errorf("training with different seeds gave the same counts")
+	}
+}
+
+func TestOpponentDistribution(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	cards := append([]poker.Card{}, poker.Cards...)
+	rnd.Shuffle(len(cards), func(i, j int) { cards[i], cards[j] = cards[j], cards[i] })
+	c := cards[:13]
+	re := &RolloutEvaluator{Separable: true, Opponent: MaxProdEvaluator{}, N: 200}
+	ops := re.OpponentDistribution(c, nil, 10)
+	if len(ops) == 0 || len(ops) > 10 {
+		t.Fatalf("got %d opponent plays, want 1 to 10", len(ops))
+	}
+	for i, op := range ops {
+		if op.Frequency <= 0 || op.Frequency > 1 {
+			t.Errorf("%s: frequency out of range", &op)
+		}
+		if i > 0 && op.Frequency > ops[i-1].Frequency {
+			t.Errorf("%s is more frequent than %s", &op, &ops[i-1])
+		}
+		if op.Ranks[0] > op.Ranks[1] || op.Ranks[1] > op.Ranks[2] {
+			t.Errorf("%s is fouled", &op)
+		}
+	}
+	total := 0.0
+	for _, op := range re.OpponentDistribution(c, nil, 1000) {
+		total += op.Frequency
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("frequencies of all the opponent's plays sum to %f, want 1", total)
+	}
+}
+
+func TestTrainSampledEvaluatorSkew(t *testing.T) {
+	se := smallSampledEvaluator(t, 100)
+	short := &SampledEvaluator{wins: [3][]float64{se.wins[0], se.wins[1][:100], se.wins[2]}}
+	if _, err := TrainSampledEvaluator(short, 10, nil, nil); err == nil {
+		t.Errorf("training against an opponent with too few ranks succeeded")
+	}
+	other := &SampledEvaluator{wins: se.wins, table: "0123456789abcdef"}
+	if _, err := TrainSampledEvaluator(other, 10, nil, nil); err == nil {
+		t.Errorf("training against an opponent made with other tables succeeded")
+	}
+	var b bytes.Buffer
+	if err := se.Marshal(&b); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := UnmarshalSampledEvaluator(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := TrainSampledEvaluator(loaded, 10, nil, nil); err != nil {
+		t.Errorf("training against a loaded opponent failed: %s", err)
+	}
+}
+
+func TestTableInfo(t *testing.T) {
+	ti := TableInfo()
+	// There are 13 trips, 13*12 pairs and 13-choose-3 high cards in
+	// 3-card hands. There are 7462 distinct 5-card hands from one
+	// deck, and the tables also rank the 13 five of a kinds.
+	want := TableMetadata{ScoreMax: poker.ScoreMax, Ranks3: 13 + 13*12 + 286, Ranks5: 7462 + 13, Checksum: TableChecksum()}
+	if ti != want {
+		t.Errorf("TableInfo() = %+v, want %+v", ti, want)
+	}
+}
+
+func TestBootstrapEnsemble(t *testing.T) {
+	e, err := TrainBootstrapEnsemble(MaxProdEvaluator{}, 300, 5, ScoringHK, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(e.Members) != 5 {
+		t.Fatalf("got %d members, want 5", len(e.Members))
+	}
+	if reflect.DeepEqual(e.Members[0].Counts(2), e.Members[1].Counts(2)) {
+		t.Errorf("two members have the same counts")
+	}
+	rnd := rand.New(rand.NewSource(2))
+	for i := 0; i < 10; i++ {
+		c := randomDeal(rnd)
+		h, conf := e.DecisionConfidence(c)
+		if err := CheckHand(&h, c); err != nil {
+			t.Fatalf("%v: %s", &h, err)
+		}
+		if s := ArrangementStability(c, e, e.Members); s.Hand.Key() != h.Key() || s.Agreement != conf {
+			t.Errorf("confidence in %v is %f, but its stability is %+v", &h, conf, s)
+		}
+		if conf < 0 || conf > 1 {
+			t.Errorf("confidence in %v is %f, want between 0 and 1", &h, conf)
+		}
+		r := h.ranks()
+		want := 0.0
+		for _, se := range e.Members {
+			want += se.Evaluator(c)(r[0], r[1], r[2]) / 5
+		}
+		if got := e.Evaluator(c)(r[0], r[1], r[2]); math.Abs(got-want) > 1e-9 {
+			t.Errorf("ensemble values %v at %f, want the average %f", &h, got, want)
+		}
+	}
+	if _, err := NewBootstrapEnsemble(&RolloutEvaluator{Separable: true, Opponent: MaxProdEvaluator{}, N: 10}, 3, nil); err == nil {
+		t.Errorf("made an ensemble from a rollout evaluator that isn't pre-rolled-out")
+	}
+}
@@ -0,0 +1,99 @@
+package cpoker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"runtime/debug"
+	"time"
+)
+
+// Version identifies the source the binary was built from. Release
+// builds should set it with, for example:
+//
+//	go build -ldflags "-X github.com/paulhankin/cpoker.Version=$(git describe --always --dirty)"
+var Version = ""
+
+// buildVersion returns Version, or if it's not set, the module
+// version recorded in the binary.
+func buildVersion() string {
+	if Version != "" {
+		return Version
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		return bi.Main.Version
+	}
+	return "unknown"
+}
+
+// An Experiment records what's needed to attribute the results of a
+// training or comparison run: the parameters, the version of the
+// code, the random seeds used, and hashes of the files produced.
+type Experiment struct {
+	Name      string            `json:"name"`
+	Version   string            `json:"version"`
+	Start     time.Time         `json:"start"`
+	End       time.Time         `json:"end"`
+	Params    map[string]string `json:"params"`
+	Seeds     map[string]int64  `json:"seeds,omitempty"`
+	Artifacts map[string]string `json:"artifacts,omitempty"` // filename to SHA-256
+	Results   map[string]string `json:"results,omitempty"`
+}
+
+// NewExperiment starts recording an experiment. The parameters are the
+// values of all the flags in fs (which may be nil).
+func NewExperiment(name string, fs *flag.FlagSet) *Experiment {
+	ex := &Experiment{
+		Name:    name,
+		Version: buildVersion(),
+		Start:   time.Now(),
+		Params:  map[string]string{},
+		Seeds:   map[string]int64{},
+	}
+	if fs != nil {
+		fs.VisitAll(func(f *flag.Flag) {
+			ex.Params[f.Name] = f.Value.String()
+		})
+	}
+	return ex
+}
+
+// AddArtifact records the SHA-256 of a file produced by the experiment.
+func (ex *Experiment) AddArtifact(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	if ex.Artifacts == nil {
+		ex.Artifacts = map[string]string{}
+	}
+	ex.Artifacts[filename] = hex.EncodeToString(h.Sum(nil))
+	return nil
+}
+
+// Append marks the experiment finished, and appends it as a line of
+// JSON to the named file, creating it if necessary.
+func (ex *Experiment) Append(filename string) error {
+	ex.End = time.Now()
+	b, err := json.Marshal(ex)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
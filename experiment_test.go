@@ -0,0 +1,73 @@
+package cpoker
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExperiment(t *testing.T) {
+	dir, err := ioutil.TempDir("", "experiment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("cycles", 3, "")
+	fs.String("to", "", "")
+	if err := fs.Parse([]string{"-to", "out.data"}); err != nil {
+		t.Fatal(err)
+	}
+	ex := NewExperiment("train", fs)
+	if ex.Params["cycles"] != "3" || ex.Params["to"] != "out.data" {
+		t.Errorf("got params %v, want every flag's value", ex.Params)
+	}
+	ex.Seeds["deal"] = 7
+	artifact := filepath.Join(dir, "out.data")
+	if err := ioutil.WriteFile(artifact, []byte("abc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ex.AddArtifact(artifact); err != nil {
+		t.Fatal(err)
+	}
+	// The SHA-256 of "abc".
+	if got, want := ex.Artifacts[artifact], "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"; got != want {
+		t.Errorf("artifact hash is %s, want %s", got, want)
+	}
+	if err := ex.AddArtifact(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("AddArtifact of a missing file succeeded")
+	}
+
+	// Experiments are appended to the manifest, one per line.
+	manifest := filepath.Join(dir, "experiments.jsonl")
+	for i := 0; i < 2; i++ {
+		if err := ex.Append(manifest); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, err := os.Open(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	lines := 0
+	for s := bufio.NewScanner(f); s.Scan(); lines++ {
+		var got Experiment
+		if err := json.Unmarshal(s.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Name != "train" || got.Seeds["deal"] != 7 || got.Artifacts[artifact] != ex.Artifacts[artifact] || got.Params["to"] != "out.data" {
+			t.Errorf("manifest line %d is %+v, want %+v", lines, got, ex)
+		}
+		if got.End.Before(got.Start) {
+			t.Errorf("experiment ended at %s, before it started at %s", got.End, got.Start)
+		}
+	}
+	if lines != 2 {
+		t.Errorf("the manifest has %d lines, want 2", lines)
+	}
+}
@@ -0,0 +1,290 @@
+package cpoker
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// A Candidate is an arrangement considered when playing a hand.
+type Candidate struct {
+	Arrangement
+	EV float64 // The value of the arrangement, according to the evaluator
+
+	// Wins are the probabilities that the front, middle and back win,
+	// if the evaluator is a SampledEvaluator.
+	Wins    [3]float64
+	HasWins bool
+}
+
+// Royalties returns the royalties the candidate earns in each slot under
+// the given scoring.
+func (c *Candidate) Royalties(s *Scoring) [3]int {
+	var r [3]int
+	if s.Royalties == nil {
+		return r
+	}
+	for i := 0; i < 3; i++ {
+		r[i] = s.Royalties[i][c.Ranks[i]]
+	}
+	return r
+}
+
+// An Explanation describes why a hand is played the way it is.
+type Explanation struct {
+	Candidates []Candidate // The best arrangements, best first
+	Reasons    []string    // Why the best arrangement beats the runner-up
+}
+
+func (e *Explanation) String() string {
+	var b strings.Builder
+	for i, c := range e.Candidates {
+		fmt.Fprintf(&b, "%d. %s: EV %.3f\n", i+1, &c.Hand, c.EV)
+	}
+	for _, r := range e.Reasons {
+		fmt.Fprintf(&b, "  %s\n", r)
+	}
+	return b.String()
+}
+
+// royaltyString describes the royalties earned in each slot.
+func royaltyString(r [3]int) string {
+	parts := []string{"front", "middle", "back"}
+	var paid []string
+	for i, ri := range r {
+		if ri != 0 {
+			paid = append(paid, fmt.Sprintf("%s %d", parts[i], ri))
+		}
+	}
+	if len(paid) == 0 {
+		return "0"
+	}
+	return fmt.Sprintf("%d (%s)", r[0]+r[1]+r[2], strings.Join(paid, ", "))
+}
+
+// ExplainPlay plays the 13 cards c with the given evaluator, and
+// returns the three best arrangements, and how the best differs
+// from the runner-up, including the royalties each earns under the
+// scoring s (or Scoring2to4 if s is nil). If he is an EvaluatorPoints,
+// arrangements are valued in expected points scored with s, so the
+// value includes the royalties.
+func ExplainPlay(c []poker.Card, he HandEvaluator, s *Scoring) Explanation {
+	if s == nil {
+		s = Scoring2to4
+	}
+	evaluator := he.Evaluator(c)
+	if ep, ok := he.(EvaluatorPoints); ok {
+		evaluator = ep.Points(c, s)
+	}
+	se, _ := he.(*SampledEvaluator)
+	var cands []Candidate
+	for _, a := range Arrangements(c) {
+		cand := Candidate{Arrangement: a, EV: evaluator(a.Ranks[0], a.Ranks[1], a.Ranks[2])}
+		if se != nil {
+			cand.HasWins = true
+			for i := 0; i < 3; i++ {
+				cand.Wins[i] = se.wins[i][a.Ranks[i]]
+			}
+		}
+		cands = append(cands, cand)
+	}
+	sort.SliceStable(cands, func(i, j int) bool { return cands[i].EV > cands[j].EV })
+	if len(cands) > 3 {
+		cands = cands[:3]
+	}
+	e := Explanation{Candidates: cands}
+	if len(cands) < 2 {
+		e.Reasons = append(e.Reasons, "there's only one sensible arrangement")
+		return e
+	}
+	best, next := &cands[0], &cands[1]
+	parts := []string{"front", "middle", "back"}
+	slots := [3][2][]poker.Card{
+		{best.Hand.Front[:], next.Hand.Front[:]},
+		{best.Hand.Middle[:], next.Hand.Middle[:]},
+		{best.Hand.Back[:], next.Hand.Back[:]},
+	}
+	for i := 0; i < 3; i++ {
+		if best.Ranks[i] == next.Ranks[i] {
+			continue
+		}
+		d0, _ := poker.DescribeShort(slots[i][0])
+		d1, _ := poker.DescribeShort(slots[i][1])
+		verb := "stronger"
+		if best.Ranks[i] < next.Ranks[i] {
+			verb = "weaker"
+		}
+		r := fmt.Sprintf("%s is %s: %s rather than %s", parts[i], verb, d0, d1)
+		if best.HasWins {
+			r += fmt.Sprintf(" (wins %.1f%% rather than %.1f%%)", 100*best.Wins[i], 100*next.Wins[i])
+		}
+		e.Reasons = append(e.Reasons, r)
+	}
+	if rb, rn := best.Royalties(s), next.Royalties(s); rb != rn {
+		e.Reasons = append(e.Reasons, fmt.Sprintf("royalties are %s rather than %s", royaltyString(rb), royaltyString(rn)))
+	} else if rb != [3]int{} {
+		e.Reasons = append(e.Reasons, fmt.Sprintf("both earn royalties of %s", royaltyString(rb)))
+	}
+	e.Reasons = append(e.Reasons, fmt.Sprintf("overall, EV is better by %.3f", best.EV-next.EV))
+	return e
+}
+
+// A MiddleBackPlacement is a way of putting a candidate 5-card hand
+// in the middle or the back, with the remaining cards in the other slot.
+type MiddleBackPlacement struct {
+	Hand      Hand
+	Fouled    bool
+	Value     float64 // The value of the hand, or -Inf if it's fouled
+	Royalties [2]int  // The royalties earned in the middle and back
+}
+
+// A MiddleBackAdvice compares the placements of candidate hands in
+// the middle and back.
+type MiddleBackAdvice struct {
+	Placements []MiddleBackPlacement // Best first
+
+	// Points is set if values are expected points, rather than in
+	// the evaluator's own units.
+	Points bool
+}
+
+// Gain returns how much more the best placement is worth than the
+// next best, which is +Inf if every other placement fouls.
+func (a *MiddleBackAdvice) Gain() float64 {
+	if len(a.Placements) < 2 {
+		return math.Inf(1)
+	}
+	return a.Placements[0].Value - a.Placements[1].Value
+}
+
+func (a *MiddleBackAdvice) String() string {
+	var b strings.Builder
+	unit := ""
+	if a.Points {
+		unit = " points"
+	}
+	for i, p := range a.Placements {
+		md, _ := poker.DescribeShort(p.Hand.Middle[:])
+		bd, _ := poker.DescribeShort(p.Hand.Back[:])
+		fmt.Fprintf(&b, "%d. back %s, middle %s: ", i+1, bd, md)
+		if p.Fouled {
+			fmt.Fprintf(&b, "fouls\n")
+			continue
+		}
+		fmt.Fprintf(&b, "%.3f%s", p.Value, unit)
+		if p.Royalties != [2]int{} {
+			fmt.Fprintf(&b, ", royalties %d+%d", p.Royalties[0], p.Royalties[1])
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// AdviseMiddleBack compares putting each of the candidate 5-card
+// hands (typically two, for example a flush and a full house that
+// share cards) in the middle or the back, with the rest of the 10
+// cards in the other slot, and the given front. Hands are valued with
+// he; if it's an EvaluatorPoints, they're valued in expected points
+// scored with s (or Scoring2to4 if s is nil), which includes the
+// difference in royalties between the slots.
+func AdviseMiddleBack(front, rest []poker.Card, candidates [][]poker.Card, he HandEvaluator, s *Scoring) (MiddleBackAdvice, error) {
+	if s == nil {
+		s = Scoring2to4
+	}
+	if len(front) != 3 || len(rest) != 10 {
+		return MiddleBackAdvice{}, fmt.Errorf("got %d and %d cards, want 3 and 10", len(front), len(rest))
+	}
+	c := append(append([]poker.Card{}, front...), rest...)
+	if err := checkDeal(c); err != nil {
+		return MiddleBackAdvice{}, err
+	}
+	ev := he.Evaluator(c)
+	var a MiddleBackAdvice
+	if ep, ok := he.(EvaluatorPoints); ok {
+		ev, a.Points = ep.Points(c, s), true
+	}
+	for i, cand := range candidates {
+		in, ok := NewCardSet(cand)
+		if len(cand) != 5 || !ok {
+			return MiddleBackAdvice{}, fmt.Errorf("candidate %d isn't 5 distinct cards", i)
+		}
+		var other []poker.Card
+		for _, ci := range rest {
+			if !in.Contains(ci) {
+				other = append(other, ci)
+			}
+		}
+		if len(other) != 5 {
+			return MiddleBackAdvice{}, fmt.Errorf("candidate %d isn't made from the 10 cards", i)
+		}
+		for _, mb := range [2][2][]poker.Card{{other, cand}, {cand, other}} {
+			var p MiddleBackPlacement
+			copy(p.Hand.Front[:], front)
+			copy(p.Hand.Middle[:], mb[0])
+			copy(p.Hand.Back[:], mb[1])
+			r := p.Hand.ranks()
+			p.Fouled = r[0] > r[1] || r[1] > r[2]
+			p.Value = math.Inf(-1)
+			if !p.Fouled {
+				p.Value = ev(r[0], r[1], r[2])
+			}
+			if s.Royalties != nil {
+				p.Royalties = [2]int{s.Royalties[1][r[1]], s.Royalties[2][r[2]]}
+			}
+			a.Placements = append(a.Placements, p)
+		}
+	}
+	sort.SliceStable(a.Placements, func(i, j int) bool { return a.Placements[i].Value > a.Placements[j].Value })
+	return a, nil
+}
+
+// A CardValue is how much a card in a hand contributes to its value.
+type CardValue struct {
+	Card  poker.Card
+	Slot  int     // 0, 1, 2 for the front, middle and back
+	Value float64 // The hand's value, less its mean value with the card replaced
+}
+
+func (cv *CardValue) String() string {
+	parts := []string{"front", "middle", "back"}
+	return fmt.Sprintf("%s in the %s: %+.3f", cv.Card, parts[cv.Slot], cv.Value)
+}
+
+// CardValues returns, for each card of the hand h in order of slot,
+// how much it adds to the hand's value according to he: the value of
+// the hand, less the mean value of the hand with the card replaced by
+// each of the 39 cards that weren't dealt. A card that's doing nothing
+// for its slot, such as a low kicker, has a value near zero or below.
+// Replacements are valued by the strength of each slot, even if they
+// make the hand foul.
+func CardValues(h *Hand, he HandEvaluator) []CardValue {
+	c := h.cards()
+	dealt, _ := NewCardSet(c)
+	ev := he.Evaluator(c)
+	r := h.ranks()
+	value := ev(r[0], r[1], r[2])
+	var result []CardValue
+	slots := [3][]poker.Card{h.Front[:], h.Middle[:], h.Back[:]}
+	for i, slot := range slots {
+		sub := append([]poker.Card{}, slot...)
+		for j, card := range slot {
+			total, n := 0.0, 0
+			for _, s := range poker.Cards {
+				if dealt.Contains(s) {
+					continue
+				}
+				sub[j] = s
+				sr := r
+				sr[i] = eval(sub)
+				total += ev(sr[0], sr[1], sr[2])
+				n++
+			}
+			sub[j] = card
+			result = append(result, CardValue{Card: card, Slot: i, Value: value - total/float64(n)})
+		}
+	}
+	return result
+}
@@ -0,0 +1,79 @@
+package cpoker
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+func TestExplainPlay(t *testing.T) {
+	// A royal flush, which pays 5 in the back with ScoringHK.
+	c := mustCards(t, "SASKSQSJSTD2D4D6D8DTH3C5C7")
+	e := ExplainPlay(c, smallSampledEvaluator(t, 100), ScoringHK)
+	if len(e.Candidates) != 3 {
+		t.Fatalf("got %d candidates, want 3", len(e.Candidates))
+	}
+	if r := e.Candidates[0].Royalties(ScoringHK); r != [3]int{0, 0, 5} {
+		t.Errorf("best arrangement %s earns royalties %v, want 5 in the back", &e.Candidates[0].Hand, r)
+	}
+	found := false
+	for _, r := range e.Reasons {
+		found = found || strings.Contains(r, "royalties") && strings.Contains(r, "back 5")
+	}
+	if !found {
+		t.Errorf("explanation doesn't mention the royalty in the back:\n%s", &e)
+	}
+}
+
+func TestAdviseMiddleBack(t *testing.T) {
+	front := mustCards(t, "C2D3H4")
+	// A flush in hearts, or trip eights.
+	rest := mustCards(t, "H5H7H8HJHKS8D8C9SQDT")
+	flush := mustCards(t, "H5H7H8HJHK")
+	trips := mustCards(t, "H8S8D8SQDT")
+	se := smallSampledEvaluator(t, 100)
+	a, err := AdviseMiddleBack(front, rest, [][]poker.Card{flush, trips}, se, ScoringHK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Placements) != 4 || !a.Points {
+		t.Fatalf("got %d placements (points %v), want 4 in points", len(a.Placements), a.Points)
+	}
+	if best := a.Placements[0]; !reflect.DeepEqual(best.Hand.Back[:], flush) || best.Fouled {
+		t.Errorf("advised:\n%s\nwant the flush in the back first", &a)
+	}
+	if g := a.Gain(); g <= 0 {
+		t.Errorf("gain is %f, want positive", g)
+	}
+	for _, p := range a.Placements {
+		if r := p.Hand.ranks(); p.Fouled != (r[1] > r[2]) {
+			t.Errorf("placement %s: fouled is %v", &p.Hand, p.Fouled)
+		}
+	}
+	if _, err := AdviseMiddleBack(front, rest, [][]poker.Card{mustCards(t, "H5H7H8HJC2")}, se, nil); err == nil {
+		t.Errorf("advising with a candidate using the front's cards succeeded, want an error")
+	}
+}
+
+func TestCardValues(t *testing.T) {
+	se := smallSampledEvaluator(t, 300)
+	// The front is a pair of aces with a low kicker.
+	h := mustHand(t, "HASAC2", "D9C9S4H5D6", "SKHKDKC7D8")
+	cvs := CardValues(h, se)
+	if len(cvs) != 13 {
+		t.Fatalf("got %d card values, want 13", len(cvs))
+	}
+	for i, cv := range cvs {
+		if cv.Card != h.cards()[i] || cv.Slot != []int{0, 0, 0, 1, 1, 1, 1, 1, 2, 2, 2, 2, 2}[i] {
+			t.Errorf("card value %d is %s, want %s", i, &cv, h.cards()[i])
+		}
+	}
+	if ace, kicker := cvs[0].Value, cvs[2].Value; ace <= kicker || ace <= 0 {
+		t.Errorf("the ace in the front is worth %f, and the kicker %f; want the ace to be worth more", ace, kicker)
+	}
+	if king, seven := cvs[8].Value, cvs[11].Value; king <= seven {
+		t.Errorf("a king in the back is worth %f, and the seven %f; want the king to be worth more", king, seven)
+	}
+}
@@ -0,0 +1,53 @@
+package cpoker
+
+import "github.com/paulhankin/poker/v2/poker"
+
+// A Featurizer turns a way of playing a deal into a vector of
+// features, for use by learned evaluators. Sharing featurizers lets
+// different kinds of model be compared fairly.
+type Featurizer interface {
+	// Len returns the number of features.
+	Len() int
+
+	// Features appends to dst the features of playing the cards c with
+	// the given front, middle and back ranks, and returns the result.
+	Features(dst []float64, c []poker.Card, f, m, b int16) []float64
+}
+
+// DefaultFeaturizer has, for each of the front, middle and back, the
+// dense rank of the hand scaled to between 0 and 1, and a one-hot
+// encoding of the hand's category. It ignores the cards.
+type DefaultFeaturizer struct{}
+
+// Len returns the number of features.
+func (DefaultFeaturizer) Len() int {
+	return 3 * (1 + len(categoryNames))
+}
+
+// Features appends the features of playing the ranks to dst.
+func (DefaultFeaturizer) Features(dst []float64, _ []poker.Card, f, m, b int16) []float64 {
+	for i, e := range [3]int16{f, m, b} {
+		d := DenseRanksFor(i)
+		dst = append(dst, float64(d.Dense(e))/float64(d.Len()-1))
+		for c := HighCard; c <= FiveOfAKind; c++ {
+			dst = append(dst, float64(b2i(slotCategory(i, e) == c)))
+		}
+	}
+	return dst
+}
+
+// A ModelEvaluator evaluates hands by applying a model to the
+// features of each way of playing them.
+type ModelEvaluator struct {
+	Featurizer Featurizer
+	Model      func(features []float64) float64
+}
+
+// Evaluator returns a hand evaluator for the given set of cards.
+func (me *ModelEvaluator) Evaluator(c []poker.Card) func(f, m, b int16) float64 {
+	buf := make([]float64, 0, me.Featurizer.Len())
+	return func(f, m, b int16) float64 {
+		buf = me.Featurizer.Features(buf[:0], c, f, m, b)
+		return me.Model(buf)
+	}
+}
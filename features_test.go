@@ -0,0 +1,37 @@
+package cpoker
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestDefaultFeaturizer(t *testing.T) {
+	c := randomDeal(rand.New(rand.NewSource(4)))
+	var fz DefaultFeaturizer
+	for _, a := range Arrangements(c) {
+		got := fz.Features(nil, c, a.Ranks[0], a.Ranks[1], a.Ranks[2])
+		if len(got) != fz.Len() {
+			t.Fatalf("got %d features, want %d", len(got), fz.Len())
+		}
+		for _, x := range got {
+			if x < 0 || x > 1 {
+				t.Errorf("feature %f out of range", x)
+			}
+		}
+	}
+}
+
+func TestModelEvaluator(t *testing.T) {
+	// A model which just sums the scaled ranks of each slot.
+	me := &ModelEvaluator{
+		Featurizer: DefaultFeaturizer{},
+		Model: func(x []float64) float64 {
+			n := len(x) / 3
+			return x[0] + x[n] + x[2*n]
+		},
+	}
+	c := randomDeal(rand.New(rand.NewSource(5)))
+	if _, _, err := PlayE(c, me); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,52 @@
+// Binary gentables writes handcounts_gen.go, which holds how many
+// 3-card and 5-card hands have each rank, so that programs don't have
+// to enumerate every hand when they start. Run it with go generate
+// after changing the version of the poker package. It doesn't import
+// cpoker, so it can still be built if handcounts_gen.go is missing or
+// out of date:
+//
+//	go generate github.com/paulhankin/cpoker
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+
+	"github.com/paulhankin/cpoker/internal/ranks"
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+var out = flag.String("out", "handcounts_gen.go", "the file to write")
+
+func main() {
+	flag.Parse()
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by gentables; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package cpoker\n\n")
+	fmt.Fprintf(&b, "// generatedTableChecksum is the TableChecksum of the tables the\n// counts were generated from.\n")
+	fmt.Fprintf(&b, "const generatedTableChecksum = %q\n\n", ranks.Checksum())
+	fmt.Fprintf(&b, "// generatedHandCounts[0] and generatedHandCounts[1] are how many\n// 3-card and 5-card hands have each rank.\n")
+	fmt.Fprintf(&b, "var generatedHandCounts = [2][%d]int32{\n", poker.ScoreMax+1)
+	for _, size := range []int{3, 5} {
+		fmt.Fprintf(&b, "{\n")
+		for e, n := range ranks.Count(size) {
+			fmt.Fprintf(&b, "%d,", n)
+			if e%16 == 15 {
+				fmt.Fprintf(&b, "\n")
+			}
+		}
+		fmt.Fprintf(&b, "\n},\n")
+	}
+	fmt.Fprintf(&b, "}\n")
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatalf("failed to format generated code: %s", err)
+	}
+	if err := ioutil.WriteFile(*out, src, 0644); err != nil {
+		log.Fatalf("failed to write %s: %s", *out, err)
+	}
+}
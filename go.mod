@@ -2,4 +2,7 @@ module github.com/paulhankin/cpoker
 
 go 1.13
 
-require github.com/paulhankin/poker/v2 v2.0.6
+require (
+	github.com/paulhankin/poker/v2 v2.0.6
+	gopkg.in/yaml.v3 v3.0.1
+)
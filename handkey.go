@@ -0,0 +1,46 @@
+package cpoker
+
+// A HandKey identifies a hand, whatever the order of the cards in each
+// of its front, middle and back. Keys can be compared with == and used
+// as map keys.
+type HandKey [3]CardSet
+
+// Key returns the hand's key.
+func (h *Hand) Key() HandKey {
+	var k HandKey
+	for _, c := range h.Front {
+		k[0] = k[0].Add(c)
+	}
+	for _, c := range h.Middle {
+		k[1] = k[1].Add(c)
+	}
+	for _, c := range h.Back {
+		k[2] = k[2].Add(c)
+	}
+	return k
+}
+
+// Hash returns a 64-bit hash of the hand's key, for compact indexes
+// of many hands. Different hands can have the same hash, though it's
+// very unlikely; use Key where that matters.
+func (h *Hand) Hash() uint64 {
+	return h.Key().Hash()
+}
+
+// Hash returns a 64-bit hash of the key.
+func (k HandKey) Hash() uint64 {
+	var x uint64
+	for _, cs := range k {
+		x = mix64(x ^ uint64(cs))
+	}
+	return x
+}
+
+// mix64 is the finalizer of the splitmix64 generator, which
+// scrambles the bits of x.
+func mix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+	x = (x ^ x>>27) * 0x94d049bb133111eb
+	return x ^ x>>31
+}
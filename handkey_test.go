@@ -0,0 +1,36 @@
+package cpoker
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestHandHash(t *testing.T) {
+	rnd := rand.New(rand.NewSource(8))
+	hashes := map[uint64]HandKey{}
+	for i := 0; i < 200000; i++ {
+		c := randomDeal(rnd)
+		var h Hand
+		copy(h.Front[:], c[:3])
+		copy(h.Middle[:], c[3:8])
+		copy(h.Back[:], c[8:])
+		k, x := h.Key(), h.Hash()
+		if other, ok := hashes[x]; ok && other != k {
+			t.Fatalf("%s has the same hash as another hand", &h)
+		}
+		hashes[x] = k
+
+		// Reordering the cards in a slot doesn't change the hash, but
+		// moving a card to another slot does.
+		shuffled := h
+		rnd.Shuffle(5, func(i, j int) { shuffled.Back[i], shuffled.Back[j] = shuffled.Back[j], shuffled.Back[i] })
+		if shuffled.Key() != k || shuffled.Hash() != x {
+			t.Fatalf("reordering the back of %s changed its key", &h)
+		}
+		swapped := h
+		swapped.Middle[0], swapped.Back[0] = h.Back[0], h.Middle[0]
+		if swapped.Key() == k || swapped.Hash() == x {
+			t.Fatalf("swapping cards between the middle and back of %s didn't change its key", &h)
+		}
+	}
+}
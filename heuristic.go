@@ -0,0 +1,205 @@
+package cpoker
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/paulhankin/cpoker/internal/ranks"
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+//go:generate go run ./gentables
+
+var (
+	percentilesInit tableInit
+	percentiles     [2][]float64 // for 3-card and 5-card hands
+	weaker          [2][]float64 // the fraction of hands strictly weaker
+
+	// handCountsChecksum is the TableChecksum of the tables the
+	// generated hand counts are for. Tests change it to act as if the
+	// rank tables had changed.
+	handCountsChecksum = generatedTableChecksum
+)
+
+// countHands returns how many 3-card (if i is 0) or 5-card hands have
+// each rank. The counts are generated by gentables, so that every
+// hand doesn't have to be enumerated, but if the poker package's
+// tables have changed since, they're counted instead.
+func countHands(i int) []int {
+	if handCountsChecksum != TableChecksum() {
+		return ranks.Count([]int{3, 5}[i])
+	}
+	counts := make([]int, poker.ScoreMax+1)
+	for e, n := range generatedHandCounts[i] {
+		counts[e] = int(n)
+	}
+	return counts
+}
+
+// HandCounts5 returns, for each Eval5 rank from 0 to ScoreMax, how many
+// distinct 5-card hands from one deck have that rank. The counts add up
+// to 2,598,960, so, for example, the exact probability that a hand beats
+// a uniformly random hand is the sum of the counts of the weaker ranks
+// divided by that.
+func HandCounts5() []int {
+	return countHands(1)
+}
+
+// HandCounts3 returns, for each Eval3 rank from 0 to ScoreMax, how many
+// distinct 3-card hands from one deck have that rank. The counts add up
+// to 22,100.
+func HandCounts3() []int {
+	return countHands(0)
+}
+
+// handPercentiles returns, for each rank, the fraction of all 3-card
+// (if i is 0) or 5-card hands which are weaker, counting hands of the
+// same rank as half weaker. They're computed on first use.
+func handPercentiles(i int) []float64 {
+	percentilesInit.Do(func() {
+		for j := range percentiles {
+			counts := countHands(j)
+			total := 0
+			for _, c := range counts {
+				total += c
+			}
+			percentiles[j] = make([]float64, len(counts))
+			weaker[j] = make([]float64, len(counts))
+			below := 0
+			for e, c := range counts {
+				percentiles[j][e] = (float64(below) + float64(c)/2) / float64(total)
+				weaker[j][e] = float64(below) / float64(total)
+				below += c
+			}
+		}
+	})
+	return percentiles[i]
+}
+
+// percentile returns the fraction of all 3-card (if i is 0) or 5-card
+// hands which are strictly weaker than rank e.
+func percentile(i int, e int16) float64 {
+	handPercentiles(i)
+	switch {
+	case e < 0:
+		return 0
+	case int(e) >= len(weaker[i]):
+		return 1
+	}
+	return weaker[i][e]
+}
+
+// Percentile5 returns the fraction of all 5-card hands from one deck
+// whose Eval5 rank is lower than e, computed exactly from HandCounts5.
+// Hands of rank e don't count, so the weakest hand is at 0. Ranks below
+// 0 are at 0, and ranks above ScoreMax at 1.
+func Percentile5(e int16) float64 {
+	return percentile(1, e)
+}
+
+// Percentile3 is like Percentile5, but for 3-card hands and Eval3
+// ranks. Ranks stronger than any 3-card hand, such as flushes, are at 1.
+func Percentile3(e int16) float64 {
+	return percentile(0, e)
+}
+
+// A HeuristicEvaluator is a simple hand-crafted evaluator, which is a
+// much better starting point for training than MaxProdEvaluator. It
+// estimates the probability each slot wins from the percentile of its
+// hand among all hands of that size, raised to a power for each slot,
+// since opponents' hands are stronger than random ones and are
+// stronger towards the back. These are combined as a SampledEvaluator
+// combines its win probabilities. Optional bonuses reward a pair or
+// better in the front and a flush or better in the middle. The win
+// probabilities are computed on first use, so the exponents mustn't be
+// changed after the evaluator has been used.
+type HeuristicEvaluator struct {
+	Exponents   [3]float64 // The powers the front, middle and back percentiles are raised to
+	FrontPair   float64    // The bonus for a pair or better in the front
+	MiddleFlush float64    // The bonus for a flush or better in the middle
+
+	winsInit tableInit
+	se       SampledEvaluator // The win probabilities from the exponents
+}
+
+// DefaultHeuristic is a HeuristicEvaluator with exponents chosen by
+// matching it against the trained coefficients. Neither bonus helped.
+var DefaultHeuristic = &HeuristicEvaluator{Exponents: [3]float64{6, 15, 30}}
+
+// Evaluator returns the function that evaluates hands.
+func (he *HeuristicEvaluator) Evaluator(_ []poker.Card) func(f, m, b int16) float64 {
+	he.winsInit.Do(func() {
+		p3, p5 := handPercentiles(0), handPercentiles(1)
+		for i, p := range [][]float64{p3, p5, p5} {
+			he.se.wins[i] = make([]float64, len(p))
+			for e := range p {
+				he.se.wins[i][e] = math.Pow(p[e], he.Exponents[i])
+			}
+		}
+	})
+	se := &he.se
+	return func(f, m, b int16) float64 {
+		v := se.evaluateHand(f, m, b)
+		if slotCategory(0, f) >= Pair {
+			v += he.FrontPair
+		}
+		if slotCategory(1, m) >= Flush {
+			v += he.MiddleFlush
+		}
+		return v
+	}
+}
+
+// A WeightedSlotEvaluator values a hand as a weighted sum of the
+// percentiles of its front, middle and back among all hands of their
+// size. It's a simple parametric baseline: it's much weaker than
+// HeuristicEvaluator, but the weights are easy to interpret.
+type WeightedSlotEvaluator struct {
+	Wf, Wm, Wb float64
+}
+
+// Evaluator returns the function that evaluates hands.
+func (we WeightedSlotEvaluator) Evaluator(_ []poker.Card) func(f, m, b int16) float64 {
+	p3, p5 := handPercentiles(0), handPercentiles(1)
+	return func(f, m, b int16) float64 {
+		return we.Wf*p3[f] + we.Wm*p5[m] + we.Wb*p5[b]
+	}
+}
+
+// A WeightTrial is the result of trying one set of weights.
+type WeightTrial struct {
+	Weights WeightedSlotEvaluator
+	Score   float64 // The mean score per hand against the rollout
+}
+
+// TuneWeightedSlots tries every pair of front and middle weights from
+// the grid, with the back weight fixed at 1 (since scaling all the
+// weights doesn't change how hands are played). Each is scored by
+// playing the same deals from rnd against a rollout of n hands played
+// by opp, using the given scoring, or Scoring2to4 if it's nil. The
+// trials are returned best first.
+func TuneWeightedSlots(rnd *rand.Rand, opp HandEvaluator, grid []float64, deals, n int, scoring *Scoring) []WeightTrial {
+	if scoring == nil {
+		scoring = Scoring2to4
+	}
+	played, _, _ := rollout(rnd, nil, opp, n)
+	cs := NewProbeSet(rnd, deals).Deals
+	var trials []WeightTrial
+	for _, wf := range grid {
+		for _, wm := range grid {
+			we := WeightedSlotEvaluator{Wf: wf, Wm: wm, Wb: 1}
+			total := 0
+			for _, c := range cs {
+				h, _ := Play(c, we)
+				for _, p := range played {
+					score, _, _ := scoring.showdown(h.ranks(), p)
+					total += score
+				}
+			}
+			trials = append(trials, WeightTrial{we, float64(total) / float64(deals*n)})
+		}
+	}
+	sort.SliceStable(trials, func(i, j int) bool { return trials[i].Score > trials[j].Score })
+	return trials
+}
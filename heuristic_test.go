@@ -0,0 +1,124 @@
+package cpoker
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+func TestHeuristicEvaluator(t *testing.T) {
+	for i := 0; i < 2; i++ {
+		p := handPercentiles(i)
+		for e := 1; e < len(p); e++ {
+			if p[e] < p[e-1] {
+				t.Fatalf("handPercentiles(%d)[%d] = %f, less than %f for the rank below", i, e, p[e], p[e-1])
+			}
+		}
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		c := randomDeal(rnd)
+		h, _ := Play(c, DefaultHeuristic)
+		if err := CheckHand(&h, c); err != nil {
+			t.Errorf("heuristic play of %v is illegal: %s", c, err)
+		}
+	}
+}
+
+func TestTuneWeightedSlots(t *testing.T) {
+	grid := []float64{0.5, 1, 2}
+	trials := TuneWeightedSlots(rand.New(rand.NewSource(1)), MaxProdEvaluator{}, grid, 20, 100, nil)
+	if len(trials) != len(grid)*len(grid) {
+		t.Fatalf("got %d trials, want %d", len(trials), len(grid)*len(grid))
+	}
+	for i := 1; i < len(trials); i++ {
+		if trials[i].Score > trials[i-1].Score {
+			t.Errorf("trial %d (%+v) scored better than trial %d (%+v)", i, trials[i], i-1, trials[i-1])
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		percentile func(int16) float64
+		counts     []int
+	}{{"Percentile3", Percentile3, HandCounts3()}, {"Percentile5", Percentile5, HandCounts5()}} {
+		total := 0
+		for _, n := range tc.counts {
+			total += n
+		}
+		below := 0
+		for e, n := range tc.counts {
+			if got, want := tc.percentile(int16(e)), float64(below)/float64(total); got != want {
+				t.Fatalf("%s(%d) = %f, want %f", tc.name, e, got, want)
+			}
+			below += n
+		}
+		if got := tc.percentile(-1); got != 0 {
+			t.Errorf("%s(-1) = %f, want 0", tc.name, got)
+		}
+		if got := tc.percentile(poker.ScoreMax + 1); got != 1 {
+			t.Errorf("%s(%d) = %f, want 1", tc.name, poker.ScoreMax+1, got)
+		}
+	}
+	// Only the 4 royal flushes aren't weaker than a royal flush.
+	if got, want := Percentile5(MinScoreFiveOfAKind-1), 1-4/2598960.0; math.Abs(got-want) > 1e-12 {
+		t.Errorf("Percentile5 of a royal flush = %.9f, want %.9f", got, want)
+	}
+	if got := Percentile3(MinScoreFlush); got != 1 {
+		t.Errorf("Percentile3 of a flush = %f, want 1", got)
+	}
+}
+
+func TestGeneratedHandCounts(t *testing.T) {
+	if generatedTableChecksum != TableChecksum() {
+		t.Fatalf("handcounts_gen.go was generated from tables %s, want %s: run go generate", generatedTableChecksum, TableChecksum())
+	}
+	for i, size := range []int{3, 5} {
+		counts := countHands(i)
+		want := make([]int, len(counts))
+		h := make([]poker.Card, size)
+		forEachCombination(52, size, func(idx []int) {
+			for k, x := range idx {
+				h[k] = poker.Cards[x]
+			}
+			want[eval(h)]++
+		})
+		if !reflect.DeepEqual(counts, want) {
+			t.Errorf("generated counts of %d-card hands differ from the hands", size)
+		}
+	}
+}
+
+func TestHandCounts(t *testing.T) {
+	for _, tc := range []struct {
+		counts []int
+		total  int
+	}{{HandCounts3(), 22100}, {HandCounts5(), 2598960}} {
+		total := 0
+		for _, n := range tc.counts {
+			total += n
+		}
+		if len(tc.counts) != poker.ScoreMax+1 || total != tc.total {
+			t.Errorf("got %d counts adding up to %d, want %d adding up to %d", len(tc.counts), total, poker.ScoreMax+1, tc.total)
+		}
+	}
+	// Every royal flush, and nothing else, has the best rank below five
+	// of a kind, which can't be dealt from one deck.
+	counts := HandCounts5()
+	if n := counts[MinScoreFiveOfAKind-1]; n != 4 {
+		t.Errorf("%d hands have the rank of a royal flush, want 4", n)
+	}
+	if n := counts[poker.ScoreMax]; n != 0 {
+		t.Errorf("%d hands have the rank of five aces, want 0", n)
+	}
+	// The counts are copies.
+	counts[0] = -1
+	if HandCounts5()[0] == -1 {
+		t.Errorf("changing the counts changed later counts")
+	}
+}
@@ -0,0 +1,239 @@
+package cpoker
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// A HistoryHand is a hand from a player's hand history: how they
+// arranged their cards, and how their opponent arranged theirs. The
+// player's name is only needed when a history (such as a record of a
+// session) has hands from several players.
+type HistoryHand struct {
+	Player   string `json:"player,omitempty"`
+	Hand     Hand   `json:"hand"`
+	Opponent Hand   `json:"opponent"`
+}
+
+// ReadHandHistory reads a hand history, which has one HistoryHand
+// per line, encoded as JSON. Blank lines are skipped.
+func ReadHandHistory(r io.Reader) ([]HistoryHand, error) {
+	var hands []HistoryHand
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		if strings.TrimSpace(s.Text()) == "" {
+			continue
+		}
+		var hh HistoryHand
+		if err := json.Unmarshal(s.Bytes(), &hh); err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		hands = append(hands, hh)
+	}
+	return hands, s.Err()
+}
+
+// cards returns the 13 cards of a hand.
+func (h *Hand) cards() []poker.Card {
+	return append(append(append([]poker.Card{}, h.Front[:]...), h.Middle[:]...), h.Back[:]...)
+}
+
+// checkCards checks that no card is dealt more than once, to either
+// the player or the opponent. Either hand may be fouled.
+func (hh *HistoryHand) checkCards() error {
+	seen := map[poker.Card]bool{}
+	for _, c := range append(hh.Hand.cards(), hh.Opponent.cards()...) {
+		if seen[c] {
+			return fmt.Errorf("card %s is dealt more than once", c)
+		}
+		seen[c] = true
+	}
+	return nil
+}
+
+// A ReplayGap is a hand from a history which was replayed, with how
+// the player and the engine played the player's cards.
+type ReplayGap struct {
+	Index       int  `json:"index"` // The index of the hand in the history
+	Player      Hand `json:"player"`
+	Engine      Hand `json:"engine"`
+	Opponent    Hand `json:"opponent"`
+	PlayerScore int  `json:"player_score"`
+	EngineScore int  `json:"engine_score"`
+	Fouled      bool `json:"fouled,omitempty"` // Whether the player's hand is fouled
+}
+
+func (g *ReplayGap) String() string {
+	fouled := ""
+	if g.Fouled {
+		fouled = " (fouled)"
+	}
+	return fmt.Sprintf("hand %d: engine %+d, player %+d\n  player:   %s%s\n  engine:   %s\n  opponent: %s",
+		g.Index, g.EngineScore, g.PlayerScore, &g.Player, fouled, &g.Engine, &g.Opponent)
+}
+
+// A ReplayReport compares a player's results in a hand history with
+// the results an engine would have had playing the same cards
+// against the same opponent hands.
+type ReplayReport struct {
+	Hands         int         `json:"hands"`
+	PlayerPerHand float64     `json:"player_per_hand"` // The player's mean score
+	EnginePerHand float64     `json:"engine_per_hand"` // The engine's mean score
+	StdErr        float64     `json:"stderr"`          // The standard error of the difference of the means
+	Differences   int         `json:"differences"`     // How many hands the engine played differently
+	Gaps          []ReplayGap `json:"gaps"`            // The hands where the engine did best compared to the player, best first
+}
+
+func (r *ReplayReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d hands, %d played differently\n", r.Hands, r.Differences)
+	fmt.Fprintf(&b, "player %.4f per hand, engine %.4f per hand, difference %+.4f +/- %.4f\n",
+		r.PlayerPerHand, r.EnginePerHand, r.EnginePerHand-r.PlayerPerHand, r.StdErr)
+	for i := range r.Gaps {
+		fmt.Fprintf(&b, "\n%s\n", &r.Gaps[i])
+	}
+	return b.String()
+}
+
+// ReplayHistory replays each of the player's deals in a history with
+// play (for example, Play with an evaluator, or an engine client), and
+// scores both the player's and the engine's hands against the
+// opponent's hand, using the given scoring, or Scoring2to4 if it's
+// nil. The report includes the top hands where the engine gained the
+// most over the player. A fouled hand loses every slot and earns no
+// royalties, so a hand the player fouled is scored with that penalty,
+// and is usually among the gaps.
+func ReplayHistory(hands []HistoryHand, play func(c []poker.Card) (Hand, error), s *Scoring, top int) (*ReplayReport, error) {
+	if s == nil {
+		s = Scoring2to4
+	}
+	r := &ReplayReport{Hands: len(hands)}
+	var diffs lossStats
+	var gaps []ReplayGap
+	for i := range hands {
+		hh := &hands[i]
+		if err := hh.checkCards(); err != nil {
+			return nil, fmt.Errorf("hand %d: %s", i, err)
+		}
+		c := hh.Hand.cards()
+		eh, err := play(c)
+		if err != nil {
+			return nil, fmt.Errorf("hand %d: %s", i, err)
+		}
+		pr, er, opp := hh.Hand.ranks(), eh.ranks(), hh.Opponent.ranks()
+		ps, _, _ := s.foulShowdown(pr, opp)
+		es, _, _ := s.foulShowdown(er, opp)
+		g := ReplayGap{
+			Index:       i,
+			Player:      hh.Hand,
+			Engine:      eh,
+			Opponent:    hh.Opponent,
+			PlayerScore: ps,
+			EngineScore: es,
+			Fouled:      pr[0] > pr[1] || pr[1] > pr[2],
+		}
+		r.PlayerPerHand += float64(g.PlayerScore)
+		r.EnginePerHand += float64(g.EngineScore)
+		diffs.add(float64(g.EngineScore - g.PlayerScore))
+		if er != pr {
+			r.Differences++
+		}
+		gaps = append(gaps, g)
+	}
+	if r.Hands > 0 {
+		r.PlayerPerHand /= float64(r.Hands)
+		r.EnginePerHand /= float64(r.Hands)
+		r.StdErr = math.Sqrt(diffs.variance() / float64(r.Hands))
+	}
+	sort.SliceStable(gaps, func(i, j int) bool {
+		return gaps[i].EngineScore-gaps[i].PlayerScore > gaps[j].EngineScore-gaps[j].PlayerScore
+	})
+	for _, g := range gaps {
+		if len(r.Gaps) >= top || g.EngineScore <= g.PlayerScore {
+			break
+		}
+		r.Gaps = append(r.Gaps, g)
+	}
+	return r, nil
+}
+
+// A Luck is a player's results over a set of hands, split into what
+// was due to their decisions and what was due to luck. Score is the
+// sum of DealEV, DecisionEV and Matchup.
+type Luck struct {
+	Player string `json:"player"`
+	Hands  int    `json:"hands"`
+	Score  int    `json:"score"` // The points the player scored
+
+	// DealEV is the expected points of the best play of the cards the
+	// player was dealt: how lucky they were with their cards.
+	DealEV float64 `json:"deal_ev"`
+
+	// DecisionEV is the expected points lost by playing differently
+	// to the best play, which is never positive.
+	DecisionEV float64 `json:"decision_ev"`
+
+	// Matchup is the difference between the points scored and the
+	// expected points of the hands played: how lucky they were with
+	// their opponents' hands.
+	Matchup float64 `json:"matchup"`
+}
+
+func (l *Luck) String() string {
+	return fmt.Sprintf("%s: %+d over %d hands = deal %+.2f, decisions %+.2f, matchups %+.2f",
+		l.Player, l.Score, l.Hands, l.DealEV, l.DecisionEV, l.Matchup)
+}
+
+// DecomposeLuck splits each player's results in a history into the
+// strength of their deals, the quality of their decisions, and the
+// luck of the matchups, using ep's expected points under the scoring
+// s (or Scoring2to4 if it's nil) to value hands. Players are returned
+// in order of name. Fouled hands are scored as ReplayHistory scores
+// them, and a fouled hand is expected to lose the forfeit for fouling,
+// so the royalties of the opponent's hand are counted as luck.
+func DecomposeLuck(hands []HistoryHand, ep EvaluatorPoints, s *Scoring) ([]Luck, error) {
+	if s == nil {
+		s = Scoring2to4
+	}
+	byPlayer := map[string]*Luck{}
+	var names []string
+	for i := range hands {
+		hh := &hands[i]
+		if err := hh.checkCards(); err != nil {
+			return nil, fmt.Errorf("hand %d: %s", i, err)
+		}
+		c := hh.Hand.cards()
+		ev := ep.Points(c, s)
+		best, _ := Play(c, fixedEvaluator(ev))
+		br, pr := best.ranks(), hh.Hand.ranks()
+		bestEV, playedEV := ev(br[0], br[1], br[2]), ev(pr[0], pr[1], pr[2])
+		if pr[0] > pr[1] || pr[1] > pr[2] {
+			playedEV = -float64(s.forfeit())
+		}
+		score, _, _ := s.foulShowdown(pr, hh.Opponent.ranks())
+		l := byPlayer[hh.Player]
+		if l == nil {
+			l = &Luck{Player: hh.Player}
+			byPlayer[hh.Player] = l
+			names = append(names, hh.Player)
+		}
+		l.Hands++
+		l.Score += score
+		l.DealEV += bestEV
+		l.DecisionEV += playedEV - bestEV
+		l.Matchup += float64(score) - playedEV
+	}
+	sort.Strings(names)
+	var r []Luck
+	for _, name := range names {
+		r = append(r, *byPlayer[name])
+	}
+	return r, nil
+}
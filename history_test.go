@@ -0,0 +1,126 @@
+package cpoker
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// randomDeals deals 13 cards to each of two players.
+func randomDeals(rnd *rand.Rand) (c, opp []poker.Card) {
+	cards := append([]poker.Card{}, poker.Cards...)
+	rnd.Shuffle(len(cards), func(i, j int) { cards[i], cards[j] = cards[j], cards[i] })
+	return cards[:13], cards[13:26]
+}
+
+func TestReplayHistory(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	var b bytes.Buffer
+	for i := 0; i < 20; i++ {
+		c, opp := randomDeals(rnd)
+		hh := HistoryHand{Opponent: Arrangements(opp)[0].Hand}
+		hh.Hand, _ = Play(c, MaxProdEvaluator{})
+		if i%2 == 1 {
+			// Play some hands differently, with another arrangement.
+			a := Arrangements(c)
+			hh.Hand = a[len(a)-1].Hand
+		}
+		j, err := json.Marshal(hh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(&b, "%s\n", j)
+	}
+	hands, err := ReadHandHistory(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	play := func(c []poker.Card) (Hand, error) {
+		h, _, err := PlayE(c, MaxProdEvaluator{})
+		return h, err
+	}
+	r, err := ReplayHistory(hands, play, nil, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Hands != 20 {
+		t.Errorf("replayed %d hands, want 20", r.Hands)
+	}
+	if r.Differences > 10 {
+		t.Errorf("%d hands were played differently, want at most 10", r.Differences)
+	}
+	if len(r.Gaps) > 5 {
+		t.Errorf("got %d gaps, want at most 5", len(r.Gaps))
+	}
+	for _, g := range r.Gaps {
+		if g.Index%2 == 0 {
+			t.Errorf("hand %d played the same way has a gap: %s", g.Index, &g)
+		}
+	}
+
+	// A fouled hand loses every slot and the opponent's royalties, and
+	// is a gap.
+	fouled := HistoryHand{
+		Hand:     *mustHand(t, "SASKSQ", "H2D3C4S5H7", "D8C9STHJD6"),
+		Opponent: *mustHand(t, "H3C3S3", "H4S4D4H8C2", "D5C5H5C6HT"),
+	}
+	r, err = ReplayHistory([]HistoryHand{fouled}, play, ScoringHK, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := -(3 + 3 + 3); len(r.Gaps) != 1 || !r.Gaps[0].Fouled || r.Gaps[0].PlayerScore != want {
+		t.Errorf("fouled hand: got gaps %v, want one fouled gap with score %d", r.Gaps, want)
+	}
+
+	// The player and the opponent can't share cards.
+	shared := fouled
+	shared.Opponent.Back[4] = shared.Hand.Front[0]
+	if _, err := ReplayHistory([]HistoryHand{shared}, play, nil, 5); err == nil {
+		t.Errorf("replaying a hand sharing a card with the opponent succeeded, want an error")
+	}
+}
+
+func TestDecomposeLuck(t *testing.T) {
+	se := smallSampledEvaluator(t, 100)
+	rnd := rand.New(rand.NewSource(1))
+	var hands []HistoryHand
+	for i := 0; i < 10; i++ {
+		hh := HistoryHand{Player: []string{"bob", "alice"}[i%2]}
+		c, opp := randomDeals(rnd)
+		hh.Hand, _ = Play(c, MaxProdEvaluator{})
+		hh.Opponent, _ = Play(opp, MaxProdEvaluator{})
+		hands = append(hands, hh)
+	}
+	// Bob fouls his first hand.
+	fouled := &hands[0].Hand
+	fouled.Middle, fouled.Back = fouled.Back, fouled.Middle
+	if r := fouled.ranks(); r[1] <= r[2] {
+		t.Fatalf("hand %s isn't fouled", fouled)
+	}
+	ls, err := DecomposeLuck(hands, se, ScoringHK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ls) != 2 || ls[0].Player != "alice" || ls[1].Player != "bob" {
+		t.Fatalf("got %v, want alice and bob", ls)
+	}
+	for _, l := range ls {
+		if l.Hands != 5 {
+			t.Errorf("%s played %d hands, want 5", l.Player, l.Hands)
+		}
+		if l.DecisionEV > 0 {
+			t.Errorf("%s gained %f from decisions, want at most 0", l.Player, l.DecisionEV)
+		}
+		if l.Player == "bob" && l.DecisionEV > -float64(ScoringHK.forfeit()) {
+			t.Errorf("bob fouled a hand, but only lost %f from decisions", l.DecisionEV)
+		}
+		if sum := l.DealEV + l.DecisionEV + l.Matchup; math.Abs(sum-float64(l.Score)) > 1e-9 {
+			t.Errorf("%s: parts sum to %f, want the score %d", l.Player, sum, l.Score)
+		}
+	}
+}
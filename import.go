@@ -0,0 +1,101 @@
+package cpoker
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// ReadWinProbabilitiesCSV reads win probabilities produced outside this
+// package, and constructs a SampledEvaluator from them. The CSV must have
+// a header row "rank,front,middle,back", followed by one row for each rank
+// from 0 to ScoreMax, in order. Each row has the probability that a hand
+// of that rank wins in each slot (as returned by WinProbabilities).
+func ReadWinProbabilitiesCSV(r io.Reader) (*SampledEvaluator, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 || len(rows[0]) != 4 || rows[0][0] != "rank" || rows[0][1] != "front" || rows[0][2] != "middle" || rows[0][3] != "back" {
+		return nil, fmt.Errorf("want header rank,front,middle,back")
+	}
+	rows = rows[1:]
+	if len(rows) != poker.ScoreMax+1 {
+		return nil, fmt.Errorf("got %d ranks, want %d", len(rows), poker.ScoreMax+1)
+	}
+	var p [3][]float64
+	for i, row := range rows {
+		if rank, err := strconv.Atoi(row[0]); err != nil || rank != i {
+			return nil, fmt.Errorf("row %d: got rank %q, want %d", i+2, row[0], i)
+		}
+		for j := 0; j < 3; j++ {
+			x, err := strconv.ParseFloat(row[j+1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %s", i+2, err)
+			}
+			p[j] = append(p[j], x)
+		}
+	}
+	return NewSampledEvaluatorFromProbabilities(p)
+}
+
+// ReadWinProbabilitiesJSON is like ReadWinProbabilitiesCSV, but reads
+// a JSON object with "front", "middle" and "back" fields, each of which
+// is an array of the ScoreMax+1 win probabilities for that slot.
+func ReadWinProbabilitiesJSON(r io.Reader) (*SampledEvaluator, error) {
+	var in struct {
+		Front, Middle, Back []float64
+	}
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, err
+	}
+	return NewSampledEvaluatorFromProbabilities([3][]float64{in.Front, in.Middle, in.Back})
+}
+
+// LoadEvaluatorFile reads a SampledEvaluator from a named file. Files
+// ending in .csv or .json are read with ReadWinProbabilitiesCSV or
+// ReadWinProbabilitiesJSON, bundles ending in .cpk with LoadBundle
+// (keeping the evaluator, which values hands with the bundle's scoring,
+// and failing if the bundle's variant doesn't rank hands as the
+// standard game does), and anything else with LoadSampledEvaluator.
+// An evaluator for a variant which discards a card values 13-card
+// hands as any other does; use Bundle.Play to play 14-card deals.
+func LoadEvaluatorFile(filename string) (*SampledEvaluator, error) {
+	read := ReadWinProbabilitiesCSV
+	switch filepath.Ext(filename) {
+	case ".csv":
+	case ".json":
+		read = ReadWinProbabilitiesJSON
+	case ".cpk":
+		b, err := LoadBundle(filename)
+		if err != nil {
+			return nil, err
+		}
+		t, err := TablesFor(b.Variant)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", filename, err)
+		}
+		if t.Checksum() != TableChecksum() {
+			return nil, fmt.Errorf("%s: the evaluator is for variant %q, which ranks hands differently from the standard game", filename, b.Variant.Name)
+		}
+		return b.Evaluator, nil
+	default:
+		return LoadSampledEvaluator(filename)
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	se, err := read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+	return se, nil
+}
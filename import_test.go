@@ -0,0 +1,30 @@
+package cpoker
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadWinProbabilitiesCSV(t *testing.T) {
+	se := smallSampledEvaluator(t, 100)
+	var b bytes.Buffer
+	fmt.Fprintln(&b, "rank,front,middle,back")
+	for r := range se.WinProbabilities(0) {
+		fmt.Fprintf(&b, "%d,%v,%v,%v\n", r, se.WinProbabilities(0)[r], se.WinProbabilities(1)[r], se.WinProbabilities(2)[r])
+	}
+	got, err := ReadWinProbabilitiesCSV(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if !reflect.DeepEqual(got.WinProbabilities(i), se.WinProbabilities(i)) {
+			t.Errorf("slot %d: probabilities differ", i)
+		}
+	}
+	if _, err := ReadWinProbabilitiesCSV(strings.NewReader("rank,front,middle,back\n0,0,0,0\n")); err == nil {
+		t.Errorf("read a CSV file with too few ranks")
+	}
+}
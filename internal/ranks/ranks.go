@@ -0,0 +1,53 @@
+// Package ranks identifies the poker package's rank tables, and counts
+// the hands of each rank. It's separate from cpoker so that gentables,
+// which generates cpoker's hand counts, can be built without them.
+package ranks
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// Checksum identifies the poker package's rank tables: ScoreMax, and
+// which hand each rank stands for.
+func Checksum() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\n", poker.ScoreMax)
+	for e := 0; e <= poker.ScoreMax; e++ {
+		for _, toHand := range []func(int16) ([]poker.Card, bool){poker.EvalToHand3, poker.EvalToHand5} {
+			desc := "-"
+			if c, ok := toHand(int16(e)); ok {
+				desc, _ = poker.Describe(c)
+			}
+			fmt.Fprintf(h, "%s\n", desc)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// Count returns how many hands of the given size (3 or 5) have each
+// rank, by enumerating every hand from one deck.
+func Count(size int) []int {
+	counts := make([]int, poker.ScoreMax+1)
+	var idx [5]int
+	var rec func(i, from int)
+	rec = func(i, from int) {
+		if i == size {
+			if size == 3 {
+				counts[poker.Eval3(&[3]poker.Card{poker.Cards[idx[0]], poker.Cards[idx[1]], poker.Cards[idx[2]]})]++
+			} else {
+				counts[poker.Eval5(&[5]poker.Card{poker.Cards[idx[0]], poker.Cards[idx[1]], poker.Cards[idx[2]], poker.Cards[idx[3]], poker.Cards[idx[4]]})]++
+			}
+			return
+		}
+		for x := from; x < len(poker.Cards); x++ {
+			idx[i] = x
+			rec(i+1, x+1)
+		}
+	}
+	rec(0, 0)
+	return counts
+}
@@ -0,0 +1,48 @@
+package cpoker
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// Latency summarizes how long an evaluator takes to play a hand.
+type Latency struct {
+	Deals int
+	P50   time.Duration
+	P99   time.Duration
+	Max   time.Duration
+}
+
+func (l Latency) String() string {
+	return fmt.Sprintf("%d deals: p50 %s, p99 %s, max %s", l.Deals, l.P50, l.P99, l.Max)
+}
+
+// WarmUp plays n random deals with he, which brings the evaluator's
+// tables into memory, and returns how long each play took.
+func WarmUp(rnd *rand.Rand, he HandEvaluator, n int) Latency {
+	if n <= 0 {
+		return Latency{}
+	}
+	cards := append([]poker.Card{}, poker.Cards...)
+	times := make([]time.Duration, n)
+	for i := range times {
+		for j := 0; j < 13; j++ {
+			k := rnd.Intn(52-j) + j
+			cards[j], cards[k] = cards[k], cards[j]
+		}
+		start := time.Now()
+		Play(cards[:13], he)
+		times[i] = time.Since(start)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	return Latency{
+		Deals: n,
+		P50:   times[n/2],
+		P99:   times[(n*99)/100],
+		Max:   times[n-1],
+	}
+}
@@ -0,0 +1,23 @@
+package cpoker
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestWarmUp(t *testing.T) {
+	if l := WarmUp(rand.New(rand.NewSource(1)), MaxProdEvaluator{}, 0); l != (Latency{}) {
+		t.Errorf("warming up with no deals gave %s, want nothing", l)
+	}
+	l := WarmUp(rand.New(rand.NewSource(1)), MaxProdEvaluator{}, 20)
+	if l.Deals != 20 {
+		t.Errorf("warmed up with %d deals, want 20", l.Deals)
+	}
+	if l.Max <= 0 || l.P50 > l.P99 || l.P99 > l.Max {
+		t.Errorf("got latency %s, want p50 <= p99 <= max, and max > 0", l)
+	}
+	if !strings.HasPrefix(l.String(), "20 deals: p50 ") {
+		t.Errorf("latency is shown as %q", l)
+	}
+}
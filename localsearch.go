@@ -0,0 +1,176 @@
+package cpoker
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// A LocalSearch arranges hands by hill-climbing from random
+// arrangements, swapping one or two cards at a time between the front,
+// middle, back and (if there are more than 13 cards) the unused cards,
+// and restarting from a new arrangement when no swap helps. Unlike
+// Play, it doesn't consider every arrangement, so it can be used for
+// variants whose search spaces are too large to enumerate, at the risk
+// of missing the best hand.
+type LocalSearch struct {
+	Restarts int           // How many random arrangements to climb from, or 0 for 20
+	MaxEvals int           // The most arrangements to evaluate, or 0 for no limit
+	Time     time.Duration // How long to spend, or 0 for no limit
+	Rand     *rand.Rand    // The random source, or nil for the global one
+}
+
+// lsScore is how good an arrangement is: unfouled arrangements are
+// better than fouled ones, and fouled ones are better the less they
+// foul by, so that climbing leads towards legal hands.
+type lsScore struct {
+	foul  int // How far the slots are out of order, or 0 if they aren't
+	value float64
+}
+
+func (s lsScore) better(t lsScore) bool {
+	if s.foul != t.foul {
+		return s.foul < t.foul
+	}
+	return s.value > t.value
+}
+
+// lsGroup returns which part of an arrangement position i is in:
+// 0, 1, 2 for the front, middle and back, and 3 for unused cards.
+func lsGroup(i int) int {
+	switch {
+	case i < 3:
+		return 0
+	case i < 8:
+		return 1
+	case i < 13:
+		return 2
+	}
+	return 3
+}
+
+// Play arranges 13 of the cards c to maximize he's value, and returns
+// the hand and its value. If there are more than 13 cards, the rest
+// aren't used. stats.Hands is how many arrangements were evaluated,
+// and stats.Truncated is set if the budget ran out. It's an error if
+// there are fewer than 13 cards, they aren't distinct, or no legal
+// hand was found.
+func (ls *LocalSearch) Play(c []poker.Card, he HandEvaluator) (Hand, float64, EvalStats, error) {
+	var stats EvalStats
+	if len(c) < 13 {
+		return Hand{}, 0, stats, fmt.Errorf("got %d cards, want at least 13", len(c))
+	}
+	if _, ok := NewCardSet(c); !ok {
+		return Hand{}, 0, stats, fmt.Errorf("cards %v contain an invalid or duplicate card", c)
+	}
+	restarts := ls.Restarts
+	if restarts == 0 {
+		restarts = 20
+	}
+	shuffle := rand.Shuffle
+	if ls.Rand != nil {
+		shuffle = ls.Rand.Shuffle
+	}
+	var deadline time.Time
+	if ls.Time > 0 {
+		deadline = time.Now().Add(ls.Time)
+	}
+	evaluator := he.Evaluator(c)
+	p := append([]poker.Card{}, c...)
+	var h Hand
+	score := func() lsScore {
+		copy(h.Front[:], p[0:3])
+		copy(h.Middle[:], p[3:8])
+		copy(h.Back[:], p[8:13])
+		r := h.ranks()
+		stats.Hands++
+		if (ls.MaxEvals > 0 && stats.Hands >= ls.MaxEvals) || (!deadline.IsZero() && stats.Hands%64 == 0 && time.Now().After(deadline)) {
+			stats.Truncated = true
+		}
+		foul := 0
+		if r[0] > r[1] {
+			foul += int(r[0] - r[1])
+		}
+		if r[1] > r[2] {
+			foul += int(r[1] - r[2])
+		}
+		if foul > 0 {
+			return lsScore{foul: foul}
+		}
+		return lsScore{value: evaluator(r[0], r[1], r[2])}
+	}
+	swapMiddleBack := func() {
+		for i := 3; i < 8; i++ {
+			p[i], p[i+5] = p[i+5], p[i]
+		}
+	}
+	// climb swaps cards, or the whole middle and back, while that
+	// improves the arrangement.
+	climb := func(cur lsScore) lsScore {
+		for improved := true; improved && !stats.Truncated; {
+			improved = false
+			for i := 0; i < 13 && !stats.Truncated; i++ {
+				for j := i + 1; j < len(p) && !stats.Truncated; j++ {
+					if lsGroup(i) == lsGroup(j) {
+						continue
+					}
+					p[i], p[j] = p[j], p[i]
+					if s := score(); s.better(cur) {
+						cur, improved = s, true
+					} else {
+						p[i], p[j] = p[j], p[i]
+					}
+				}
+			}
+			// Swapping two cards for two others moves pairs between
+			// the parts of the hand, which single swaps can't do
+			// without passing through a worse arrangement.
+			for i1 := 0; i1 < 13 && !improved && !stats.Truncated; i1++ {
+				for i2 := i1 + 1; i2 < 13 && lsGroup(i2) == lsGroup(i1) && !improved && !stats.Truncated; i2++ {
+					for j1 := i2 + 1; j1 < len(p) && !improved && !stats.Truncated; j1++ {
+						if lsGroup(j1) == lsGroup(i1) {
+							continue
+						}
+						for j2 := j1 + 1; j2 < len(p) && lsGroup(j2) == lsGroup(j1) && !improved && !stats.Truncated; j2++ {
+							p[i1], p[j1], p[i2], p[j2] = p[j1], p[i1], p[j2], p[i2]
+							if s := score(); s.better(cur) {
+								cur, improved = s, true
+							} else {
+								p[i1], p[j1], p[i2], p[j2] = p[j1], p[i1], p[j2], p[i2]
+							}
+						}
+					}
+				}
+			}
+			if stats.Truncated {
+				break
+			}
+			swapMiddleBack()
+			if s := score(); s.better(cur) {
+				cur, improved = s, true
+			} else {
+				swapMiddleBack()
+			}
+		}
+		return cur
+	}
+	var best Hand
+	bestScore, found := lsScore{}, false
+	for r := 0; r < restarts && !stats.Truncated; r++ {
+		shuffle(len(p), func(i, j int) { p[i], p[j] = p[j], p[i] })
+		cur := climb(score())
+		if cur.foul == 0 && (!found || cur.better(bestScore)) {
+			found, bestScore = true, cur
+			copy(best.Front[:], p[0:3])
+			copy(best.Middle[:], p[3:8])
+			copy(best.Back[:], p[8:13])
+		}
+	}
+	if !found {
+		return Hand{}, 0, stats, errors.New("no legal hand was found")
+	}
+	return best, bestScore.value, stats, nil
+}
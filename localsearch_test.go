@@ -0,0 +1,55 @@
+package cpoker
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestLocalSearch(t *testing.T) {
+	rnd := rand.New(rand.NewSource(10))
+	se := smallSampledEvaluator(t, 1000)
+	ls := &LocalSearch{Restarts: 40, Rand: rnd}
+	for _, he := range []HandEvaluator{MaxProdEvaluator{}, se} {
+		matches := 0
+		for i := 0; i < 20; i++ {
+			c := randomDeal(rnd)
+			h, v, _, err := ls.Play(c, he)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := CheckHand(&h, c); err != nil {
+				t.Fatalf("%v: %s", &h, err)
+			}
+			best, _ := Play(c, he)
+			br := best.ranks()
+			bestV := he.Evaluator(c)(br[0], br[1], br[2])
+			if v > bestV+1e-9 {
+				t.Errorf("local search found %v worth %f, but Play's best is %f", &h, v, bestV)
+			}
+			matches += b2i(v >= bestV-1e-9)
+		}
+		if matches < 15 {
+			t.Errorf("%T: local search found the best hand for %d/20 deals, want at least 15", he, matches)
+		}
+	}
+
+	// With 15 cards, two are left out.
+	c := DealPlayers(rnd, 2)
+	cards := append(c[0], c[1][:2]...)
+	h, _, _, err := ls.Play(cards, se)
+	if err != nil {
+		t.Fatal(err)
+	}
+	used, _ := NewCardSet(h.cards())
+	all, _ := NewCardSet(cards)
+	if used.Len() != 13 || used&^all != 0 {
+		t.Errorf("local search played %v, which isn't 13 of %v", &h, cards)
+	}
+	if r := h.ranks(); r[0] > r[1] || r[1] > r[2] {
+		t.Errorf("local search played fouled hand %v", &h)
+	}
+
+	if _, _, stats, _ := (&LocalSearch{MaxEvals: 100}).Play(c[0], se); !stats.Truncated || stats.Hands != 100 {
+		t.Errorf("with a budget of 100, evaluated %d arrangements (truncated %v)", stats.Hands, stats.Truncated)
+	}
+}
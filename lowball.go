@@ -0,0 +1,223 @@
+package cpoker
+
+import (
+	"sort"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// A lowClass is a class of 3- or 5-card hands that rank the same in
+// lowball: the raw ranks (2->0, ..., A->12) of the cards in increasing
+// order (with any unused ranks 0), and whether they're all one suit.
+type lowClass struct {
+	ranks [5]int
+	flush bool
+}
+
+// key packs the class into an integer, for looking it up.
+func (lc lowClass) key() uint32 {
+	k := uint32(0)
+	for _, r := range lc.ranks {
+		k = k<<4 | uint32(r)
+	}
+	if lc.flush {
+		k |= 1 << 20
+	}
+	return k
+}
+
+// highKey orders classes of hands with size cards by their strength
+// as high hands, larger being stronger. values maps raw ranks to the
+// order of the cards (so that aces can be low), and if straights is
+// false, straights and flushes don't count.
+func (lc lowClass) highKey(values *[13]int, straights bool, size int) uint32 {
+	var counts [13]int
+	for _, r := range lc.ranks[:size] {
+		counts[values[r]]++
+	}
+	// The cards ordered by how many of their rank there are, then by
+	// value.
+	var order []int
+	for _, r := range lc.ranks[:size] {
+		order = append(order, values[r])
+	}
+	sort.Slice(order, func(i, j int) bool {
+		if counts[order[i]] != counts[order[j]] {
+			return counts[order[i]] > counts[order[j]]
+		}
+		return order[i] > order[j]
+	})
+	var groups [5]int
+	for _, n := range counts {
+		groups[n]++
+	}
+	straight := straights && groups[1] == 5 && order[0]-order[4] == 4 // Only 5-card hands have 5 groups of 1
+	flush := straights && lc.flush
+	var class HandCategory
+	switch {
+	case straight && flush:
+		class = StraightFlush
+	case groups[4] == 1:
+		class = Quads
+	case groups[3] == 1 && groups[2] == 1:
+		class = FullHouse
+	case flush:
+		class = Flush
+	case straight:
+		class = Straight
+	case groups[3] == 1:
+		class = Trips
+	case groups[2] == 2:
+		class = TwoPair
+	case groups[2] == 1:
+		class = Pair
+	}
+	k := uint32(class)
+	for _, r := range order {
+		k = k<<4 | uint32(r)
+	}
+	return k
+}
+
+// A lowTable ranks 3- or 5-card hands for a kind of lowball.
+type lowTable struct {
+	size      int     // How many cards the hands have
+	values    [13]int // The order of each raw rank, lowest first
+	straights bool    // Whether straights and flushes count against a hand
+
+	built   tableInit
+	ranks   map[uint32]int16 // from lowClass.key
+	classes []lowClass       // indexed by rank
+}
+
+// init builds the table, the first time it's needed.
+func (lt *lowTable) init() {
+	lt.built.Do(func() {
+		var classes []lowClass
+		var lc lowClass
+		var rec func(i, from int)
+		rec = func(i, from int) {
+			if i == lt.size {
+				classes = append(classes, lc)
+				if lt.straights && lt.size == 5 && lc.ranks[0] < lc.ranks[1] && lc.ranks[1] < lc.ranks[2] && lc.ranks[2] < lc.ranks[3] && lc.ranks[3] < lc.ranks[4] {
+					flush := lc
+					flush.flush = true
+					classes = append(classes, flush)
+				}
+				return
+			}
+			for r := from; r < 13; r++ {
+				if i >= 4 && lc.ranks[i-4] == r {
+					continue // There are only four cards of each rank.
+				}
+				lc.ranks[i] = r
+				rec(i+1, r)
+			}
+		}
+		rec(0, 0)
+		// The best low hand is the weakest high hand, and gets the
+		// largest rank.
+		sort.Slice(classes, func(i, j int) bool {
+			return classes[i].highKey(&lt.values, lt.straights, lt.size) > classes[j].highKey(&lt.values, lt.straights, lt.size)
+		})
+		lt.ranks = map[uint32]int16{}
+		lt.classes = append([]lowClass{{}}, classes...)
+		for i, lc := range classes {
+			lt.ranks[lc.key()] = int16(i + 1)
+		}
+	})
+}
+
+// eval returns the rank of lt.size distinct cards, or 0 if they
+// aren't.
+func (lt *lowTable) eval(c []poker.Card) int16 {
+	if len(c) != lt.size {
+		return 0
+	}
+	if _, ok := NewCardSet(c); !ok {
+		return 0
+	}
+	lt.init()
+	var lc lowClass
+	lc.flush = lt.straights && lt.size == 5
+	for i, ci := range c {
+		lc.ranks[i] = ci.RawRank()
+		if ci.Suit() != c[0].Suit() {
+			lc.flush = false
+		}
+	}
+	sort.Ints(lc.ranks[:lt.size])
+	return lt.ranks[lc.key()]
+}
+
+// toHand returns an example hand with the given rank.
+func (lt *lowTable) toHand(e int16) ([]poker.Card, bool) {
+	lt.init()
+	if e < 1 || int(e) >= len(lt.classes) {
+		return nil, false
+	}
+	lc := lt.classes[e]
+	var h []poker.Card
+	for i, r := range lc.ranks[:lt.size] {
+		// Cards of the same rank are next to each other, so they get
+		// different suits, and only a flush gets a single suit.
+		s := poker.Suit(i % 4)
+		if lc.flush {
+			s = poker.Heart
+		}
+		c, _ := poker.MakeCard(s, poker.Rank(r+1)%13+1)
+		h = append(h, c)
+	}
+	return h, true
+}
+
+// newLowTable returns an unbuilt table of hands with size cards for
+// the given kind of lowball (LowballDeuceToSeven or LowballAceToFive).
+func newLowTable(kind string, size int) *lowTable {
+	if kind == LowballAceToFive {
+		// Aces are low, and straights and flushes don't count.
+		return &lowTable{size: size, values: [13]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 0}}
+	}
+	return &lowTable{size: size, values: [13]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, straights: true}
+}
+
+var (
+	low27 = newLowTable(LowballDeuceToSeven, 5)
+	lowA5 = newLowTable(LowballAceToFive, 5)
+)
+
+// EvalLow27 returns the rank of 5 distinct cards as a deuce-to-seven
+// lowball hand, in which aces are always high, straights and flushes
+// count against the hand, and the best hand is 7-5-4-3-2 of mixed
+// suits. Ranks go from 1 to LowRankMax, and, as with Eval5, larger
+// ranks are better hands. It returns 0 if c isn't 5 distinct cards.
+func EvalLow27(c []poker.Card) int16 {
+	return low27.eval(c)
+}
+
+// LowRankMax is the largest rank EvalLow27 returns.
+const LowRankMax = 7462
+
+// EvalToHandLow27 returns an example hand with the given EvalLow27
+// rank, and whether there is one.
+func EvalToHandLow27(e int16) ([]poker.Card, bool) {
+	return low27.toHand(e)
+}
+
+// EvalLowA5 returns the rank of 5 distinct cards as an ace-to-five
+// lowball hand, in which aces are low, straights and flushes don't
+// count, and the best hand is 5-4-3-2-A. Ranks go from 1 to
+// LowA5RankMax, and larger ranks are better hands. It returns 0 if c
+// isn't 5 distinct cards.
+func EvalLowA5(c []poker.Card) int16 {
+	return lowA5.eval(c)
+}
+
+// LowA5RankMax is the largest rank EvalLowA5 returns.
+const LowA5RankMax = 6175
+
+// EvalToHandLowA5 returns an example hand with the given EvalLowA5
+// rank, and whether there is one.
+func EvalToHandLowA5(e int16) ([]poker.Card, bool) {
+	return lowA5.toHand(e)
+}
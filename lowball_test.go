@@ -0,0 +1,89 @@
+package cpoker
+
+import "testing"
+
+func TestEvalLow27(t *testing.T) {
+	// From best to worst.
+	hands := []string{
+		"C7D5H4S3C2",
+		"C7D6H4S3C2",
+		"C8D5H4S3C2",
+		"CKDQHJC8S2",
+		"CAD5H4S3C2", // Aces are high, and A2345 isn't a straight.
+		"CAHKDQSJC9",
+		"C2D2H4S5C6",
+		"C3D3H2S2C4",
+		"C8D7H6S5C4", // A straight.
+		"H2H3H4H5H7", // A flush.
+		"HAHKHQHJHT",
+	}
+	last := int16(LowRankMax + 1)
+	for _, s := range hands {
+		e := EvalLow27(mustCards(t, s))
+		if e <= 0 || e >= last {
+			t.Errorf("EvalLow27(%s) = %d, want between 1 and %d", s, e, last-1)
+		}
+		last = e
+	}
+	if e := EvalLow27(mustCards(t, "C7D5H4S3C2")); e != LowRankMax {
+		t.Errorf("the best low hand has rank %d, want %d", e, LowRankMax)
+	}
+	for e := int16(1); e <= LowRankMax; e++ {
+		h, ok := EvalToHandLow27(e)
+		if !ok {
+			t.Fatalf("no hand with rank %d", e)
+		}
+		if got := EvalLow27(h); got != e {
+			t.Fatalf("EvalToHandLow27(%d) = %v, which has rank %d", e, h, got)
+		}
+	}
+	if _, ok := EvalToHandLow27(LowRankMax + 1); ok {
+		t.Errorf("found a hand with rank %d", LowRankMax+1)
+	}
+	if e := EvalLow27(mustCards(t, "C7C7H4S3C2")); e != 0 {
+		t.Errorf("EvalLow27 of a repeated card = %d, want 0", e)
+	}
+}
+
+func TestEvalLowA5(t *testing.T) {
+	// From best to worst.
+	hands := []string{
+		"H5H4H3H2HA", // Straights and flushes don't count.
+		"C6D4H3S2CA",
+		"C6D5H4S3C2",
+		"C7D5H4S3C2",
+		"CKDQHJCTS9",
+		"CAD2H2S3C4", // Aces are low.
+		"CKDKHQSJCT",
+		"CAD2H2S3C3",
+		"CAD2H2S2C3",
+		"CAD2H2S2DA",
+		"CKDKHKSKCQ",
+	}
+	last := int16(LowA5RankMax + 1)
+	for _, s := range hands {
+		e := EvalLowA5(mustCards(t, s))
+		if e <= 0 || e >= last {
+			t.Errorf("EvalLowA5(%s) = %d, want between 1 and %d", s, e, last-1)
+		}
+		last = e
+	}
+	if e := EvalLowA5(mustCards(t, "H5H4H3H2HA")); e != LowA5RankMax {
+		t.Errorf("the best low hand has rank %d, want %d", e, LowA5RankMax)
+	}
+	if a, b := EvalLowA5(mustCards(t, "H9H7H4H3H2")), EvalLowA5(mustCards(t, "S9H7H4H3H2")); a != b {
+		t.Errorf("a flush has rank %d, and the same ranks unsuited have %d", a, b)
+	}
+	for e := int16(1); e <= LowA5RankMax; e++ {
+		h, ok := EvalToHandLowA5(e)
+		if !ok {
+			t.Fatalf("no hand with rank %d", e)
+		}
+		if got := EvalLowA5(h); got != e {
+			t.Fatalf("EvalToHandLowA5(%d) = %v, which has rank %d", e, h, got)
+		}
+	}
+	if _, ok := EvalToHandLowA5(LowA5RankMax + 1); ok {
+		t.Errorf("found a hand with rank %d", LowA5RankMax+1)
+	}
+}
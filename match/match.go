@@ -0,0 +1,197 @@
+// Binary match plays two engine processes against each other,
+// using the engine protocol. Each deal is played twice, with the
+// engines swapping cards, to reduce the effect of luck.
+// For example:
+//
+//	match -a "engine -from coefficients.data" -b "engine -from other.data" -hands 1000
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/paulhankin/cpoker"
+	"github.com/paulhankin/cpoker/client"
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+var (
+	engineA   = flag.String("a", "", "command line to run the first engine")
+	engineB   = flag.String("b", "", "command line to run the second engine")
+	hands     = flag.Int("hands", 100, "how many deals to play (each is played twice)")
+	moveTime  = flag.Duration("move_time", 10*time.Second, "how long an engine may take to play a hand")
+	startTime = flag.Duration("start_time", 2*time.Minute, "how long an engine may take to start up")
+	ratings   = flag.String("ratings", "", "if set, a file of ratings to update with the result of the match")
+	glicko    = flag.Bool("glicko", false, "with -ratings, the ratings are Glicko ratings rather than Elo ratings")
+	scoring   = flag.String("scoring", "2-4", "how to score hands: "+strings.Join(cpoker.ScoringNames(), ", "))
+	outSpec   = flag.String("out", "", "if set, write a record of each hand and the result to this output, which looks like jsonl://path")
+	surrender = flag.Int("surrender", 0, "the points an engine pays its opponent for passing a deal")
+)
+
+var out *cpoker.RecordWriter
+
+// writeRecord writes a record to the -out output, if there is one.
+func writeRecord(typ string, v interface{}) {
+	if err := out.Write(typ, v); err != nil {
+		log.Fatalf("failed to write output: %s", err)
+	}
+}
+
+// A handRecord is the outcome of one hand of the match. The hands
+// are omitted if an engine failed, and an engine's hand is omitted if
+// it passed.
+type handRecord struct {
+	Hand    int          `json:"hand"`
+	A       *cpoker.Hand `json:"a,omitempty"`
+	B       *cpoker.Hand `json:"b,omitempty"`
+	APassed bool         `json:"a_passed,omitempty"`
+	BPassed bool         `json:"b_passed,omitempty"`
+	Score   int          `json:"score"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// passScore scores a hand in which one or both engines passed. An engine
+// which passes pays its opponent the surrender, and there's no showdown.
+func passScore(s *cpoker.Scoring, passA, passB bool) int {
+	score := 0
+	if passA {
+		score -= s.Surrender
+	}
+	if passB {
+		score += s.Surrender
+	}
+	return score
+}
+
+// adjudicate scores a showdown in which one or both engines failed.
+// A failing engine loses every slot, and earns no royalties.
+func adjudicate(s *cpoker.Scoring, errA, errB error) int {
+	forfeit := 3*s.Slot + s.Majority + s.Scoop
+	if errA != nil && errB != nil {
+		return 0
+	} else if errA != nil {
+		return -forfeit
+	}
+	return forfeit
+}
+
+func main() {
+	flag.Parse()
+	preset, err := cpoker.ScoringByName(*scoring)
+	if err != nil {
+		log.Fatalf("bad -scoring: %s", err)
+	}
+	sc := *preset
+	sc.Surrender = *surrender
+	if *outSpec != "" {
+		if out, err = cpoker.OpenOutput(*outSpec); err != nil {
+			log.Fatalf("failed to open -out: %s", err)
+		}
+		defer out.Close()
+	}
+	a, err := client.Start(*engineA, *startTime)
+	if err != nil {
+		log.Fatalf("failed to start engine a: %s", err)
+	}
+	defer a.Close()
+	b, err := client.Start(*engineB, *startTime)
+	if err != nil {
+		log.Fatalf("failed to start engine b: %s", err)
+	}
+	defer b.Close()
+	a.Timeout, b.Timeout = *moveTime, *moveTime
+	if *ratings != "" && a.Name == b.Name {
+		log.Fatalf("both engines are named %q, so the match can't be rated; start them with different names", a.Name)
+	}
+	log.Printf("%s vs %s", a.Name, b.Name)
+	total, played := 0, 0
+	for hand := 0; hand < *hands; hand++ {
+		cards := cpoker.DealPlayers(nil, 2)
+		for _, deal := range [][2][]poker.Card{{cards[0], cards[1]}, {cards[1], cards[0]}} {
+			ha, errA := a.Play(deal[0], client.Options{})
+			hb, errB := b.Play(deal[1], client.Options{})
+			// Passing isn't a failure.
+			passA, passB := errA == cpoker.ErrPassed, errB == cpoker.ErrPassed
+			if passA {
+				errA = nil
+			}
+			if passB {
+				errB = nil
+			}
+			score := 0
+			switch {
+			case errA != nil || errB != nil:
+				score = adjudicate(&sc, errA, errB)
+			case passA || passB:
+				score = passScore(&sc, passA, passB)
+			default:
+				score = sc.Score(&ha, &hb)
+			}
+			total += score
+			hr := handRecord{Hand: played, APassed: passA, BPassed: passB, Score: score}
+			if errA != nil || errB != nil {
+				hr.Error = fmt.Sprintf("a: %v, b: %v", errA, errB)
+			} else {
+				if !passA {
+					hr.A = &ha
+				}
+				if !passB {
+					hr.B = &hb
+				}
+			}
+			writeRecord("hand", hr)
+			played++
+			if errA != nil || errB != nil {
+				log.Printf("adjudicated hand %d (a: %v, b: %v), stopping match", played, errA, errB)
+				report(a.Name, b.Name, total, played)
+				return
+			}
+		}
+	}
+	report(a.Name, b.Name, total, played)
+}
+
+func report(a, b string, total, played int) {
+	perHand := 0.0
+	if played > 0 {
+		perHand = float64(total) / float64(played)
+	}
+	fmt.Printf("%s vs %s: %+d over %d hands (%.4f per hand)\n", a, b, total, played, perHand)
+	writeRecord("result", struct {
+		A       string  `json:"a"`
+		B       string  `json:"b"`
+		Total   int     `json:"total"`
+		Played  int     `json:"played"`
+		PerHand float64 `json:"per_hand"`
+	}{a, b, total, played, perHand})
+	if *ratings == "" || played == 0 {
+		return
+	}
+	r, err := cpoker.LoadRatings(*ratings)
+	if err != nil {
+		log.Fatalf("failed to load ratings: %s", err)
+	}
+	if len(r.Players) == 0 && *glicko {
+		r = cpoker.NewGlickoRatings()
+	}
+	if r.Glicko && !*glicko {
+		log.Fatalf("the ratings in %s are Glicko ratings, so -glicko must be set", *ratings)
+	} else if !r.Glicko && *glicko {
+		log.Fatalf("the ratings in %s are Elo ratings, so -glicko can't be set", *ratings)
+	}
+	r.Update(a, b, cpoker.MatchResult(float64(total)))
+	if err := r.Save(*ratings); err != nil {
+		log.Fatalf("failed to save ratings: %s", err)
+	}
+	for _, n := range r.Names() {
+		p := r.Players[n]
+		if r.Glicko {
+			fmt.Printf("%8.1f ±%5.1f %5d %s\n", p.Elo, 2*p.RD, p.Games, n)
+		} else {
+			fmt.Printf("%8.1f %5d %s\n", p.Elo, p.Games, n)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package cpoker
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// A MetricRecord is one measurement from a training or comparison run.
+// Metrics files contain one JSON-encoded MetricRecord per line.
+type MetricRecord struct {
+	Time   time.Time `json:"time"`
+	Run    string    `json:"run"`              // The name of the run
+	Metric string    `json:"metric"`           // What's measured, for example "ev" or "probe_changes"
+	Step   int       `json:"step"`             // The training cycle, or number of hands played
+	Value  float64   `json:"value"`            // The measurement
+	StdErr float64   `json:"stderr,omitempty"` // The standard error of the measurement, if known
+}
+
+// A MetricsWriter appends records to a metrics file.
+type MetricsWriter struct {
+	f   *os.File
+	enc *json.Encoder
+}
+
+// OpenMetrics opens a named metrics file for appending, creating
+// it if necessary.
+func OpenMetrics(filename string) (*MetricsWriter, error) {
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &MetricsWriter{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+// Write appends a record. If its time isn't set, the current time is used.
+func (mw *MetricsWriter) Write(r MetricRecord) error {
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	return mw.enc.Encode(r)
+}
+
+// Close closes the metrics file.
+func (mw *MetricsWriter) Close() error {
+	return mw.f.Close()
+}
+
+// ReadMetrics reads the records of a metrics file. A partly written
+// last line (from a run that's still writing) is ignored, but any other
+// line that isn't a record is an error.
+func ReadMetrics(r io.Reader) ([]MetricRecord, error) {
+	var recs []MetricRecord
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		b, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if len(b) == 0 {
+			return recs, nil
+		}
+		var rec MetricRecord
+		if jerr := json.Unmarshal(b, &rec); jerr != nil {
+			if err == io.EOF {
+				return recs, nil // A partly written last line
+			}
+			return nil, fmt.Errorf("line %d: %s", line, jerr)
+		}
+		recs = append(recs, rec)
+		if err == io.EOF {
+			return recs, nil
+		}
+	}
+}
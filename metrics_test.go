@@ -0,0 +1,63 @@
+package cpoker
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMetricsRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fn := filepath.Join(dir, "m.jsonl")
+	want := []MetricRecord{
+		{Run: "a", Metric: "ev", Step: 100, Value: 0.25, StdErr: 0.1},
+		{Run: "a", Metric: "probe_changes", Step: 1, Value: 0.5},
+	}
+	for _, r := range want {
+		mw, err := OpenMetrics(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := mw.Write(r); err != nil {
+			t.Fatal(err)
+		}
+		mw.Close()
+	}
+	f, err := os.OpenFile(fn, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"run":"a","met`) // a partly written record
+	f.Close()
+	f, err = os.Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err := ReadMetrics(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d records, want %d", len(got), len(want))
+	}
+	for i := range got {
+		if got[i].Time.IsZero() {
+			t.Errorf("record %d has no time", i)
+		}
+		got[i].Time = want[i].Time
+		if got[i] != want[i] {
+			t.Errorf("record %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	bad := "{\"run\":\"a\",\"metric\":\"ev\"}\nnot a record\n{\"run\":\"a\"}\n"
+	if _, err := ReadMetrics(strings.NewReader(bad)); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("reading a malformed line gave error %v, want an error for line 2", err)
+	}
+}
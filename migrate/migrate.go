@@ -0,0 +1,103 @@
+// Binary migrate converts a coefficients file in the legacy text
+// format (without a table header or checksum) to the current format,
+// or to a .cpk bundle if the output's name ends in .cpk. It checks the
+// conversion by playing the deals of the benchmark train uses to choose
+// checkpoints (see cpoker.NewBenchmarkProbeSet) with the evaluators
+// before and after. The output is written to a temporary file, which
+// only replaces -to if every deal is played the same, so -to can be the
+// same file as -from. For example:
+//
+//	migrate -from old.data -to coefficients.cpk -scoring hk
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paulhankin/cpoker"
+)
+
+var (
+	fromFile = flag.String("from", "", "the coefficients file to convert")
+	toFile   = flag.String("to", "", "the file to write; if it ends in .cpk, a bundle is written")
+	scoring  = flag.String("scoring", "2-4", "with a .cpk output, the scoring the evaluator was trained for: "+strings.Join(cpoker.ScoringNames(), ", "))
+	variant  = flag.String("variant", "standard", "with a .cpk output, the name of the variant the evaluator was trained for")
+	deals    = flag.Int("benchmark_hands", 1000, "how many hands of the benchmark to check the conversion with; each is two deals")
+)
+
+func main() {
+	flag.Parse()
+	if *fromFile == "" || *toFile == "" {
+		log.Fatalf("-from and -to must be specified")
+	}
+	sc, err := cpoker.ScoringByName(*scoring)
+	if err != nil {
+		log.Fatalf("bad -scoring: %s", err)
+	}
+	old, err := cpoker.LoadEvaluatorFile(*fromFile)
+	if err != nil {
+		log.Fatalf("failed to load evaluator: %s", err)
+	}
+	save := saver(old, sc, *toFile)
+	// The temporary file has the same extension as -to, so that it's
+	// read back in the same format.
+	f, err := ioutil.TempFile(filepath.Dir(*toFile), ".migrate-*"+filepath.Ext(*toFile))
+	if err != nil {
+		log.Fatalf("failed to create a temporary file: %s", err)
+	}
+	tmp := f.Name()
+	f.Close()
+	n, err := migrate(old, save, tmp)
+	if err != nil {
+		os.Remove(tmp)
+		log.Fatal(err)
+	}
+	if err := os.Rename(tmp, *toFile); err != nil {
+		os.Remove(tmp)
+		log.Fatalf("failed to replace %s: %s", *toFile, err)
+	}
+	log.Printf("wrote %s, which plays all %d deals of the benchmark the same as %s", *toFile, n, *fromFile)
+}
+
+// saver returns how old is to be written to the file to. If to is a
+// bundle, it's saved with the scoring sc, and old is set to play under
+// sc too, so that it's compared with the bundle it's read back as
+// under the same scoring.
+func saver(old *cpoker.SampledEvaluator, sc *cpoker.Scoring, to string) func(string) error {
+	if !strings.HasSuffix(to, ".cpk") {
+		return old.Save
+	}
+	old.Scoring = sc
+	b := &cpoker.Bundle{
+		Evaluator: old,
+		Scoring:   sc,
+		Variant:   cpoker.VariantConfig{Name: *variant},
+		Metadata:  map[string]string{"migrated_from": *fromFile},
+	}
+	return b.Save
+}
+
+// migrate writes old to the file tmp with save, reads it back, and
+// checks it plays the benchmark's deals as old does. It returns how
+// many deals were checked.
+func migrate(old *cpoker.SampledEvaluator, save func(string) error, tmp string) (int, error) {
+	if err := save(tmp); err != nil {
+		return 0, fmt.Errorf("failed to write %s: %s", *toFile, err)
+	}
+	migrated, err := cpoker.LoadEvaluatorFile(tmp)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read back %s: %s", *toFile, err)
+	}
+	ps := cpoker.NewBenchmarkProbeSet(*deals)
+	ps.Update(old)
+	if changes := ps.UpdateChanges(migrated); len(changes) > 0 {
+		cpoker.WriteChanges(os.Stderr, changes)
+		return 0, fmt.Errorf("%d of %d deals are played differently after the conversion, so %s wasn't written", len(changes), len(ps.Deals), *toFile)
+	}
+	return len(ps.Deals), nil
+}
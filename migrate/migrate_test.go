@@ -0,0 +1,46 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paulhankin/cpoker"
+)
+
+func TestMigrateScoring(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(d int) { *deals = d }(*deals)
+	*deals = 50
+	for _, name := range []string{"2-4", "hk"} {
+		sc, err := cpoker.ScoringByName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		old, err := cpoker.LoadEvaluatorFile("../coefficients.data")
+		if err != nil {
+			t.Fatal(err)
+		}
+		to := filepath.Join(dir, name+".cpk")
+		n, err := migrate(old, saver(old, sc, to), to)
+		if err != nil {
+			t.Errorf("migrate with scoring %s: %s", name, err)
+			continue
+		}
+		if n != 2**deals {
+			t.Errorf("migrate with scoring %s checked %d deals, want %d", name, n, 2**deals)
+		}
+		b, err := cpoker.LoadBundle(to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.Scoring.Name != name {
+			t.Errorf("migrated bundle has scoring %s, want %s", b.Scoring.Name, name)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package cpoker
+
+import (
+	"math"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// An Objective scores a legal arrangement of 13 cards, given the
+// cards in each slot and their ranks. It's a lighter-weight
+// alternative to a HandEvaluator for experimenting with unusual goals,
+// for example maximizing royalties or playing a particular style.
+type Objective func(front [3]poker.Card, middle, back [5]poker.Card, ef, em, eb int16) float64
+
+// PlayObjective takes 13 cards and returns the legal arrangement for
+// which obj is largest. Unlike Play, it doesn't assume that stronger
+// hands are better, so it considers every legal arrangement, which
+// is much slower. Like Play, it skips arrangements whose front isn't
+// weaker than the middle, or whose middle and back are equal.
+func PlayObjective(c []poker.Card, obj Objective) (Hand, EvalStats) {
+	stats := EvalStats{}
+	best, bestV := Hand{}, math.Inf(-1)
+	fIdx := [3]int{-1, 1, 2}
+	for next3(&fIdx) {
+		front := [3]poker.Card{c[fIdx[0]], c[fIdx[1]], c[fIdx[2]]}
+		ef := poker.Eval3(&front)
+		bIdx := [5]int{-1, -1, 1, 2, 3}
+		for next4(&bIdx) {
+			back, middle := split(c, &fIdx, &bIdx)
+			eb := poker.Eval5(&back)
+			em := poker.Eval5(&middle)
+			if em > eb {
+				em, eb = eb, em
+				middle, back = back, middle
+			}
+			if ef >= em {
+				stats.StrongFront++
+				continue
+			}
+			if em == eb {
+				stats.BackEqualsMiddle++
+				continue
+			}
+			stats.Hands++
+			if v := obj(front, middle, back, ef, em, eb); v >= bestV {
+				bestV = v
+				best = Hand{Front: front, Middle: middle, Back: back}
+			}
+		}
+	}
+	return best, stats
+}
+
+// EvaluatorObjective returns an Objective which scores arrangements of
+// c as he does.
+func EvaluatorObjective(c []poker.Card, he HandEvaluator) Objective {
+	ev := he.Evaluator(c)
+	return func(_ [3]poker.Card, _, _ [5]poker.Card, ef, em, eb int16) float64 {
+		return ev(ef, em, eb)
+	}
+}
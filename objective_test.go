@@ -0,0 +1,35 @@
+package cpoker
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+func TestPlayObjective(t *testing.T) {
+	rnd := rand.New(rand.NewSource(3))
+	for i := 0; i < 5; i++ {
+		c := randomDeal(rnd)
+		h, _ := Play(c, MaxProdEvaluator{})
+		ho, _ := PlayObjective(c, EvaluatorObjective(c, MaxProdEvaluator{}))
+		if err := CheckHand(&ho, c); err != nil {
+			t.Fatalf("PlayObjective(%v) = %v: %s", c, &ho, err)
+		}
+		if h.ranks() != ho.ranks() {
+			t.Errorf("PlayObjective(%v) = %v, want the same ranks as Play's %v", c, &ho, &h)
+		}
+		// Minimizing the strength of the back hand gives a legal hand
+		// whose back is no stronger than the one Play chooses.
+		weak, _ := PlayObjective(c, func(_ [3]poker.Card, _, _ [5]poker.Card, _, _, eb int16) float64 { return -float64(eb) })
+		if err := CheckHand(&weak, c); err != nil {
+			t.Fatalf("PlayObjective(%v) = %v: %s", c, &weak, err)
+		}
+		if weak.ranks()[2] > h.ranks()[2] {
+			t.Errorf("PlayObjective(%v) with a weak back = %v, stronger than %v", c, &weak, &h)
+		}
+		if r := weak.ranks(); r[0] >= r[1] || r[1] == r[2] {
+			t.Errorf("PlayObjective(%v) with a weak back = %v, an arrangement Play skips", c, &weak)
+		}
+	}
+}
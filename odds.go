@@ -0,0 +1,71 @@
+package cpoker
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// Odds are a hand's chances at showdown.
+type Odds struct {
+	Samples int        `json:"samples"` // How many deals the odds were estimated from
+	Win     [3]float64 `json:"win"`     // The probability of beating every opponent in the front, middle and back
+	Scoop   float64    `json:"scoop"`   // The probability of beating every opponent in every slot
+}
+
+func (o *Odds) String() string {
+	return fmt.Sprintf("front %.1f%%, middle %.1f%%, back %.1f%%, scoop %.1f%% (%d samples)",
+		100*o.Win[0], 100*o.Win[1], 100*o.Win[2], 100*o.Scoop, o.Samples)
+}
+
+// ShowdownOdds estimates hero's odds against nOpponents opponents,
+// each dealt 13 random cards from the rest of the deck, and playing
+// them as he does. Ties aren't wins. It's an error if hero is fouled,
+// or nOpponents isn't between 1 and 3.
+func ShowdownOdds(hero *Hand, nOpponents int, he HandEvaluator, samples int) (Odds, error) {
+	return showdownOdds(nil, hero, nOpponents, he, samples)
+}
+
+// showdownOdds is ShowdownOdds, dealing the opponents' cards from rnd
+// (or the global source if rnd is nil).
+func showdownOdds(rnd *rand.Rand, hero *Hand, nOpponents int, he HandEvaluator, samples int) (Odds, error) {
+	if nOpponents < 1 || nOpponents > 3 {
+		return Odds{}, errors.New("there must be between 1 and 3 opponents")
+	}
+	r := hero.ranks()
+	if r[0] > r[1] || r[1] > r[2] {
+		return Odds{}, fmt.Errorf("hand %s is fouled", hero)
+	}
+	fixed := make([][]poker.Card, nOpponents+1)
+	fixed[0] = hero.cards()
+	var wins [3]int
+	scoops := 0
+	for i := 0; i < samples; i++ {
+		hands, err := DealConstrained(rnd, fixed)
+		if err != nil {
+			return Odds{}, err
+		}
+		won := [3]bool{true, true, true}
+		for _, c := range hands[1:] {
+			h, _ := Play(c, he)
+			or := h.ranks()
+			for j := range won {
+				won[j] = won[j] && r[j] > or[j]
+			}
+		}
+		for j, w := range won {
+			wins[j] += b2i(w)
+		}
+		scoops += b2i(won[0] && won[1] && won[2])
+	}
+	odds := Odds{Samples: samples}
+	if samples > 0 {
+		for j := range wins {
+			odds.Win[j] = float64(wins[j]) / float64(samples)
+		}
+		odds.Scoop = float64(scoops) / float64(samples)
+	}
+	return odds, nil
+}
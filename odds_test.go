@@ -0,0 +1,31 @@
+package cpoker
+
+import "testing"
+
+func TestShowdownOdds(t *testing.T) {
+	strong := mustHand(t, "HASACK", "C2D2H2S2C3", "HTHJHQHKH9")
+	weak := mustHand(t, "C4S5H6", "C8D8H9SJCQ", "D3D4D5D6C7")
+	so, err := ShowdownOdds(strong, 1, MaxProdEvaluator{}, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wo, err := ShowdownOdds(weak, 3, MaxProdEvaluator{}, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if so.Samples != 50 || so.Scoop < 0.9 {
+		t.Errorf("odds for %v are %v, want a near-certain scoop", strong, &so)
+	}
+	for i := range wo.Win {
+		if wo.Scoop > wo.Win[i] || wo.Win[i] >= so.Win[i] {
+			t.Errorf("odds for %v are %v, and for %v are %v", weak, &wo, strong, &so)
+		}
+	}
+	fouled := mustHand(t, "HASACK", "D3D4D5D6C7", "C8D8H9SJCQ")
+	if _, err := ShowdownOdds(fouled, 1, MaxProdEvaluator{}, 10); err == nil {
+		t.Errorf("got odds for fouled hand %v", fouled)
+	}
+	if _, err := ShowdownOdds(strong, 4, MaxProdEvaluator{}, 10); err == nil {
+		t.Errorf("got odds against 4 opponents")
+	}
+}
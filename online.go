@@ -0,0 +1,82 @@
+package cpoker
+
+import (
+	"errors"
+	"math"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// An OnlineEvaluator is a SampledEvaluator which slowly adapts to the
+// hands it sees opponents play. Each observed hand moves the win
+// probabilities towards the observed distribution of the opponents'
+// ranks, by a fraction Rate. To stop a few unusual hands (or a
+// deliberate attempt to mislead it) from ruining the evaluator, the win
+// probabilities never move more than MaxDrift from the base evaluator's.
+//
+// An OnlineEvaluator isn't safe for concurrent use.
+type OnlineEvaluator struct {
+	base     *SampledEvaluator
+	current  *SampledEvaluator
+	Rate     float64
+	MaxDrift float64
+	observed int
+}
+
+// NewOnlineEvaluator returns an OnlineEvaluator which starts with the
+// win probabilities of base.
+func NewOnlineEvaluator(base *SampledEvaluator, rate, maxDrift float64) (*OnlineEvaluator, error) {
+	if rate <= 0 || rate >= 1 {
+		return nil, errors.New("rate must be between 0 and 1")
+	}
+	if maxDrift < 0 {
+		return nil, errors.New("maxDrift must not be negative")
+	}
+	cur := &SampledEvaluator{Scoring: base.Scoring}
+	for i := 0; i < 3; i++ {
+		cur.wins[i] = append([]float64{}, base.wins[i]...)
+	}
+	return &OnlineEvaluator{base: base, current: cur, Rate: rate, MaxDrift: maxDrift}, nil
+}
+
+// Evaluator returns a hand evaluator using the current win probabilities.
+func (oe *OnlineEvaluator) Evaluator(cs []poker.Card) func(f, m, b int16) float64 {
+	return oe.current.Evaluator(cs)
+}
+
+// Points returns the expected points for a hand, scored with s, using
+// the current win probabilities. See SampledEvaluator.Points.
+func (oe *OnlineEvaluator) Points(c []poker.Card, s *Scoring) func(f, m, b int16) float64 {
+	return oe.current.Points(c, s)
+}
+
+// Observe updates the win probabilities with a hand played by an opponent.
+func (oe *OnlineEvaluator) Observe(h *Hand) {
+	for i, r := range h.ranks() {
+		wins, base := oe.current.wins[i], oe.base.wins[i]
+		for e := range wins {
+			target := 0.0
+			if int(r) <= e {
+				target = 1
+			}
+			p := wins[e] + oe.Rate*(target-wins[e])
+			wins[e] = math.Max(base[e]-oe.MaxDrift, math.Min(base[e]+oe.MaxDrift, p))
+		}
+	}
+	oe.observed++
+}
+
+// Observed returns how many hands have been observed.
+func (oe *OnlineEvaluator) Observed() int {
+	return oe.observed
+}
+
+// Snapshot returns a SampledEvaluator with the current win
+// probabilities, which can be saved.
+func (oe *OnlineEvaluator) Snapshot() *SampledEvaluator {
+	r := &SampledEvaluator{Scoring: oe.current.Scoring}
+	for i := 0; i < 3; i++ {
+		r.wins[i] = append([]float64{}, oe.current.wins[i]...)
+	}
+	return r
+}
@@ -0,0 +1,51 @@
+package cpoker
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestOnlineEvaluator(t *testing.T) {
+	base := smallSampledEvaluator(t, 200)
+	oe, err := NewOnlineEvaluator(base, 0.1, 0.05)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rnd := rand.New(rand.NewSource(6))
+	for i := 0; i < 100; i++ {
+		h, _ := Play(randomDeal(rnd), MaxProdEvaluator{})
+		oe.Observe(&h)
+	}
+	if oe.Observed() != 100 {
+		t.Errorf("Observed() = %d, want 100", oe.Observed())
+	}
+	snap := oe.Snapshot()
+	moved := false
+	for i := 0; i < 3; i++ {
+		bw, sw := base.WinProbabilities(i), snap.WinProbabilities(i)
+		for e := range sw {
+			if d := sw[e] - bw[e]; d > 0.05+1e-9 || d < -0.05-1e-9 {
+				t.Fatalf("slot %d rank %d moved from %v to %v, more than the maximum drift", i, e, bw[e], sw[e])
+			} else if d != 0 {
+				moved = true
+			}
+			if e > 0 && sw[e] < sw[e-1] {
+				t.Fatalf("slot %d: win probability decreases from rank %d to %d", i, e-1, e)
+			}
+		}
+	}
+	if !moved {
+		t.Errorf("no win probabilities changed")
+	}
+	// The points are those of the current win probabilities, so
+	// -surrender can be used with -learn_rate.
+	var ep EvaluatorPoints = oe
+	c := randomDeal(rnd)
+	a := Arrangements(c)[0].Ranks
+	if got, want := ep.Points(c, ScoringHK)(a[0], a[1], a[2]), snap.Points(c, ScoringHK)(a[0], a[1], a[2]); got != want {
+		t.Errorf("Points = %f, want the snapshot's %f", got, want)
+	}
+	if _, err := NewOnlineEvaluator(base, 0, 0.05); err == nil {
+		t.Errorf("NewOnlineEvaluator with zero rate succeeded")
+	}
+}
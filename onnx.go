@@ -0,0 +1,473 @@
+package cpoker
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+)
+
+// This file contains a small, dependency-free reader for ONNX models,
+// supporting the operators needed for multi-layer perceptrons:
+// Gemm, MatMul, Add, Relu, Sigmoid, Tanh and Identity, on float
+// tensors of at most two dimensions. It's enough to serve models
+// trained in Python on features from a Featurizer.
+
+// A tensor is a float tensor with at most two dimensions.
+type tensor struct {
+	dims []int
+	data []float64
+}
+
+// rowsCols returns the shape of t as a matrix. Vectors are rows.
+func (t *tensor) rowsCols() (int, int) {
+	switch len(t.dims) {
+	case 0:
+		return 1, 1
+	case 1:
+		return 1, t.dims[0]
+	}
+	return t.dims[0], t.dims[1]
+}
+
+type onnxNode struct {
+	op      string
+	inputs  []string
+	outputs []string
+	ints    map[string]int
+	floats  map[string]float64
+	args    []onnxArg // The inputs, resolved by compile
+	out     int       // The slot of the output, set by compile
+}
+
+// An onnxArg is an input of a node: either a constant, or the slot
+// of a value computed when the graph is run (-1 for an optional input
+// that's missing).
+type onnxArg struct {
+	konst *tensor
+	slot  int
+}
+
+type onnxGraph struct {
+	nodes  []onnxNode
+	consts map[string]*tensor
+	input  string
+	output string
+	slots  int // The number of values computed when the graph is run, including the input in slot 0
+	out    int // The slot of the output
+}
+
+// protoField is a single field of an encoded protocol buffer message.
+type protoField struct {
+	num   int
+	wire  int
+	value uint64 // for varint and fixed fields
+	bytes []byte // for length-delimited fields
+}
+
+func parseProto(b []byte) ([]protoField, error) {
+	var r []protoField
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, errors.New("bad protobuf key")
+		}
+		b = b[n:]
+		f := protoField{num: int(key >> 3), wire: int(key & 7)}
+		switch f.wire {
+		case 0:
+			f.value, n = binary.Uvarint(b)
+			if n <= 0 {
+				return nil, errors.New("bad protobuf varint")
+			}
+			b = b[n:]
+		case 1:
+			if len(b) < 8 {
+				return nil, errors.New("short protobuf fixed64")
+			}
+			f.value, b = binary.LittleEndian.Uint64(b), b[8:]
+		case 2:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < l {
+				return nil, errors.New("bad protobuf length")
+			}
+			f.bytes, b = b[n:n+int(l)], b[n+int(l):]
+		case 5:
+			if len(b) < 4 {
+				return nil, errors.New("short protobuf fixed32")
+			}
+			f.value, b = uint64(binary.LittleEndian.Uint32(b)), b[4:]
+		default:
+			return nil, fmt.Errorf("unsupported protobuf wire type %d", f.wire)
+		}
+		r = append(r, f)
+	}
+	return r, nil
+}
+
+// ints returns the values of a repeated integer field, packed or not.
+func (f *protoField) ints() ([]uint64, error) {
+	if f.wire == 0 {
+		return []uint64{f.value}, nil
+	}
+	var r []uint64
+	for b := f.bytes; len(b) > 0; {
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, errors.New("bad packed varint")
+		}
+		r, b = append(r, v), b[n:]
+	}
+	return r, nil
+}
+
+// floats returns the values of a repeated float field, packed or not.
+func (f *protoField) floats() []float64 {
+	if f.wire == 5 {
+		return []float64{float64(math.Float32frombits(uint32(f.value)))}
+	}
+	var r []float64
+	for b := f.bytes; len(b) >= 4; b = b[4:] {
+		r = append(r, float64(math.Float32frombits(binary.LittleEndian.Uint32(b))))
+	}
+	return r
+}
+
+const onnxFloat = 1 // TensorProto.FLOAT
+
+func parseTensor(b []byte) (string, *tensor, error) {
+	fields, err := parseProto(b)
+	if err != nil {
+		return "", nil, err
+	}
+	var name string
+	t := &tensor{}
+	dataType := uint64(onnxFloat)
+	for i := range fields {
+		f := &fields[i]
+		switch f.num {
+		case 1:
+			dims, err := f.ints()
+			if err != nil {
+				return "", nil, err
+			}
+			for _, d := range dims {
+				t.dims = append(t.dims, int(d))
+			}
+		case 2:
+			dataType = f.value
+		case 4:
+			t.data = append(t.data, f.floats()...)
+		case 8:
+			name = string(f.bytes)
+		case 9:
+			t.data = (&protoField{wire: 2, bytes: f.bytes}).floats()
+		}
+	}
+	if dataType != onnxFloat {
+		return "", nil, fmt.Errorf("tensor %q: unsupported data type %d", name, dataType)
+	}
+	if len(t.dims) > 2 {
+		return "", nil, fmt.Errorf("tensor %q: too many dimensions", name)
+	}
+	if r, c := t.rowsCols(); r*c != len(t.data) {
+		return "", nil, fmt.Errorf("tensor %q: has %d values, want %d", name, len(t.data), r*c)
+	}
+	return name, t, nil
+}
+
+func parseNode(b []byte) (onnxNode, error) {
+	n := onnxNode{ints: map[string]int{}, floats: map[string]float64{}}
+	fields, err := parseProto(b)
+	if err != nil {
+		return n, err
+	}
+	for _, f := range fields {
+		switch f.num {
+		case 1:
+			n.inputs = append(n.inputs, string(f.bytes))
+		case 2:
+			n.outputs = append(n.outputs, string(f.bytes))
+		case 4:
+			n.op = string(f.bytes)
+		case 5:
+			attr, err := parseProto(f.bytes)
+			if err != nil {
+				return n, err
+			}
+			var name string
+			for _, a := range attr {
+				if a.num == 1 {
+					name = string(a.bytes)
+				}
+			}
+			for _, a := range attr {
+				if a.num == 2 {
+					n.floats[name] = float64(math.Float32frombits(uint32(a.value)))
+				} else if a.num == 3 {
+					n.ints[name] = int(int64(a.value))
+				}
+			}
+		}
+	}
+	return n, nil
+}
+
+// valueName returns the name of a ValueInfoProto.
+func valueName(b []byte) (string, error) {
+	fields, err := parseProto(b)
+	if err != nil {
+		return "", err
+	}
+	for _, f := range fields {
+		if f.num == 1 {
+			return string(f.bytes), nil
+		}
+	}
+	return "", errors.New("value has no name")
+}
+
+func parseONNX(b []byte) (*onnxGraph, error) {
+	model, err := parseProto(b)
+	if err != nil {
+		return nil, err
+	}
+	var graph []protoField
+	for _, f := range model {
+		if f.num == 7 {
+			if graph, err = parseProto(f.bytes); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if graph == nil {
+		return nil, errors.New("model has no graph")
+	}
+	g := &onnxGraph{consts: map[string]*tensor{}}
+	var inputs []string
+	for _, f := range graph {
+		switch f.num {
+		case 1:
+			n, err := parseNode(f.bytes)
+			if err != nil {
+				return nil, err
+			}
+			g.nodes = append(g.nodes, n)
+		case 5:
+			name, t, err := parseTensor(f.bytes)
+			if err != nil {
+				return nil, err
+			}
+			g.consts[name] = t
+		case 11:
+			name, err := valueName(f.bytes)
+			if err != nil {
+				return nil, err
+			}
+			inputs = append(inputs, name)
+		case 12:
+			if g.output == "" {
+				if g.output, err = valueName(f.bytes); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	// Older models list initializers as inputs too.
+	for _, in := range inputs {
+		if _, ok := g.consts[in]; !ok {
+			if g.input != "" {
+				return nil, errors.New("model has more than one input")
+			}
+			g.input = in
+		}
+	}
+	if g.input == "" || g.output == "" {
+		return nil, errors.New("model must have an input and an output")
+	}
+	if err := g.compile(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// compile resolves the names of the nodes' inputs and outputs, so
+// running the graph needn't look them up, and constants are found once.
+func (g *onnxGraph) compile() error {
+	slots := map[string]int{g.input: 0}
+	for i := range g.nodes {
+		n := &g.nodes[i]
+		if len(n.outputs) != 1 || len(n.inputs) == 0 || n.inputs[0] == "" {
+			return fmt.Errorf("%s: want one output and at least one input", n.op)
+		}
+		n.args = make([]onnxArg, len(n.inputs))
+		for j, name := range n.inputs {
+			if t, ok := g.consts[name]; ok {
+				n.args[j] = onnxArg{konst: t}
+			} else if slot, ok := slots[name]; ok {
+				n.args[j] = onnxArg{slot: slot}
+			} else if name == "" {
+				n.args[j] = onnxArg{slot: -1}
+			} else {
+				return fmt.Errorf("%s: unknown input %q", n.op, name)
+			}
+		}
+		n.out = len(slots)
+		slots[n.outputs[0]] = n.out
+	}
+	out, ok := slots[g.output]
+	if !ok {
+		return fmt.Errorf("model doesn't compute its output %q", g.output)
+	}
+	g.slots, g.out = len(slots), out
+	return nil
+}
+
+func matmul(a, b *tensor, transA, transB bool) (*tensor, error) {
+	ar, ac := a.rowsCols()
+	br, bc := b.rowsCols()
+	at := func(i, j int) float64 { return a.data[i*ac+j] }
+	bt := func(i, j int) float64 { return b.data[i*bc+j] }
+	if transA {
+		ar, ac = ac, ar
+		at = func(i, j int) float64 { return a.data[j*ar+i] }
+	}
+	if transB {
+		br, bc = bc, br
+		bt = func(i, j int) float64 { return b.data[j*br+i] }
+	}
+	if ac != br {
+		return nil, fmt.Errorf("can't multiply %dx%d by %dx%d", ar, ac, br, bc)
+	}
+	r := &tensor{dims: []int{ar, bc}, data: make([]float64, ar*bc)}
+	for i := 0; i < ar; i++ {
+		for j := 0; j < bc; j++ {
+			s := 0.0
+			for k := 0; k < ac; k++ {
+				s += at(i, k) * bt(k, j)
+			}
+			r.data[i*bc+j] = s
+		}
+	}
+	return r, nil
+}
+
+// add returns alpha*a + beta*b, broadcasting b over the rows of a.
+func add(a, b *tensor, alpha, beta float64) (*tensor, error) {
+	ar, ac := a.rowsCols()
+	br, bc := b.rowsCols()
+	if (br != 1 && br != ar) || (bc != 1 && bc != ac) {
+		return nil, fmt.Errorf("can't add %dx%d to %dx%d", br, bc, ar, ac)
+	}
+	r := &tensor{dims: []int{ar, ac}, data: make([]float64, ar*ac)}
+	for i := 0; i < ar; i++ {
+		for j := 0; j < ac; j++ {
+			r.data[i*ac+j] = alpha*a.data[i*ac+j] + beta*b.data[(i%br)*bc+j%bc]
+		}
+	}
+	return r, nil
+}
+
+func apply(a *tensor, f func(float64) float64) *tensor {
+	r := &tensor{dims: a.dims, data: make([]float64, len(a.data))}
+	for i, x := range a.data {
+		r.data[i] = f(x)
+	}
+	return r
+}
+
+func (g *onnxGraph) run(input []float64) (float64, error) {
+	vals := make([]*tensor, g.slots)
+	vals[0] = &tensor{dims: []int{1, len(input)}, data: input}
+	for _, n := range g.nodes {
+		in := make([]*tensor, len(n.args))
+		for i, a := range n.args {
+			if in[i] = a.konst; in[i] == nil && a.slot >= 0 {
+				in[i] = vals[a.slot]
+			}
+		}
+		var out *tensor
+		var err error
+		switch n.op {
+		case "Gemm":
+			if len(in) < 2 {
+				return 0, errors.New("Gemm: want at least two inputs")
+			}
+			alpha, beta := 1.0, 1.0
+			if v, ok := n.floats["alpha"]; ok {
+				alpha = v
+			}
+			if v, ok := n.floats["beta"]; ok {
+				beta = v
+			}
+			if out, err = matmul(in[0], in[1], n.ints["transA"] != 0, n.ints["transB"] != 0); err == nil && len(in) > 2 && in[2] != nil {
+				out, err = add(out, in[2], alpha, beta)
+			} else if err == nil {
+				out = apply(out, func(x float64) float64 { return alpha * x })
+			}
+		case "MatMul":
+			if len(in) != 2 {
+				return 0, errors.New("MatMul: want two inputs")
+			}
+			out, err = matmul(in[0], in[1], false, false)
+		case "Add":
+			if len(in) != 2 {
+				return 0, errors.New("Add: want two inputs")
+			}
+			out, err = add(in[0], in[1], 1, 1)
+		case "Relu":
+			out = apply(in[0], func(x float64) float64 { return math.Max(x, 0) })
+		case "Sigmoid":
+			out = apply(in[0], func(x float64) float64 { return 1 / (1 + math.Exp(-x)) })
+		case "Tanh":
+			out = apply(in[0], math.Tanh)
+		case "Identity":
+			out = in[0]
+		default:
+			return 0, fmt.Errorf("unsupported ONNX operator %q", n.op)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%s: %s", n.op, err)
+		}
+		vals[n.out] = out
+	}
+	out := vals[g.out]
+	if out == nil || len(out.data) == 0 {
+		return 0, fmt.Errorf("model didn't compute its output %q", g.output)
+	}
+	return out.data[0], nil
+}
+
+// LoadONNXEvaluator reads an ONNX model from a named file, and returns
+// an evaluator which applies the model to the features of each hand.
+// The model must have a single input, of shape [1, fz.Len()], and its
+// output's first value is the value of the hand. Only models built from
+// Gemm, MatMul, Add, Relu, Sigmoid, Tanh and Identity are supported.
+// The model is checked when it's loaded, so it can't fail on features
+// from fz: given the wrong number of features, the evaluator's Model
+// panics rather than returning a wrong value.
+func LoadONNXEvaluator(filename string, fz Featurizer) (*ModelEvaluator, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	g, err := parseONNX(b)
+	if err != nil {
+		return nil, err
+	}
+	// Check the model works on the features before using it.
+	if _, err := g.run(make([]float64, fz.Len())); err != nil {
+		return nil, err
+	}
+	return &ModelEvaluator{
+		Featurizer: fz,
+		Model: func(x []float64) float64 {
+			v, err := g.run(x)
+			if err != nil {
+				panic(fmt.Sprintf("ONNX model %s: %s", filename, err))
+			}
+			return v
+		},
+	}, nil
+}
@@ -0,0 +1,119 @@
+package cpoker
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Helpers to encode protocol buffers, for building test models.
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func pbBytes(num int, b []byte) []byte {
+	r := appendUvarint(nil, uint64(num<<3|2))
+	r = appendUvarint(r, uint64(len(b)))
+	return append(r, b...)
+}
+
+func pbVarint(num int, v uint64) []byte {
+	return appendUvarint(appendUvarint(nil, uint64(num<<3)), v)
+}
+
+func pbFloat(num int, v float32) []byte {
+	r := appendUvarint(nil, uint64(num<<3|5))
+	return appendUint32(r, math.Float32bits(v))
+}
+
+func pbTensor(name string, dims []int, data []float32) []byte {
+	var r []byte
+	for _, d := range dims {
+		r = append(r, pbVarint(1, uint64(d))...)
+	}
+	r = append(r, pbVarint(2, onnxFloat)...)
+	raw := []byte{}
+	for _, x := range data {
+		raw = appendUint32(raw, math.Float32bits(x))
+	}
+	r = append(r, pbBytes(8, []byte(name))...)
+	return append(r, pbBytes(9, raw)...)
+}
+
+func pbNode(op string, inputs []string, output string, attrs ...[]byte) []byte {
+	var r []byte
+	for _, in := range inputs {
+		r = append(r, pbBytes(1, []byte(in))...)
+	}
+	r = append(r, pbBytes(2, []byte(output))...)
+	r = append(r, pbBytes(4, []byte(op))...)
+	for _, a := range attrs {
+		r = append(r, pbBytes(5, a)...)
+	}
+	return r
+}
+
+func TestLoadONNXEvaluator(t *testing.T) {
+	fz := DefaultFeaturizer{}
+	n := fz.Len()
+	w := make([]float32, n)
+	for i := range w {
+		w[i] = float32(i%5) - 2
+	}
+	// y = sigmoid(2 * x.W^T + 0.5)
+	var graph []byte
+	graph = append(graph, pbBytes(1, pbNode("Gemm", []string{"x", "W", "B"}, "h",
+		append(pbBytes(1, []byte("transB")), pbVarint(3, 1)...),
+		append(pbBytes(1, []byte("alpha")), pbFloat(2, 2)...),
+	))...)
+	graph = append(graph, pbBytes(1, pbNode("Sigmoid", []string{"h"}, "y"))...)
+	graph = append(graph, pbBytes(5, pbTensor("W", []int{1, n}, w))...)
+	graph = append(graph, pbBytes(5, pbTensor("B", []int{1}, []float32{0.5}))...)
+	graph = append(graph, pbBytes(11, pbBytes(1, []byte("x")))...)
+	graph = append(graph, pbBytes(12, pbBytes(1, []byte("y")))...)
+	model := append(pbVarint(1, 7), pbBytes(7, graph)...)
+
+	dir, err := ioutil.TempDir("", "onnx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "model.onnx")
+	if err := ioutil.WriteFile(filename, model, 0644); err != nil {
+		t.Fatal(err)
+	}
+	me, err := LoadONNXEvaluator(filename, fz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := make([]float64, n)
+	for i := range x {
+		x[i] = float64(i) / float64(n)
+	}
+	s := 0.0
+	for i := range x {
+		s += x[i] * float64(w[i])
+	}
+	want := 1 / (1 + math.Exp(-(2*s + 0.5)))
+	if got := me.Model(x); math.Abs(got-want) > 1e-5 {
+		t.Errorf("model gave %f, want %f", got, want)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("model gave a value for %d features, want a panic", n-1)
+			}
+		}()
+		me.Model(x[:n-1])
+	}()
+}
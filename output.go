@@ -0,0 +1,89 @@
+package cpoker
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// A RecordWriter writes structured records, for tools to read instead
+// of the output meant for people. Each record is a JSON object on its
+// own line, with a "type" field saying what kind of record it is.
+type RecordWriter struct {
+	w io.Writer
+	c io.Closer
+}
+
+// OpenOutput opens the output described by the -out flag of the train,
+// strategy, match, replay and run binaries, or listed in a scenario's
+// outputs. The only form supported is "jsonl://path", which creates the
+// named file. The path can't be "-": the binaries write their output
+// for people to standard output, so records written there couldn't be
+// told apart from it.
+func OpenOutput(spec string) (*RecordWriter, error) {
+	path, err := outputPath(spec)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &RecordWriter{w: f, c: f}, nil
+}
+
+// outputPath returns the file named by an output spec.
+func outputPath(spec string) (string, error) {
+	const scheme = "jsonl://"
+	if !strings.HasPrefix(spec, scheme) {
+		return "", fmt.Errorf("output %q should look like %spath", spec, scheme)
+	}
+	path := spec[len(scheme):]
+	if path == "-" {
+		return "", fmt.Errorf("output %q can't be standard output, which is used for the report", spec)
+	}
+	return path, nil
+}
+
+// NewRecordWriter returns a RecordWriter which writes to w.
+func NewRecordWriter(w io.Writer) *RecordWriter {
+	return &RecordWriter{w: w}
+}
+
+// Write writes v, which must encode as a JSON object, as a record of
+// the given type. A nil RecordWriter discards records, so binaries
+// needn't check whether -out was given.
+func (rw *RecordWriter) Write(typ string, v interface{}) error {
+	if rw == nil {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if len(b) < 2 || b[0] != '{' {
+		return fmt.Errorf("%s record is %T, want an object", typ, v)
+	}
+	t, _ := json.Marshal(typ)
+	var line bytes.Buffer
+	fmt.Fprintf(&line, `{"type":%s`, t)
+	if len(b) > 2 {
+		line.WriteByte(',')
+	}
+	line.Write(b[1:])
+	line.WriteByte('\n')
+	_, err = rw.w.Write(line.Bytes())
+	return err
+}
+
+// Close closes the output, unless it's a writer passed to
+// NewRecordWriter.
+func (rw *RecordWriter) Close() error {
+	if rw == nil || rw.c == nil {
+		return nil
+	}
+	return rw.c.Close()
+}
@@ -0,0 +1,36 @@
+package cpoker
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRecordWriter(t *testing.T) {
+	var b bytes.Buffer
+	rw := NewRecordWriter(&b)
+	if err := rw.Write("result", Comparison{Played: 2, EVPerHand: 0.5}); err != nil {
+		t.Fatal(err)
+	}
+	if err := rw.Write("empty", struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := rw.Write("number", 1); err == nil {
+		t.Errorf("writing a number as a record succeeded, want an error")
+	}
+	want := `{"type":"result","played":2,"ev":0.5,"stderr":0,"hero_scoops":0,"villain_scoops":0,"same":0}
+{"type":"empty"}
+`
+	if b.String() != want {
+		t.Errorf("got records:\n%s\nwant:\n%s", b.String(), want)
+	}
+	var nilWriter *RecordWriter
+	if err := nilWriter.Write("result", Comparison{}); err != nil {
+		t.Errorf("writing to a nil RecordWriter failed: %s", err)
+	}
+	if _, err := OpenOutput("records.jsonl"); err == nil {
+		t.Errorf("OpenOutput without a scheme succeeded, want an error")
+	}
+	if _, err := OpenOutput("jsonl://-"); err == nil {
+		t.Errorf("OpenOutput to standard output succeeded, want an error")
+	}
+}
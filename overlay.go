@@ -0,0 +1,158 @@
+package cpoker
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// An OverlayEvent describes a deal an engine has played, for showing
+// on a stream overlay.
+type OverlayEvent struct {
+	Deal []string `json:"deal"`           // The cards dealt
+	Hand Hand     `json:"hand"`           // How the engine played them
+	Odds *Odds    `json:"odds,omitempty"` // The hand's odds against one random opponent
+}
+
+// An Overlay pushes the deals an engine plays to HTTP clients as
+// Server-Sent Events, so that streamers can build overlays (for
+// example browser sources in OBS) that follow the engine. Each event
+// is named "deal", and its data is an OverlayEvent encoded as JSON.
+// A client that connects is first sent the most recent deal, if there
+// is one. Clients that don't keep up miss events rather than slowing
+// the engine down.
+type Overlay struct {
+	// OddsSamples is how many deals the odds are estimated from, or 0
+	// to not estimate odds. Odds are estimated in the background, so
+	// they don't delay the engine: each deal is sent at once without
+	// odds, and sent again with them when they've been estimated,
+	// unless the engine has played another deal by then.
+	OddsSamples int
+
+	mu      sync.Mutex
+	last    []byte
+	clients map[chan []byte]bool
+	deals   int      // how many deals have been published
+	pending *oddsJob // the latest deal whose odds haven't been estimated
+	working bool     // whether odds are being estimated
+}
+
+// An oddsJob is a deal whose odds are to be estimated.
+type oddsJob struct {
+	deal int
+	ev   OverlayEvent
+	he   HandEvaluator
+}
+
+// Publish sends an event for the hand h played from the cards c
+// using he to every connected client. If odds are estimated, they're
+// estimated with he while the engine goes on playing, so if he isn't
+// safe for concurrent use, it mustn't be changed afterwards (an
+// OnlineEvaluator is copied, so it can go on learning).
+func (o *Overlay) Publish(c []poker.Card, h *Hand, he HandEvaluator) error {
+	ev := OverlayEvent{Deal: cardNames(c), Hand: *h}
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	if oe, ok := he.(*OnlineEvaluator); ok {
+		he = oe.Snapshot()
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.deals++
+	o.send(data)
+	if o.OddsSamples > 0 {
+		o.pending = &oddsJob{deal: o.deals, ev: ev, he: he}
+		if !o.working {
+			o.working = true
+			go o.estimateOdds()
+		}
+	}
+	return nil
+}
+
+// send sends an encoded event to every connected client. The lock
+// must be held.
+func (o *Overlay) send(data []byte) {
+	o.last = data
+	for ch := range o.clients {
+		select {
+		case ch <- data:
+		default:
+		}
+	}
+}
+
+// estimateOdds estimates the odds of the pending deals, one at a time,
+// until there are none left, and sends the events of those which are
+// still the latest. Deals whose odds can't be estimated are skipped.
+func (o *Overlay) estimateOdds() {
+	for {
+		o.mu.Lock()
+		job := o.pending
+		o.pending = nil
+		if job == nil {
+			o.working = false
+			o.mu.Unlock()
+			return
+		}
+		o.mu.Unlock()
+		odds, err := ShowdownOdds(&job.ev.Hand, 1, job.he, o.OddsSamples)
+		if err != nil {
+			continue
+		}
+		job.ev.Odds = &odds
+		data, err := json.Marshal(job.ev)
+		if err != nil {
+			continue
+		}
+		o.mu.Lock()
+		if job.deal == o.deals {
+			o.send(data)
+		}
+		o.mu.Unlock()
+	}
+}
+
+// ServeHTTP streams events to a client until it disconnects.
+func (o *Overlay) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming isn't supported", http.StatusInternalServerError)
+		return
+	}
+	ch := make(chan []byte, 16)
+	o.mu.Lock()
+	if o.clients == nil {
+		o.clients = map[chan []byte]bool{}
+	}
+	o.clients[ch] = true
+	if o.last != nil {
+		ch <- o.last
+	}
+	o.mu.Unlock()
+	defer func() {
+		o.mu.Lock()
+		delete(o.clients, ch)
+		o.mu.Unlock()
+	}()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case data := <-ch:
+			if _, err := fmt.Fprintf(w, "event: deal\ndata: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package cpoker
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOverlay(t *testing.T) {
+	c := randomDeal(rand.New(rand.NewSource(3)))
+	o := &Overlay{OddsSamples: 10}
+	e := &Engine{Name: "test", Evaluators: map[string]HandEvaluator{"default": MaxProdEvaluator{}}, Overlay: o}
+	var out bytes.Buffer
+	in := EngineProtocol + "\ndeal " + strings.Join(cardNames(c), " ") + "\n"
+	if err := e.Serve(strings.NewReader(in), &out); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(o)
+	defer srv.Close()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type is %q, want text/event-stream", ct)
+	}
+	h, err := ParseEngineHand(strings.TrimSpace(strings.SplitN(out.String(), "\n", 2)[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A new client is sent the last deal, and the odds are sent when
+	// they've been estimated.
+	r := bufio.NewReader(resp.Body)
+	for {
+		var lines []string
+		for len(lines) < 3 {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines = append(lines, strings.TrimSpace(line))
+		}
+		if lines[0] != "event: deal" || !strings.HasPrefix(lines[1], "data: ") || lines[2] != "" {
+			t.Fatalf("got event %q, want a deal", lines)
+		}
+		var ev OverlayEvent
+		if err := json.Unmarshal([]byte(lines[1][len("data: "):]), &ev); err != nil {
+			t.Fatal(err)
+		}
+		if ev.Hand != h || len(ev.Deal) != 13 {
+			t.Fatalf("got event %+v, want the hand %v played from %v", ev, &h, c)
+		}
+		if ev.Odds != nil {
+			if ev.Odds.Samples != 10 {
+				t.Errorf("got odds from %d samples, want 10", ev.Odds.Samples)
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package cpoker
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// A PartialHand is a Chinese poker hand which is partly set.
+type PartialHand struct {
+	Front  []poker.Card // At most 3 cards
+	Middle []poker.Card // At most 5 cards
+	Back   []poker.Card // At most 5 cards
+}
+
+func (ph *PartialHand) slots() [3][]poker.Card {
+	return [3][]poker.Card{ph.Front, ph.Middle, ph.Back}
+}
+
+// A Placement is a card, and a slot it can be placed in.
+type Placement struct {
+	Card poker.Card
+	Slot int // 0, 1, 2 means front, middle, back
+}
+
+var slotSizes = [3]int{3, 5, 5}
+
+// forEachCombination calls f with every k-element subset of
+// the indexes 0 to n-1, in increasing order.
+func forEachCombination(n, k int, f func(idx []int)) {
+	idx := make([]int, k)
+	for i := range idx {
+		idx[i] = i
+	}
+	for k <= n {
+		f(idx)
+		i := k - 1
+		for i >= 0 && idx[i] == n-k+i {
+			i--
+		}
+		if i < 0 {
+			return
+		}
+		idx[i]++
+		for j := i + 1; j < k; j++ {
+			idx[j] = idx[j-1] + 1
+		}
+	}
+}
+
+// RankBounds3 returns the lowest and highest Eval3 ranks of a 3-card
+// hand made from all the fixed cards, and the rest from pool. ok is
+// false if there aren't enough cards. Only the completions of this one
+// hand are considered, which is at most C(len(pool), 3) evaluations,
+// so this is cheap compared to enumerating arrangements when the
+// pool is the rest of a 13-card deal.
+func RankBounds3(fixed, pool []poker.Card) (lo, hi int16, ok bool) {
+	return rankBounds(3, fixed, pool)
+}
+
+// RankBounds5 is like RankBounds3, but for 5-card hands and Eval5 ranks.
+func RankBounds5(fixed, pool []poker.Card) (lo, hi int16, ok bool) {
+	return rankBounds(5, fixed, pool)
+}
+
+// rankBounds returns the lowest and highest ranks of a hand of the
+// given size made from all the fixed cards, and the rest from pool.
+// ok is false if there aren't enough cards.
+func rankBounds(size int, fixed, pool []poker.Card) (lo, hi int16, ok bool) {
+	need := size - len(fixed)
+	if need < 0 || need > len(pool) {
+		return 0, 0, false
+	}
+	lo, hi = poker.ScoreMax, 0
+	h := make([]poker.Card, size)
+	copy(h, fixed)
+	forEachCombination(len(pool), need, func(idx []int) {
+		for i, j := range idx {
+			h[len(fixed)+i] = pool[j]
+		}
+		e := eval(h)
+		if e < lo {
+			lo = e
+		}
+		if e > hi {
+			hi = e
+		}
+	})
+	return lo, hi, true
+}
+
+// mayBeLegal reports whether the partial hand can perhaps be completed
+// using the cards in pool without fouling. It considers each slot
+// separately, so it may report true when every completion fouls.
+func mayBeLegal(slots [3][]poker.Card, pool []poker.Card) bool {
+	var lo, hi [3]int16
+	for i := 0; i < 3; i++ {
+		var ok bool
+		if lo[i], hi[i], ok = rankBounds(slotSizes[i], slots[i], pool); !ok {
+			return false
+		}
+	}
+	return lo[0] <= hi[1] && lo[1] <= hi[2]
+}
+
+// LegalPlacements returns where each of the 13 cards c which isn't yet
+// in the partial hand can be placed without the hand certainly
+// fouling. For speed, it uses bounds on the ranks each slot can make,
+// so a placement may be returned even though every way of completing the
+// hand after it fouls.
+func LegalPlacements(c []poker.Card, partial *PartialHand) ([]Placement, error) {
+	placed, ok := NewCardSet(append(append(append([]poker.Card{}, partial.Front...), partial.Middle...), partial.Back...))
+	if !ok {
+		return nil, fmt.Errorf("partial hand has an invalid or duplicate card")
+	}
+	dealt, ok := NewCardSet(c)
+	if !ok || len(c) != 13 {
+		return nil, fmt.Errorf("want 13 distinct valid cards, got %v", c)
+	}
+	if placed&^dealt != 0 {
+		return nil, fmt.Errorf("partial hand uses cards which weren't dealt")
+	}
+	slots := partial.slots()
+	for i := range slots {
+		if len(slots[i]) > slotSizes[i] {
+			return nil, fmt.Errorf("slot %d has %d cards, want at most %d", i, len(slots[i]), slotSizes[i])
+		}
+	}
+	remaining := (dealt &^ placed).Cards()
+	var r []Placement
+	for i, card := range remaining {
+		pool := append(append([]poker.Card{}, remaining[:i]...), remaining[i+1:]...)
+		for s := 0; s < 3; s++ {
+			if len(slots[s]) == slotSizes[s] {
+				continue
+			}
+			try := slots
+			try[s] = append(append([]poker.Card{}, slots[s]...), card)
+			if mayBeLegal(try, pool) {
+				r = append(r, Placement{card, s})
+			}
+		}
+	}
+	return r, nil
+}
+
+// canComplete reports whether the partial hand can be completed without
+// fouling by placing exactly the cards in pool.
+func canComplete(slots [3][]poker.Card, pool []poker.Card) bool {
+	if len(slots[0])+len(slots[1])+len(slots[2]) == 0 {
+		// Any 13 cards can be played without fouling (as Play does),
+		// so there's no need to search the 72072 ways of placing them.
+		return true
+	}
+	var h [3][]poker.Card
+	for i := range h {
+		h[i] = make([]poker.Card, slotSizes[i])
+		copy(h[i], slots[i])
+	}
+	needF, needM := slotSizes[0]-len(slots[0]), slotSizes[1]-len(slots[1])
+	found := false
+	rest := make([]poker.Card, 0, len(pool))
+	forEachCombination(len(pool), needF, func(fi []int) {
+		if found {
+			return
+		}
+		rest = rest[:0]
+		for i, j := 0, 0; i < len(pool); i++ {
+			if j < len(fi) && fi[j] == i {
+				h[0][len(slots[0])+j] = pool[i]
+				j++
+			} else {
+				rest = append(rest, pool[i])
+			}
+		}
+		ef := eval(h[0])
+		forEachCombination(len(rest), needM, func(mi []int) {
+			if found {
+				return
+			}
+			b := len(slots[2])
+			for i, j := 0, 0; i < len(rest); i++ {
+				if j < len(mi) && mi[j] == i {
+					h[1][len(slots[1])+j] = rest[i]
+					j++
+				} else {
+					h[2][b] = rest[i]
+					b++
+				}
+			}
+			em, eb := eval(h[1]), eval(h[2])
+			found = ef <= em && em <= eb
+		})
+	})
+	return found
+}
+
+// LegalProbability estimates, from n samples, the probability that the
+// partial hand can be completed without fouling when the cards needed to
+// complete it are drawn at random from those not in the hand or dead.
+// Each completion is chosen knowing all the cards drawn, so when cards
+// must be placed as they arrive (as in open-face Chinese poker) this is
+// an upper bound on the chance of avoiding a foul.
+func LegalProbability(rnd *rand.Rand, partial *PartialHand, dead []poker.Card, n int) (float64, error) {
+	slots := partial.slots()
+	need := 0
+	var used []poker.Card
+	for i := range slots {
+		if len(slots[i]) > slotSizes[i] {
+			return 0, fmt.Errorf("slot %d has %d cards, want at most %d", i, len(slots[i]), slotSizes[i])
+		}
+		need += slotSizes[i] - len(slots[i])
+		used = append(used, slots[i]...)
+	}
+	seen, ok := NewCardSet(append(used, dead...))
+	if !ok {
+		return 0, fmt.Errorf("partial hand and dead cards have an invalid or duplicate card")
+	}
+	var unseen []poker.Card
+	for _, c := range poker.Cards {
+		if !seen.Contains(c) {
+			unseen = append(unseen, c)
+		}
+	}
+	if need > len(unseen) {
+		return 0, fmt.Errorf("need %d cards to complete the hand, but only %d are unseen", need, len(unseen))
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("need a positive number of samples, got %d", n)
+	}
+	legal := 0
+	for s := 0; s < n; s++ {
+		for i := 0; i < need; i++ {
+			j := rnd.Intn(len(unseen)-i) + i
+			unseen[i], unseen[j] = unseen[j], unseen[i]
+		}
+		if canComplete(slots, unseen[:need]) {
+			legal++
+		}
+	}
+	return float64(legal) / float64(n), nil
+}
+
+// SafePlacements returns the slots in which card can be placed in the
+// partial hand while keeping the LegalProbability of the result
+// (estimated from n samples) at least minLegal. This trades expected
+// value for safety from fouling.
+func SafePlacements(rnd *rand.Rand, card poker.Card, partial *PartialHand, dead []poker.Card, minLegal float64, n int) ([]Placement, error) {
+	var r []Placement
+	slots := partial.slots()
+	for s := 0; s < 3; s++ {
+		if len(slots[s]) >= slotSizes[s] {
+			continue
+		}
+		try := slots
+		try[s] = append(append([]poker.Card{}, slots[s]...), card)
+		p, err := LegalProbability(rnd, &PartialHand{try[0], try[1], try[2]}, dead, n)
+		if err != nil {
+			return nil, err
+		}
+		if p >= minLegal {
+			r = append(r, Placement{card, s})
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,95 @@
+package cpoker
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+func mustCards(t *testing.T, s string) []poker.Card {
+	t.Helper()
+	var r []poker.Card
+	for i := 0; i+1 < len(s); i += 2 {
+		c, ok := poker.NameToCard[s[i:i+2]]
+		if !ok {
+			t.Fatalf("bad card %q in %q", s[i:i+2], s)
+		}
+		r = append(r, c)
+	}
+	return r
+}
+
+func TestLegalPlacements(t *testing.T) {
+	c := mustCards(t, "HAHKHQHJH9C2D3S4C5D7S8CTDJ")
+	all, err := LegalPlacements(c, &PartialHand{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 39 {
+		t.Errorf("got %d placements for an empty hand, want 39", len(all))
+	}
+	// With a seven-high middle, an ace-high front must foul, so
+	// there's no legal placement.
+	partial := &PartialHand{Front: mustCards(t, "HAHK"), Middle: mustCards(t, "C2D3S4C5D7")}
+	got, err := LegalPlacements(c, partial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("got placements %v, want none", got)
+	}
+}
+
+func TestRankBounds(t *testing.T) {
+	lo, hi, ok := RankBounds5(mustCards(t, "HAHK"), mustCards(t, "HQHJHTC2D3S4"))
+	if !ok {
+		t.Fatalf("RankBounds5 failed")
+	}
+	if want := eval(mustCards(t, "HAHKC2D3S4")); lo != want {
+		t.Errorf("lo = %d, want %d", lo, want)
+	}
+	if want := eval(mustCards(t, "HAHKHQHJHT")); hi != want {
+		t.Errorf("hi = %d, want a royal flush (%d)", hi, want)
+	}
+	if _, _, ok := RankBounds3(nil, mustCards(t, "HAHK")); ok {
+		t.Errorf("RankBounds3 succeeded with only two cards")
+	}
+}
+
+func TestLegalProbability(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	// A pair of aces in front with a weak middle is very likely to foul.
+	risky := &PartialHand{Front: mustCards(t, "HAHKCA"), Middle: mustCards(t, "S2D7")}
+	p, err := LegalProbability(rnd, risky, nil, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p > 0.5 {
+		t.Errorf("LegalProbability(%v) = %.2f, want at most 0.5", risky, p)
+	}
+	// A complete legal hand can't foul.
+	full := &PartialHand{Front: mustCards(t, "C2D3S4"), Middle: mustCards(t, "C5D7S8CTDJ"), Back: mustCards(t, "HAHKHQHJH9")}
+	if p, err := LegalProbability(rnd, full, nil, 10); err != nil || p != 1 {
+		t.Errorf("LegalProbability(%v) = %v, %v, want 1", full, p, err)
+	}
+	// Nor can an empty one.
+	if p, err := LegalProbability(rnd, &PartialHand{}, nil, 10); err != nil || p != 1 {
+		t.Errorf("LegalProbability(empty hand) = %v, %v, want 1", p, err)
+	}
+	// Pairing the ace in front is less safe than putting the
+	// second ace in the middle or back.
+	card := mustCards(t, "DA")[0]
+	safe, err := SafePlacements(rnd, card, &PartialHand{Front: mustCards(t, "SAC2")}, nil, 0.9, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pl := range safe {
+		if pl.Slot == 0 {
+			t.Errorf("SafePlacements allowed a pair of aces in front: %v", safe)
+		}
+	}
+	if len(safe) == 0 {
+		t.Errorf("SafePlacements returned no placements")
+	}
+}
@@ -0,0 +1,234 @@
+package cpoker
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// PlayStats are how often an evaluator plays each category of hand
+// in each slot, over a sample of deals.
+type PlayStats struct {
+	Deals      int
+	Categories [3][FiveOfAKind + 1]int // Categories[i][c] is how often slot i held category c
+}
+
+// CollectPlayStats plays n random deals with he, and counts the
+// categories of the hands played.
+func CollectPlayStats(rnd *rand.Rand, he HandEvaluator, n int) PlayStats {
+	var ps PlayStats
+	cards := append([]poker.Card{}, poker.Cards...)
+	for d := 0; d < n; d++ {
+		for i := 0; i < 13; i++ {
+			j := rnd.Intn(52-i) + i
+			cards[i], cards[j] = cards[j], cards[i]
+		}
+		h, _ := Play(cards[:13], he)
+		ps.Add(&h)
+	}
+	return ps
+}
+
+// Add counts the categories of a played hand.
+func (ps *PlayStats) Add(h *Hand) {
+	for i, e := range h.ranks() {
+		ps.Categories[i][slotCategory(i, e)]++
+	}
+	ps.Deals++
+}
+
+// Fraction returns the fraction of deals in which slot i (0, 1, 2 means
+// front, middle, back) held a hand of category c.
+func (ps *PlayStats) Fraction(i int, c HandCategory) float64 {
+	if ps.Deals == 0 {
+		return 0
+	}
+	return float64(ps.Categories[i][c]) / float64(ps.Deals)
+}
+
+// String returns a table of the percentage of deals in which each
+// slot held each category of hand.
+func (ps *PlayStats) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-16s %7s %7s %7s\n", "", "front", "middle", "back")
+	for c := HighCard; c <= FiveOfAKind; c++ {
+		if ps.Categories[0][c]+ps.Categories[1][c]+ps.Categories[2][c] == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "%-16s %7.2f %7.2f %7.2f\n", c, 100*ps.Fraction(0, c), 100*ps.Fraction(1, c), 100*ps.Fraction(2, c))
+	}
+	return b.String()
+}
+
+// A Pattern is the categories of the front, middle and back of a
+// played hand.
+type Pattern [3]HandCategory
+
+func (p Pattern) String() string {
+	return fmt.Sprintf("%s front, %s middle, %s back", p[0], p[1], p[2])
+}
+
+// A Cluster is the deals played with the same pattern.
+type Cluster struct {
+	Pattern Pattern
+	Deals   int
+	MeanEV  float64 // The average value the evaluator gave to the hands played
+}
+
+// ClusterPlays plays n random deals with he, and groups them by the
+// pattern of the hands played. The clusters are returned in order of
+// decreasing frequency.
+func ClusterPlays(rnd *rand.Rand, he HandEvaluator, n int) []Cluster {
+	clusters := map[Pattern]*Cluster{}
+	cards := append([]poker.Card{}, poker.Cards...)
+	for d := 0; d < n; d++ {
+		for i := 0; i < 13; i++ {
+			j := rnd.Intn(52-i) + i
+			cards[i], cards[j] = cards[j], cards[i]
+		}
+		ev := he.Evaluator(cards[:13])
+		h, _ := Play(cards[:13], fixedEvaluator(ev))
+		r := h.ranks()
+		var p Pattern
+		for i, e := range r {
+			p[i] = slotCategory(i, e)
+		}
+		c, ok := clusters[p]
+		if !ok {
+			c = &Cluster{Pattern: p}
+			clusters[p] = c
+		}
+		c.Deals++
+		c.MeanEV += ev(r[0], r[1], r[2])
+	}
+	var result []Cluster
+	for _, c := range clusters {
+		c.MeanEV /= float64(c.Deals)
+		result = append(result, *c)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Deals != result[j].Deals {
+			return result[i].Deals > result[j].Deals
+		}
+		return result[i].MeanEV > result[j].MeanEV
+	})
+	return result
+}
+
+// FormatClusters returns a table of the clusters, with the
+// percentage of deals in each, and their average value.
+func FormatClusters(clusters []Cluster) string {
+	total := 0
+	for _, c := range clusters {
+		total += c.Deals
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%7s %8s  %s\n", "deals%", "mean EV", "pattern")
+	for _, c := range clusters {
+		fmt.Fprintf(&b, "%7.2f %8.3f  %s\n", 100*float64(c.Deals)/float64(total), c.MeanEV, c.Pattern)
+	}
+	return b.String()
+}
+
+// A FrontPlay is the weakest arrangement in which an evaluator played
+// a class of front hand, over a sample of deals holding a front of that
+// class. It's the weakest play seen, not a threshold: whether a front
+// is worth keeping depends on the whole deal, so an evaluator may play
+// it with a weaker middle or back in a deal that wasn't sampled.
+type FrontPlay struct {
+	// Front is the class of front hand: a pair with its kicker such
+	// as "66-A", high cards by their top two such as "A-K", or trips.
+	Front  string
+	Rank   int16 // The rank of the weakest front in the class
+	Deals  int   // How many deals were dealt holding that front
+	Played int   // How many of the deals were played with a front in the class
+
+	// Middle and Back are the ranks of the middle and back of the
+	// weakest arrangement played with a front in the class: the one
+	// with the weakest middle, and of those, the weakest back. They're
+	// -1 if the evaluator never played the class in front.
+	Middle, Back int16
+}
+
+// frontClass returns the class of a front hand of rank e.
+func frontClass(e int16) string {
+	h, _ := poker.EvalToHand3(e)
+	d, _ := poker.DescribeShort(h)
+	if slotCategory(0, e) == HighCard {
+		return d[:len("A-K")]
+	}
+	return d
+}
+
+// WeakestFrontPlays finds, for every class of front hand, the weakest
+// arrangement he plays it in. For each class, it deals n random deals
+// holding the weakest front in the class, and plays them with he. The
+// middle and back of a FrontPlay come from the same arrangement, so
+// they're a play the evaluator actually chose. The plays are returned
+// from the weakest front to the strongest.
+func WeakestFrontPlays(rnd *rand.Rand, he HandEvaluator, n int) []FrontPlay {
+	intn := rand.Intn
+	if rnd != nil {
+		intn = rnd.Intn
+	}
+	var result []FrontPlay
+	for _, e := range ReachableRanks(0) {
+		class := frontClass(e)
+		if len(result) > 0 && result[len(result)-1].Front == class {
+			continue
+		}
+		fp := FrontPlay{Front: class, Rank: e, Middle: -1, Back: -1}
+		front, _ := poker.EvalToHand3(e)
+		var rest []poker.Card
+		for _, c := range poker.Cards {
+			if c != front[0] && c != front[1] && c != front[2] {
+				rest = append(rest, c)
+			}
+		}
+		for d := 0; d < n; d++ {
+			for i := 0; i < 10; i++ {
+				j := intn(len(rest)-i) + i
+				rest[i], rest[j] = rest[j], rest[i]
+			}
+			h, _ := Play(append(append([]poker.Card{}, front...), rest[:10]...), he)
+			fp.Deals++
+			r := h.ranks()
+			if frontClass(r[0]) != class {
+				continue
+			}
+			fp.Played++
+			if fp.Middle < 0 || r[1] < fp.Middle || r[1] == fp.Middle && r[2] < fp.Back {
+				fp.Middle, fp.Back = r[1], r[2]
+			}
+		}
+		result = append(result, fp)
+	}
+	return result
+}
+
+// FormatFrontPlays returns a markdown table of the weakest front
+// plays, with the middle and back of each arrangement described as
+// hands.
+func FormatFrontPlays(fps []FrontPlay) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "| %-8s | %7s | %-18s | %-18s |\n", "front", "played%", "middle", "back")
+	fmt.Fprintf(&b, "|:%s|%s:|:%s|:%s|\n", strings.Repeat("-", 9), strings.Repeat("-", 8), strings.Repeat("-", 19), strings.Repeat("-", 19))
+	for _, fp := range fps {
+		md, bd := "-", "-"
+		if fp.Played > 0 {
+			m, _ := poker.EvalToHand5(fp.Middle)
+			bk, _ := poker.EvalToHand5(fp.Back)
+			md, _ = poker.DescribeShort(m)
+			bd, _ = poker.DescribeShort(bk)
+		}
+		played := 0.0
+		if fp.Deals > 0 {
+			played = 100 * float64(fp.Played) / float64(fp.Deals)
+		}
+		fmt.Fprintf(&b, "| %-8s | %7.1f | %-18s | %-18s |\n", fp.Front, played, md, bd)
+	}
+	return b.String()
+}
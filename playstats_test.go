@@ -0,0 +1,116 @@
+package cpoker
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestWeakestFrontPlays(t *testing.T) {
+	fps := WeakestFrontPlays(rand.New(rand.NewSource(1)), MaxProdEvaluator{}, 2)
+	seen := map[string]bool{}
+	for i, fp := range fps {
+		if seen[fp.Front] {
+			t.Errorf("class %s appears twice", fp.Front)
+		}
+		seen[fp.Front] = true
+		if i > 0 && fp.Rank <= fps[i-1].Rank {
+			t.Errorf("front %d (%s) isn't stronger than the one before (%s)", i, fp.Front, fps[i-1].Front)
+		}
+		if fp.Deals != 2 || fp.Played > fp.Deals {
+			t.Errorf("%s: played %d of %d deals, want 2 deals", fp.Front, fp.Played, fp.Deals)
+		}
+		if fp.Played == 0 {
+			if fp.Middle != -1 || fp.Back != -1 {
+				t.Errorf("%s: never played, but has middle %d and back %d", fp.Front, fp.Middle, fp.Back)
+			}
+			continue
+		}
+		if fp.Middle < fp.Rank {
+			t.Errorf("%s: weakest middle has rank %d, below the front's %d", fp.Front, fp.Middle, fp.Rank)
+		}
+		if fp.Back < fp.Middle {
+			t.Errorf("%s: weakest arrangement has back %d below its middle %d", fp.Front, fp.Back, fp.Middle)
+		}
+	}
+	// Every class is listed, whether or not it's played.
+	for _, c := range []string{"A-K", "22-3", "AA-K", "AAA"} {
+		if !seen[c] {
+			t.Errorf("class %s is missing", c)
+		}
+	}
+}
+
+func TestCollectPlayStats(t *testing.T) {
+	ps := CollectPlayStats(rand.New(rand.NewSource(2)), MaxProdEvaluator{}, 40)
+	if ps.Deals != 40 {
+		t.Fatalf("got %d deals, want 40", ps.Deals)
+	}
+	for i := 0; i < 3; i++ {
+		n, f := 0, 0.0
+		for c := HighCard; c <= FiveOfAKind; c++ {
+			n += ps.Categories[i][c]
+			f += ps.Fraction(i, c)
+		}
+		if n != 40 || math.Abs(f-1) > 1e-9 {
+			t.Errorf("slot %d has %d hands with total fraction %f, want 40 and 1", i, n, f)
+		}
+	}
+	// Adding a hand counts the category of each of its slots.
+	h, _ := Play(mustCards(t, "SASKSQSJSTHAHKHQHJH9D2D3D5"), MaxProdEvaluator{})
+	before := ps
+	ps.Add(&h)
+	for i, e := range h.ranks() {
+		c := slotCategory(i, e)
+		if ps.Categories[i][c] != before.Categories[i][c]+1 {
+			t.Errorf("adding %s didn't count the %s in slot %d", &h, c, i)
+		}
+	}
+	if ps.Deals != 41 {
+		t.Errorf("got %d deals after adding a hand, want 41", ps.Deals)
+	}
+	var empty PlayStats
+	if f := empty.Fraction(0, HighCard); f != 0 {
+		t.Errorf("an empty PlayStats has fraction %f, want 0", f)
+	}
+	if back := slotCategory(2, h.ranks()[2]).String(); !strings.Contains(ps.String(), back) {
+		t.Errorf("the table doesn't show the %s played in the back:\n%s", back, ps.String())
+	}
+}
+
+func TestClusterPlays(t *testing.T) {
+	clusters := ClusterPlays(rand.New(rand.NewSource(2)), MaxProdEvaluator{}, 40)
+	seen := map[Pattern]bool{}
+	deals := 0
+	for i, c := range clusters {
+		if seen[c.Pattern] {
+			t.Errorf("pattern %s appears twice", c.Pattern)
+		}
+		seen[c.Pattern] = true
+		deals += c.Deals
+		if i > 0 && c.Deals > clusters[i-1].Deals {
+			t.Errorf("cluster %d (%s) has more deals than the one before", i, c.Pattern)
+		}
+		if c.Deals == 0 || c.MeanEV <= 0 {
+			t.Errorf("cluster %s has %d deals with mean EV %f", c.Pattern, c.Deals, c.MeanEV)
+		}
+	}
+	if deals != 40 {
+		t.Errorf("the clusters have %d deals, want 40", deals)
+	}
+	// The same deals give the same clusters as the play stats.
+	ps := CollectPlayStats(rand.New(rand.NewSource(2)), MaxProdEvaluator{}, 40)
+	for i := 0; i < 3; i++ {
+		var counts [FiveOfAKind + 1]int
+		for _, c := range clusters {
+			counts[c.Pattern[i]] += c.Deals
+		}
+		if counts != ps.Categories[i] {
+			t.Errorf("slot %d: clusters have categories %v, play stats have %v", i, counts, ps.Categories[i])
+		}
+	}
+	if lines := strings.Count(FormatClusters(clusters), "\n"); lines != len(clusters)+1 {
+		t.Errorf("the table of %d clusters has %d lines", len(clusters), lines)
+	}
+}
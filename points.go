@@ -0,0 +1,59 @@
+package cpoker
+
+import (
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// An EvaluatorPoints is a HandEvaluator which can value hands in
+// expected points under a particular scoring, rather than with values
+// that are only good for ranking hands against each other.
+type EvaluatorPoints interface {
+	HandEvaluator
+
+	// Points returns a function giving the expected points for
+	// playing a hand made from the cards c against the evaluator's
+	// model of the opponent, scored with s.
+	Points(c []poker.Card, s *Scoring) func(evf, evm, evb int16) float64
+}
+
+// Points returns the expected points for a hand, scored with s (or
+// Scoring2to4 if it's nil), against an opponent whose hands win with
+// the evaluator's win probabilities. Unlike Evaluator, the opponent's
+// expected royalties are included.
+func (se *SampledEvaluator) Points(_ []poker.Card, s *Scoring) func(f, m, b int16) float64 {
+	if s == nil {
+		s = Scoring2to4
+	}
+	scored := &SampledEvaluator{Scoring: s, wins: se.wins}
+	oppRoyalties := 0.0
+	if s.Royalties != nil {
+		// wins[i][e] is the probability that the opponent's hand
+		// in slot i has rank at most e.
+		for i := 0; i < 3; i++ {
+			prev := 0.0
+			for e, w := range se.wins[i] {
+				oppRoyalties += (w - prev) * float64(s.Royalties[i][e])
+				prev = w
+			}
+		}
+	}
+	return func(f, m, b int16) float64 {
+		return scored.evaluateHand(f, m, b) - oppRoyalties
+	}
+}
+
+// PlayForPoints plays the 13 cards c to maximize the expected points
+// scored with s. If he is an EvaluatorPoints, it returns the hand and
+// its expected points; otherwise it plays with he's own evaluation,
+// and ok is false.
+func PlayForPoints(c []poker.Card, he HandEvaluator, s *Scoring) (h Hand, points float64, ok bool) {
+	ep, ok := he.(EvaluatorPoints)
+	if !ok {
+		h, _ = Play(c, he)
+		return h, 0, false
+	}
+	ev := ep.Points(c, s)
+	h, _ = Play(c, fixedEvaluator(ev))
+	r := h.ranks()
+	return h, ev(r[0], r[1], r[2]), true
+}
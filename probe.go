@@ -0,0 +1,87 @@
+package cpoker
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// A ProbeSet is a fixed set of deals, used to track how the
+// arrangements chosen by an evaluator change during training.
+type ProbeSet struct {
+	Deals [][]poker.Card
+	last  []Hand // the hands played by the previous evaluator, if any
+}
+
+// NewProbeSet constructs a ProbeSet of n random deals.
+func NewProbeSet(rnd *rand.Rand, n int) *ProbeSet {
+	ps := &ProbeSet{}
+	cards := append([]poker.Card{}, poker.Cards...)
+	for i := 0; i < n; i++ {
+		for j := 0; j < 13; j++ {
+			k := rnd.Intn(52-j) + j
+			cards[j], cards[k] = cards[k], cards[j]
+		}
+		ps.Deals = append(ps.Deals, append([]poker.Card{}, cards[:13]...))
+	}
+	return ps
+}
+
+// BenchmarkSeed seeds the deals of the benchmark that train uses to
+// choose the best checkpoint.
+const BenchmarkSeed = 1
+
+// NewBenchmarkProbeSet constructs a ProbeSet of the deals of the
+// benchmark: the cards of both players in each of the n hands of a
+// comparison whose Rand is seeded with BenchmarkSeed, so 2n deals.
+func NewBenchmarkProbeSet(n int) *ProbeSet {
+	rnd := rand.New(rand.NewSource(BenchmarkSeed))
+	ps := &ProbeSet{}
+	for i := 0; i < n; i++ {
+		ps.Deals = append(ps.Deals, DealPlayers(rnd, 2)...)
+	}
+	return ps
+}
+
+// A ProbeChange is a probe deal which is played differently
+// after an update.
+type ProbeChange struct {
+	Deal     int // The index of the deal in Deals
+	Old, New Hand
+}
+
+// Update plays each deal with he, and returns how many deals are played
+// differently than by the evaluator given to the previous call to Update.
+// Arrangements with the same ranks are considered the same.
+// The first call to Update returns 0.
+func (ps *ProbeSet) Update(he HandEvaluator) int {
+	return len(ps.UpdateChanges(he))
+}
+
+// UpdateChanges is like Update, but returns the deals which
+// are played differently.
+func (ps *ProbeSet) UpdateChanges(he HandEvaluator) []ProbeChange {
+	hands := make([]Hand, len(ps.Deals))
+	var changes []ProbeChange
+	for i, c := range ps.Deals {
+		hands[i], _ = Play(c, he)
+		if ps.last != nil && hands[i].ranks() != ps.last[i].ranks() {
+			changes = append(changes, ProbeChange{Deal: i, Old: ps.last[i], New: hands[i]})
+		}
+	}
+	ps.last = hands
+	return changes
+}
+
+// WriteChanges writes a report of the changes, showing how
+// each deal used to be played and how it's played now.
+func WriteChanges(w io.Writer, changes []ProbeChange) error {
+	for _, ch := range changes {
+		if _, err := fmt.Fprintf(w, "deal %d:\n  - %s\n  + %s\n", ch.Deal, &ch.Old, &ch.New); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package cpoker
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestProbeSet(t *testing.T) {
+	ps := NewProbeSet(rand.New(rand.NewSource(1)), 30)
+	if len(ps.Deals) != 30 {
+		t.Fatalf("got %d deals, want 30", len(ps.Deals))
+	}
+	if n := ps.Update(MaxProdEvaluator{}); n != 0 {
+		t.Errorf("the first Update returned %d, want 0", n)
+	}
+	if n := ps.Update(MaxProdEvaluator{}); n != 0 {
+		t.Errorf("Update with the same evaluator returned %d, want 0", n)
+	}
+	// The changes are the deals the two evaluators play differently.
+	want := 0
+	for _, c := range ps.Deals {
+		a, _ := Play(c, MaxProdEvaluator{})
+		b, _ := Play(c, DefaultHeuristic)
+		if a.ranks() != b.ranks() {
+			want++
+		}
+	}
+	if want == 0 {
+		t.Fatal("the evaluators play every deal the same way")
+	}
+	changes := ps.UpdateChanges(DefaultHeuristic)
+	if len(changes) != want {
+		t.Errorf("got %d changes, want %d", len(changes), want)
+	}
+	for _, ch := range changes {
+		old, _ := Play(ps.Deals[ch.Deal], MaxProdEvaluator{})
+		nw, _ := Play(ps.Deals[ch.Deal], DefaultHeuristic)
+		if ch.Old != old || ch.New != nw {
+			t.Errorf("deal %d changed from %s to %s, want %s to %s", ch.Deal, &ch.Old, &ch.New, &old, &nw)
+		}
+	}
+	var b bytes.Buffer
+	if err := WriteChanges(&b, changes); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(b.String(), "\n"); got != 3*len(changes) {
+		t.Errorf("the report of %d changes has %d lines:\n%s", len(changes), got, b.String())
+	}
+
+	bps := NewBenchmarkProbeSet(5)
+	if len(bps.Deals) != 10 {
+		t.Errorf("the benchmark probe set has %d deals, want 10", len(bps.Deals))
+	}
+}
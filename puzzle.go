@@ -0,0 +1,106 @@
+package cpoker
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// PuzzleMinMargin is how much better (in EV) the solution to a puzzle
+// must be than the next best arrangement.
+const PuzzleMinMargin = 0.2
+
+// puzzleAttempts is how many deals are tried when looking for a puzzle.
+const puzzleAttempts = 1000
+
+// ErrNoPuzzle is returned by DailyPuzzle when none of the deals it
+// tries is a puzzle.
+var ErrNoPuzzle = errors.New("no deal is a puzzle")
+
+// A Puzzle is a deal with a clearly best arrangement, which a naive
+// player gets wrong.
+type Puzzle struct {
+	Date     string       // The day of the puzzle, as YYYY-MM-DD
+	Cards    []poker.Card // The 13 cards dealt
+	Solution Hand         // The best arrangement
+	Margin   float64      // How much better the solution is than the runner-up
+}
+
+// bestTwo returns the best arrangement of c and how much better its
+// value is than the next best. Only legal arrangements which aren't
+// dominated (see Arrangements) are compared. If there's no other such
+// arrangement, there's no runner-up, and ok is false.
+func bestTwo(c []poker.Card, he HandEvaluator) (best Arrangement, margin float64, ok bool) {
+	evaluator := he.Evaluator(c)
+	bestEV, nextEV := math.Inf(-1), math.Inf(-1)
+	as := Arrangements(c)
+	for _, a := range as {
+		ev := evaluator(a.Ranks[0], a.Ranks[1], a.Ranks[2])
+		if ev > bestEV {
+			best, bestEV, nextEV = a, ev, bestEV
+		} else if ev > nextEV {
+			nextEV = ev
+		}
+	}
+	if len(as) < 2 {
+		return best, 0, false
+	}
+	return best, bestEV - nextEV, true
+}
+
+// DailyPuzzle returns the puzzle for the given day. The same day
+// always gives the same deal (for the same evaluator). The puzzle is
+// a deal where the best arrangement, according to he, is better than
+// the runner-up by at least PuzzleMinMargin, and where the best
+// arrangement differs from that of MaxProdEvaluator, so it's not
+// obvious. If no such deal is found quickly, the non-obvious deal with
+// the largest margin is used. If none of the deals tried is
+// non-obvious, it returns ErrNoPuzzle.
+func DailyPuzzle(day time.Time, he HandEvaluator) (Puzzle, error) {
+	return dailyPuzzle(day, he, puzzleAttempts)
+}
+
+// dailyPuzzle is DailyPuzzle, trying the given number of deals.
+func dailyPuzzle(day time.Time, he HandEvaluator, attempts int) (Puzzle, error) {
+	y, m, d := day.Date()
+	rnd := rand.New(rand.NewSource(int64(y*10000 + int(m)*100 + d)))
+	p := Puzzle{Date: day.Format("2006-01-02"), Margin: -1}
+	cards := append([]poker.Card{}, poker.Cards...)
+	for i := 0; i < attempts && p.Margin < PuzzleMinMargin; i++ {
+		for j := 0; j < 13; j++ {
+			k := rnd.Intn(52-j) + j
+			cards[j], cards[k] = cards[k], cards[j]
+		}
+		c := cards[:13]
+		best, margin, ok := bestTwo(c, he)
+		if !ok || margin <= p.Margin {
+			// A deal with only one sensible arrangement isn't a puzzle.
+			continue
+		}
+		naive, _ := Play(c, MaxProdEvaluator{})
+		if naive.ranks() == best.Ranks {
+			continue
+		}
+		p.Cards = append([]poker.Card{}, c...)
+		p.Solution, p.Margin = best.Hand, margin
+	}
+	if p.Cards == nil {
+		return Puzzle{}, ErrNoPuzzle
+	}
+	return p, nil
+}
+
+// WriteJSON writes the puzzle, including its solution, as JSON.
+func (p *Puzzle) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(struct {
+		Date     string   `json:"date"`
+		Cards    []string `json:"cards"`
+		Solution Hand     `json:"solution"`
+		Margin   float64  `json:"margin"`
+	}{p.Date, cardNames(p.Cards), p.Solution, p.Margin})
+}
@@ -0,0 +1,77 @@
+package cpoker
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestDailyPuzzle(t *testing.T) {
+	se := smallSampledEvaluator(t, 100)
+	day := time.Date(2020, 3, 14, 9, 0, 0, 0, time.UTC)
+	p, err := DailyPuzzle(day, se)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Date != "2020-03-14" {
+		t.Errorf("puzzle has date %s, want 2020-03-14", p.Date)
+	}
+	// The same day gives the same puzzle, whatever the time.
+	q, err := DailyPuzzle(day.Add(12*time.Hour), se)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Solution != q.Solution || p.Margin != q.Margin || len(p.Cards) != len(q.Cards) {
+		t.Errorf("the same day gave different puzzles: %s and %s", &p.Solution, &q.Solution)
+	}
+	// The solution and margin are the ones for the cards dealt, and
+	// the solution isn't what a naive player would play.
+	if err := CheckHand(&p.Solution, p.Cards); err != nil {
+		t.Fatal(err)
+	}
+	best, margin, ok := bestTwo(p.Cards, se)
+	if !ok || best.Hand != p.Solution || margin != p.Margin || margin < 0 {
+		t.Errorf("puzzle %v has solution %s with margin %f, want %s with margin %f", p.Cards, &p.Solution, p.Margin, &best.Hand, margin)
+	}
+	if naive, _ := Play(p.Cards, MaxProdEvaluator{}); naive.ranks() == best.Ranks {
+		t.Errorf("puzzle %v is solved by MaxProdEvaluator", p.Cards)
+	}
+	// MaxProdEvaluator's best arrangement is always the naive one, so
+	// it never finds a puzzle.
+	if _, err := dailyPuzzle(day, MaxProdEvaluator{}, 20); err != ErrNoPuzzle {
+		t.Errorf("DailyPuzzle with MaxProdEvaluator returned error %v, want %v", err, ErrNoPuzzle)
+	}
+}
+
+func TestBestTwo(t *testing.T) {
+	// Every other arrangement of these cards is dominated by one.
+	c := mustCards(t, "H3C6H4D2S9D9CJSTSKD5SQSAHJ")
+	if n := len(Arrangements(c)); n != 1 {
+		t.Fatalf("%v has %d arrangements, want 1", c, n)
+	}
+	best, margin, ok := bestTwo(c, MaxProdEvaluator{})
+	if want, _ := Play(c, MaxProdEvaluator{}); ok || margin != 0 || best.Ranks != want.ranks() {
+		t.Errorf("bestTwo = %s, %f, %v; want %s with no runner-up", &best.Hand, margin, ok, &want)
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 5; i++ {
+		c := randomDeal(rnd)
+		ev := MaxProdEvaluator{}.Evaluator(c)
+		best, margin, ok := bestTwo(c, MaxProdEvaluator{})
+		as := Arrangements(c)
+		if !ok || margin < 0 || margin > 1e6 || len(as) < 2 {
+			t.Fatalf("bestTwo(%v) has margin %f, %v, with %d arrangements", c, margin, ok, len(as))
+		}
+		next := 0
+		for _, a := range as {
+			gap := ev(best.Ranks[0], best.Ranks[1], best.Ranks[2]) - ev(a.Ranks[0], a.Ranks[1], a.Ranks[2])
+			if a.Ranks != best.Ranks && math.Abs(gap-margin) < 1e-9 {
+				next++
+			}
+		}
+		if next == 0 {
+			t.Errorf("bestTwo(%v): no arrangement is worse than the best by the margin %f", c, margin)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package cpoker
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+)
+
+// A Rating is the rating of a player (an evaluator, engine or human).
+// With Glicko ratings, RD is the rating deviation: how uncertain the
+// rating is.
+type Rating struct {
+	Elo   float64
+	Games int
+	RD    float64 `json:",omitempty"`
+}
+
+// Ratings are the ratings of a set of players, keyed by name. They're
+// Elo ratings, unless Glicko is set, in which case they're Glicko
+// ratings, which move less as players' ratings become more certain.
+type Ratings struct {
+	K         float64            // How much a single result moves an Elo rating
+	Initial   float64            // The rating of a new player
+	Glicko    bool               `json:",omitempty"` // Whether the ratings are Glicko ratings
+	InitialRD float64            `json:",omitempty"` // With Glicko, the rating deviation of a new player
+	Players   map[string]*Rating // The players' ratings
+}
+
+// NewRatings returns an empty set of Elo ratings, with conventional
+// constants.
+func NewRatings() *Ratings {
+	return &Ratings{K: 32, Initial: 1500, Players: map[string]*Rating{}}
+}
+
+// NewGlickoRatings returns an empty set of Glicko ratings, with
+// conventional constants.
+func NewGlickoRatings() *Ratings {
+	return &Ratings{Initial: 1500, Glicko: true, InitialRD: 350, Players: map[string]*Rating{}}
+}
+
+func (r *Ratings) get(name string) *Rating {
+	p, ok := r.Players[name]
+	if !ok {
+		p = &Rating{Elo: r.Initial}
+		r.Players[name] = p
+	}
+	if r.Glicko && p.RD == 0 {
+		p.RD = r.InitialRD
+	}
+	return p
+}
+
+// glickoQ is the constant q of the Glicko system.
+var glickoQ = math.Ln10 / 400
+
+// glickoG is the function g of the Glicko system, which reduces the
+// weight of a result against an opponent whose rating is uncertain.
+func glickoG(rd float64) float64 {
+	return 1 / math.Sqrt(1+3*glickoQ*glickoQ*rd*rd/(math.Pi*math.Pi))
+}
+
+// Expected returns the expected result (from 0 to 1) of a match between
+// a and b, from a's point of view.
+func (r *Ratings) Expected(a, b string) float64 {
+	pa, pb := r.get(a), r.get(b)
+	g := 1.0
+	if r.Glicko {
+		g = glickoG(pb.RD)
+	}
+	return 1 / (1 + math.Pow(10, -g*(pa.Elo-pb.Elo)/400))
+}
+
+// Update records the result of a match between a and b. The result
+// is from a's point of view: 1 for a win, 0.5 for a draw and 0 for a loss.
+// a and b must be different players, or nothing is recorded.
+func (r *Ratings) Update(a, b string, result float64) {
+	if a == b {
+		return
+	}
+	if r.Glicko {
+		ea, eb := r.Expected(a, b), r.Expected(b, a)
+		pa, pb := r.get(a), r.get(b)
+		ra, rda := glickoUpdate(pa.RD, pb.RD, ea, result)
+		rb, rdb := glickoUpdate(pb.RD, pa.RD, eb, 1-result)
+		pa.Elo, pa.RD = pa.Elo+ra, rda
+		pb.Elo, pb.RD = pb.Elo+rb, rdb
+	} else {
+		delta := r.K * (result - r.Expected(a, b))
+		r.get(a).Elo += delta
+		r.get(b).Elo -= delta
+	}
+	r.get(a).Games++
+	r.get(b).Games++
+}
+
+// glickoUpdate returns how much a player's Glicko rating changes, and
+// their new rating deviation, after a match with the result s against
+// an opponent with rating deviation oppRD, when e was the expected
+// result.
+func glickoUpdate(rd, oppRD, e, s float64) (delta, newRD float64) {
+	g := glickoG(oppRD)
+	d2 := 1 / (glickoQ * glickoQ * g * g * e * (1 - e))
+	v := 1 / (1/(rd*rd) + 1/d2)
+	return glickoQ * v * g * (s - e), math.Sqrt(v)
+}
+
+// MatchResult converts the points a player won in a match (for
+// example, from a Comparison) into a match result for Update.
+func MatchResult(points float64) float64 {
+	if points > 0 {
+		return 1
+	} else if points < 0 {
+		return 0
+	}
+	return 0.5
+}
+
+// Names returns the names of the rated players, best first.
+func (r *Ratings) Names() []string {
+	var names []string
+	for n := range r.Players {
+		names = append(names, n)
+	}
+	sort.Slice(names, func(i, j int) bool { return r.Players[names[i]].Elo > r.Players[names[j]].Elo })
+	return names
+}
+
+// Save writes the ratings to a named file, as JSON. The file is
+// replaced atomically, so a crash can't leave it truncated.
+func (r *Ratings) Save(filename string) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, b)
+}
+
+// LoadRatings reads ratings written by Save. If the file doesn't
+// exist, empty Elo ratings are returned.
+func LoadRatings(filename string) (*Ratings, error) {
+	r := NewRatings()
+	b, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return r, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
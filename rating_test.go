@@ -0,0 +1,77 @@
+package cpoker
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRatings(t *testing.T) {
+	r := NewRatings()
+	if e := r.Expected("a", "b"); e != 0.5 {
+		t.Errorf("expected result between new players is %f, want 0.5", e)
+	}
+	r.Update("a", "b", MatchResult(3))
+	a, b := r.Players["a"], r.Players["b"]
+	if a.Elo != 1516 || b.Elo != 1484 || a.Games != 1 || b.Games != 1 {
+		t.Errorf("after a beat b, got a %+v and b %+v", a, b)
+	}
+	if ea, eb := r.Expected("a", "b"), r.Expected("b", "a"); ea <= 0.5 || math.Abs(ea+eb-1) > 1e-12 {
+		t.Errorf("expected results are %f and %f, want a favoured, and them to sum to 1", ea, eb)
+	}
+	// Losing a rematch by the same margin evens the ratings out again,
+	// less what the favourite was expected to win by.
+	r.Update("b", "a", MatchResult(3))
+	if math.Abs(a.Elo+b.Elo-3000) > 1e-9 || a.Elo >= b.Elo {
+		t.Errorf("after b beat a back, got a %+v and b %+v", a, b)
+	}
+	r.Update("a", "a", 1)
+	if a.Games != 2 {
+		t.Errorf("a played itself, and has played %d games, want 2", a.Games)
+	}
+	if got := r.Names(); !reflect.DeepEqual(got, []string{"b", "a"}) {
+		t.Errorf("names are %v, want b then a", got)
+	}
+
+	dir, err := ioutil.TempDir("", "ratings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "ratings.json")
+	if empty, err := LoadRatings(filename); err != nil || len(empty.Players) != 0 {
+		t.Errorf("loading missing ratings got %v, %v; want empty ratings", empty, err)
+	}
+	if err := r.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadRatings(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, r) {
+		t.Errorf("loaded %+v, want %+v", got, r)
+	}
+}
+
+func TestGlickoRatings(t *testing.T) {
+	r := NewGlickoRatings()
+	r.Update("a", "b", 1)
+	a, b := r.Players["a"], r.Players["b"]
+	if a.Elo <= 1500 || math.Abs(a.Elo+b.Elo-3000) > 1e-9 {
+		t.Errorf("after a beat b, got a %+v and b %+v", a, b)
+	}
+	if a.RD >= 350 || a.RD != b.RD {
+		t.Errorf("after a match between new players, their deviations are %f and %f, want them equal and less than 350", a.RD, b.RD)
+	}
+	// A result moves a rating less once it's more certain.
+	first := a.Elo - 1500
+	r.Update("c", "d", 1)
+	r.Update("a", "d", 1)
+	if gain := r.Players["c"].Elo - 1500; gain <= r.Players["a"].Elo-1500-first {
+		t.Errorf("a new player gained %f for a win, but a rated player gained %f", gain, r.Players["a"].Elo-1500-first)
+	}
+}
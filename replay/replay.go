@@ -0,0 +1,134 @@
+// Binary replay replays the deals from a player's hand history with
+// an evaluator or engine, and reports how much the engine would have
+// won with the player's cards against the same opponent hands. The
+// history has one JSON object per line, with "hand" and "opponent"
+// fields (see cpoker.HistoryHand).
+// For example:
+//
+//	replay -history mine.jsonl -from coefficients.data
+//	replay -history mine.jsonl -engine "engine -from coefficients.data"
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/paulhankin/cpoker"
+	"github.com/paulhankin/cpoker/client"
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+var (
+	historyFile = flag.String("history", "", "the hand history to replay")
+	fromFile    = flag.String("from", "", "file to load the evaluator from")
+	engineCmd   = flag.String("engine", "", "if set, instead of -from, the command line to run an engine to play the hands")
+	startTime   = flag.Duration("start_time", 2*time.Minute, "how long the engine may take to start up")
+	top         = flag.Int("top", 10, "how many of the hands with the largest gaps to show")
+	scoring     = flag.String("scoring", "2-4", "how to score hands: "+strings.Join(cpoker.ScoringNames(), ", "))
+	luck        = flag.Bool("luck", false, "instead of replaying, split each player's results into deal strength, decisions and matchup luck, valuing hands with the evaluator from -from")
+	outSpec     = flag.String("out", "", "if set, also write the report (or with -luck, a record for each player) to this output, which looks like jsonl://path")
+)
+
+func main() {
+	flag.Parse()
+	if *historyFile == "" {
+		log.Fatalf("-history must be specified")
+	}
+	sc, err := cpoker.ScoringByName(*scoring)
+	if err != nil {
+		log.Fatalf("bad -scoring: %s", err)
+	}
+	f, err := os.Open(*historyFile)
+	if err != nil {
+		log.Fatalf("failed to open history: %s", err)
+	}
+	hands, err := cpoker.ReadHandHistory(f)
+	f.Close()
+	if err != nil {
+		log.Fatalf("failed to read history: %s", err)
+	}
+	if *luck {
+		if *fromFile == "" {
+			log.Fatalf("-luck needs -from")
+		}
+		se, err := cpoker.LoadEvaluatorFile(*fromFile)
+		if err != nil {
+			log.Fatalf("failed to load evaluator: %s", err)
+		}
+		ls, err := cpoker.DecomposeLuck(hands, se, sc)
+		if err != nil {
+			log.Fatalf("failed to analyze history: %s", err)
+		}
+		var records []interface{}
+		for _, l := range ls {
+			fmt.Println(&l)
+			records = append(records, l)
+		}
+		writeOutput("luck", records...)
+		return
+	}
+	r, err := replay(hands, sc)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(r)
+	writeOutput("result", r)
+}
+
+// replay replays the history with the engine from -engine, or the
+// evaluator from -from. It returns errors rather than exiting, so that
+// the engine is always shut down.
+func replay(hands []cpoker.HistoryHand, sc *cpoker.Scoring) (*cpoker.ReplayReport, error) {
+	var play func(c []poker.Card) (cpoker.Hand, error)
+	if *engineCmd != "" {
+		cl, err := client.Start(*engineCmd, *startTime)
+		if err != nil {
+			return nil, fmt.Errorf("failed to start engine: %s", err)
+		}
+		defer cl.Close()
+		play = func(c []poker.Card) (cpoker.Hand, error) {
+			return cl.Play(c, client.Options{})
+		}
+	} else {
+		if *fromFile == "" {
+			return nil, fmt.Errorf("one of -from or -engine must be specified")
+		}
+		he, err := cpoker.LoadEvaluatorFile(*fromFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load evaluator: %s", err)
+		}
+		play = func(c []poker.Card) (cpoker.Hand, error) {
+			h, _, err := cpoker.PlayE(c, he)
+			return h, err
+		}
+	}
+	r, err := cpoker.ReplayHistory(hands, play, sc, *top)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay history: %s", err)
+	}
+	return r, nil
+}
+
+// writeOutput writes records of the given type to the -out output, if
+// there is one.
+func writeOutput(typ string, records ...interface{}) {
+	if *outSpec == "" {
+		return
+	}
+	out, err := cpoker.OpenOutput(*outSpec)
+	if err != nil {
+		log.Fatalf("failed to open -out: %s", err)
+	}
+	for _, r := range records {
+		if err := out.Write(typ, r); err != nil {
+			log.Fatalf("failed to write output: %s", err)
+		}
+	}
+	if err := out.Close(); err != nil {
+		log.Fatalf("failed to write output: %s", err)
+	}
+}
@@ -0,0 +1,122 @@
+package cpoker
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// A RolloutCache remembers the results of a RolloutEvaluator's per-hand
+// rollouts, so that revisiting a deal (for example, when analyzing the
+// same hands several times) doesn't repeat the rollout. Deals are keyed
+// by their cards, ignoring order. A cache must only be used by one
+// RolloutEvaluator, since the results depend on its opponent.
+// Rollouts with dead cards are keyed by the dealt and dead cards
+// together, since the opponent is never dealt either.
+type RolloutCache struct {
+	// If MaxEntries is positive, the least recently used rollouts are
+	// dropped when there are more than this many.
+	MaxEntries int
+	// If TTL is positive, rollouts are redone when they're older than this.
+	TTL time.Duration
+
+	mu      sync.Mutex
+	entries map[CardSet]*list.Element
+	lru     list.List // of *rolloutEntry, most recently used first
+	hits    int
+	misses  int
+	now     func() time.Time // for testing
+}
+
+type rolloutEntry struct {
+	key    CardSet
+	played [][3]int16
+	wins   [3][]float64
+	added  time.Time
+}
+
+// NewRolloutCache returns a cache holding at most maxEntries rollouts,
+// each for at most ttl. Either may be zero for no limit.
+func NewRolloutCache(maxEntries int, ttl time.Duration) *RolloutCache {
+	return &RolloutCache{MaxEntries: maxEntries, TTL: ttl}
+}
+
+// Stats returns how many rollouts were found in the cache, and how
+// many had to be done.
+func (rc *RolloutCache) Stats() (hits, misses int) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.hits, rc.misses
+}
+
+// Len returns how many rollouts are in the cache, including any
+// which have expired but not yet been dropped.
+func (rc *RolloutCache) Len() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.lru.Len()
+}
+
+func (rc *RolloutCache) time() time.Time {
+	if rc.now != nil {
+		return rc.now()
+	}
+	return time.Now()
+}
+
+func (rc *RolloutCache) get(k CardSet) (played [][3]int16, wins [3][]float64, ok bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	el := rc.entries[k]
+	if el != nil {
+		e := el.Value.(*rolloutEntry)
+		if rc.TTL <= 0 || rc.time().Sub(e.added) < rc.TTL {
+			rc.hits++
+			rc.lru.MoveToFront(el)
+			return e.played, e.wins, true
+		}
+		rc.lru.Remove(el)
+		delete(rc.entries, k)
+	}
+	rc.misses++
+	return nil, wins, false
+}
+
+func (rc *RolloutCache) put(k CardSet, played [][3]int16, wins [3][]float64) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.entries == nil {
+		rc.entries = map[CardSet]*list.Element{}
+	}
+	if el := rc.entries[k]; el != nil {
+		rc.lru.Remove(el)
+	}
+	rc.entries[k] = rc.lru.PushFront(&rolloutEntry{key: k, played: played, wins: wins, added: rc.time()})
+	for rc.MaxEntries > 0 && rc.lru.Len() > rc.MaxEntries {
+		el := rc.lru.Back()
+		rc.lru.Remove(el)
+		delete(rc.entries, el.Value.(*rolloutEntry).key)
+	}
+}
+
+// cachedRollout rolls out the opponent's play, never dealing them the
+// cards cs or dead, using the evaluator's cache if it has one.
+func (re *RolloutEvaluator) cachedRollout(cs, dead []poker.Card) (played [][3]int16, wins [3][]float64) {
+	all := append(append([]poker.Card{}, cs...), dead...)
+	if re.Cache == nil {
+		played, _, wins = re.rollout(all)
+		return played, wins
+	}
+	var k CardSet
+	for _, c := range all {
+		k = k.Add(c)
+	}
+	if played, wins, ok := re.Cache.get(k); ok {
+		return played, wins
+	}
+	played, _, wins = re.rollout(all)
+	re.Cache.put(k, played, wins)
+	return played, wins
+}
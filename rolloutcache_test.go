@@ -0,0 +1,52 @@
+package cpoker
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+func TestRolloutCache(t *testing.T) {
+	rnd := rand.New(rand.NewSource(6))
+	cards := append([]poker.Card{}, poker.Cards...)
+	rnd.Shuffle(len(cards), func(i, j int) { cards[i], cards[j] = cards[j], cards[i] })
+	now := time.Unix(0, 0)
+	cache := NewRolloutCache(2, time.Minute)
+	cache.now = func() time.Time { return now }
+	re := &RolloutEvaluator{Separable: true, Opponent: MaxProdEvaluator{}, N: 20, Cache: cache}
+	c0, c1, c2 := cards[:13], cards[13:26], cards[26:39]
+	reversed := make([]poker.Card, 13)
+	for i := range c0 {
+		reversed[12-i] = c0[i]
+	}
+	steps := []struct {
+		cards        []poker.Card
+		hits, misses int
+		advance      time.Duration
+		entries      int
+	}{
+		{c0, 0, 1, 0, 1},
+		{reversed, 1, 1, 0, 1}, // the same cards in a different order
+		{c1, 1, 2, 0, 2},
+		{c0, 2, 2, 0, 2},
+		{c2, 2, 3, 0, 2}, // c1 is the least recently used, so is dropped
+		{c1, 2, 4, 0, 2},
+		{c1, 2, 5, 2 * time.Minute, 2}, // expired
+	}
+	for i, w := range steps {
+		now = now.Add(w.advance)
+		re.Evaluator(w.cards)
+		if hits, misses := cache.Stats(); hits != w.hits || misses != w.misses {
+			t.Errorf("%d: got %d hits and %d misses, want %d and %d", i, hits, misses, w.hits, w.misses)
+		}
+		if cache.Len() != w.entries {
+			t.Errorf("%d: cache has %d rollouts, want %d", i, cache.Len(), w.entries)
+		}
+	}
+	re.EvaluatorWithDead(c0, c1[:3])
+	if _, misses := cache.Stats(); misses != 6 {
+		t.Errorf("rollout with dead cards used the cached rollout without them")
+	}
+}
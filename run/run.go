@@ -0,0 +1,59 @@
+// Binary run runs an analysis described by a YAML scenario file (see
+// cpoker.Scenario), and prints the results as JSON. With -out, the
+// results are also written as a record to that output, as the scenario's
+// outputs get records of the plays.
+// For example:
+//
+//	run -scenario analysis.yaml -out jsonl://results.jsonl
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/paulhankin/cpoker"
+)
+
+var (
+	scenarioFile = flag.String("scenario", "", "the scenario file to run")
+	outSpec      = flag.String("out", "", "if set, write a record of the results to this output, which looks like jsonl://path")
+)
+
+func main() {
+	flag.Parse()
+	if *scenarioFile == "" {
+		log.Fatalf("-scenario must be specified")
+	}
+	f, err := os.Open(*scenarioFile)
+	if err != nil {
+		log.Fatalf("failed to open scenario: %s", err)
+	}
+	s, err := cpoker.ReadScenario(f)
+	f.Close()
+	if err != nil {
+		log.Fatalf("bad scenario %s: %s", *scenarioFile, err)
+	}
+	result, err := cpoker.RunScenario(s)
+	if err != nil {
+		log.Fatalf("scenario failed: %s", err)
+	}
+	var out *cpoker.RecordWriter
+	if *outSpec != "" {
+		if out, err = cpoker.OpenOutput(*outSpec); err != nil {
+			log.Fatalf("failed to open -out: %s", err)
+		}
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(result); err != nil {
+		log.Fatalf("failed to print results: %s", err)
+	}
+	if err := out.Write("result", result); err != nil {
+		log.Fatalf("failed to write output: %s", err)
+	}
+	if err := out.Close(); err != nil {
+		log.Fatalf("failed to write output: %s", err)
+	}
+}
@@ -0,0 +1,244 @@
+package cpoker
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+
+	"github.com/paulhankin/poker/v2/poker"
+	"gopkg.in/yaml.v3"
+)
+
+// A Scenario describes an analysis run, so it can be kept in a file
+// and repeated. Scenarios are written as YAML, for example:
+//
+//	name: trained vs simple
+//	seed: 1
+//	evaluators:
+//	  trained: coefficients.data
+//	  simple: ""
+//	deals:
+//	  - HA HK HQ HJ HT C2 D3 S4 C5 D7 S8 CT DJ
+//	constraints:
+//	  - {cards: SA SK SQ, deals: 5}
+//	random_deals: 10
+//	samples: 1000
+//	compare: {hero: trained, villain: simple, hands: 1000}
+//	scoring: 1-6
+//	outputs:
+//	  - jsonl://plays.jsonl
+//
+// Evaluators are loaded with LoadEvaluatorFile, except that an empty
+// filename means MaxProdEvaluator. Every deal (the listed deals, then
+// the constrained deals, then the random deals) is played by every
+// evaluator. A constraint deals 13 cards that include the given cards.
+// If samples is positive, each play's showdown odds against one
+// opponent playing the same way are estimated from that many deals.
+// Each output (see OpenOutput) gets a "play" record for every play,
+// and a "comparison" record if there's a comparison.
+type Scenario struct {
+	Name        string               `yaml:"name"`
+	Seed        int64                `yaml:"seed"`
+	Evaluators  map[string]string    `yaml:"evaluators"`
+	Deals       []string             `yaml:"deals"`
+	Constraints []ScenarioConstraint `yaml:"constraints"`
+	RandomDeals int                  `yaml:"random_deals"`
+	Samples     int                  `yaml:"samples"`
+	Scoring     string               `yaml:"scoring"`
+	Compare     *ScenarioCompare     `yaml:"compare"`
+	Outputs     []string             `yaml:"outputs"`
+}
+
+// A ScenarioConstraint asks for deals which include some cards.
+type ScenarioConstraint struct {
+	Cards string `yaml:"cards"`
+	Deals int    `yaml:"deals"`
+}
+
+// A ScenarioCompare is a comparison of two of a scenario's evaluators.
+type ScenarioCompare struct {
+	Hero    string `yaml:"hero"`
+	Villain string `yaml:"villain"`
+	Hands   int    `yaml:"hands"`
+}
+
+// A ScenarioPlay is how an evaluator played a deal.
+type ScenarioPlay struct {
+	Deal      int    `json:"deal"`
+	Evaluator string `json:"evaluator"`
+	Hand      Hand   `json:"hand"`
+	Odds      *Odds  `json:"odds,omitempty"`
+}
+
+// A ScenarioResult is the outcome of running a scenario.
+type ScenarioResult struct {
+	Name       string         `json:"name"`
+	Deals      []string       `json:"deals"`
+	Plays      []ScenarioPlay `json:"plays"`
+	Comparison *Comparison    `json:"comparison,omitempty"`
+}
+
+// ReadScenario reads a YAML scenario, and checks it makes sense.
+// Since JSON is YAML, it reads JSON scenarios too.
+func ReadScenario(r io.Reader) (*Scenario, error) {
+	var s Scenario
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&s); err != nil {
+		return nil, err
+	}
+	if len(s.Evaluators) == 0 {
+		return nil, errors.New("scenario has no evaluators")
+	}
+	if s.RandomDeals < 0 {
+		return nil, fmt.Errorf("random_deals is %d, want at least 0", s.RandomDeals)
+	}
+	if s.Samples < 0 {
+		return nil, fmt.Errorf("samples is %d, want at least 0", s.Samples)
+	}
+	if s.Scoring != "" {
+		if _, err := ScoringByName(s.Scoring); err != nil {
+			return nil, err
+		}
+	}
+	for i, d := range s.Deals {
+		c, err := ParseCards(d)
+		if err != nil {
+			return nil, fmt.Errorf("deal %d: %s", i, err)
+		}
+		if err := checkDeal(c); err != nil {
+			return nil, fmt.Errorf("deal %d: %s", i, err)
+		}
+	}
+	for i, con := range s.Constraints {
+		c, err := ParseCards(con.Cards)
+		if err != nil {
+			return nil, fmt.Errorf("constraint %d: %s", i, err)
+		}
+		if _, err := DealConstrained(nil, [][]poker.Card{c}); err != nil {
+			return nil, fmt.Errorf("constraint %d: %s", i, err)
+		}
+		if con.Deals <= 0 {
+			return nil, fmt.Errorf("constraint %d: deals is %d, want at least 1", i, con.Deals)
+		}
+	}
+	for _, spec := range s.Outputs {
+		if _, err := outputPath(spec); err != nil {
+			return nil, err
+		}
+	}
+	if cmp := s.Compare; cmp != nil {
+		for _, name := range []string{cmp.Hero, cmp.Villain} {
+			if _, ok := s.Evaluators[name]; !ok {
+				return nil, fmt.Errorf("compare: no evaluator %q", name)
+			}
+		}
+		if cmp.Hands <= 0 {
+			return nil, fmt.Errorf("compare: hands is %d, want at least 1", cmp.Hands)
+		}
+	}
+	return &s, nil
+}
+
+// RunScenario runs a scenario which has been read with ReadScenario.
+// Random deals and comparisons use random sources seeded from the
+// scenario, so running it again gives the same results.
+func RunScenario(s *Scenario) (*ScenarioResult, error) {
+	evaluators := map[string]HandEvaluator{}
+	var names []string
+	for name, filename := range s.Evaluators {
+		if filename == "" {
+			evaluators[name] = MaxProdEvaluator{}
+		} else {
+			he, err := LoadEvaluatorFile(filename)
+			if err != nil {
+				return nil, fmt.Errorf("evaluator %q: %s", name, err)
+			}
+			evaluators[name] = he
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	rnd := rand.New(rand.NewSource(s.Seed))
+	deals := append([]string{}, s.Deals...)
+	for _, con := range s.Constraints {
+		fixed, err := ParseCards(con.Cards)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < con.Deals; i++ {
+			hands, err := DealConstrained(rnd, [][]poker.Card{fixed})
+			if err != nil {
+				return nil, err
+			}
+			deals = append(deals, poker.Hand(hands[0]).String())
+		}
+	}
+	cards := append([]poker.Card{}, poker.Cards...)
+	for i := 0; i < s.RandomDeals; i++ {
+		for j := 0; j < 13; j++ {
+			k := rnd.Intn(52-j) + j
+			cards[j], cards[k] = cards[k], cards[j]
+		}
+		deals = append(deals, poker.Hand(cards[:13]).String())
+	}
+	result := &ScenarioResult{Name: s.Name, Deals: deals}
+	for i, d := range deals {
+		c, err := ParseCards(d)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			h, _, err := PlayE(c, evaluators[name])
+			if err != nil {
+				return nil, fmt.Errorf("deal %d: %s", i, err)
+			}
+			play := ScenarioPlay{Deal: i, Evaluator: name, Hand: h}
+			if s.Samples > 0 {
+				odds, err := showdownOdds(rnd, &h, 1, evaluators[name], s.Samples)
+				if err != nil {
+					return nil, fmt.Errorf("deal %d: %s", i, err)
+				}
+				play.Odds = &odds
+			}
+			result.Plays = append(result.Plays, play)
+		}
+	}
+	if cmp := s.Compare; cmp != nil {
+		opts := CompareOptions{}
+		if s.Scoring != "" {
+			opts.Scoring, _ = ScoringByName(s.Scoring)
+		}
+		opts.Rand = rand.New(rand.NewSource(s.Seed))
+		c := CompareEvaluatorsWithOptions(evaluators[cmp.Hero], evaluators[cmp.Villain], cmp.Hands, 0, opts)
+		result.Comparison = &c
+	}
+	for _, spec := range s.Outputs {
+		if err := writeScenarioOutput(spec, result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func writeScenarioOutput(spec string, result *ScenarioResult) error {
+	rw, err := OpenOutput(spec)
+	if err != nil {
+		return err
+	}
+	for _, p := range result.Plays {
+		if err := rw.Write("play", p); err != nil {
+			rw.Close()
+			return err
+		}
+	}
+	if result.Comparison != nil {
+		if err := rw.Write("comparison", result.Comparison); err != nil {
+			rw.Close()
+			return err
+		}
+	}
+	return rw.Close()
+}
@@ -0,0 +1,104 @@
+package cpoker
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunScenario(t *testing.T) {
+	s, err := ReadScenario(strings.NewReader(`{
+		"name": "test",
+		"seed": 1,
+		"evaluators": {"a": "", "b": ""},
+		"deals": ["HA HK HQ HJ HT C2 D3 S4 C5 D7 S8 CT DJ"],
+		"random_deals": 2,
+		"scoring": "1-6",
+		"compare": {"hero": "a", "villain": "b", "hands": 3}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := RunScenario(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Deals) != 3 || len(r.Plays) != 6 {
+		t.Errorf("got %d deals and %d plays, want 3 and 6", len(r.Deals), len(r.Plays))
+	}
+	if r.Comparison == nil || r.Comparison.Played != 6 || r.Comparison.Same != 6 {
+		t.Errorf("got comparison %+v, want 6 hands all played the same", r.Comparison)
+	}
+	for _, bad := range []string{
+		"evaluators: {a: \"\"}\nsamples: -1",
+		"evaluators: {a: \"\"}\nconstraints: [{cards: HA HA, deals: 1}]",
+		"evaluators: {a: \"\"}\nconstraints: [{cards: HA, deals: 0}]",
+		"evaluators: {a: \"\"}\noutputs: [plays.jsonl]",
+		`{"evaluators": {}}`,
+		`{"evaluators": {"a": ""}, "deals": ["HA HK"]}`,
+		`{"evaluators": {"a": ""}, "compare": {"hero": "a", "villain": "c", "hands": 1}}`,
+		`{"evaluators": {"a": ""}, "scoring": "nonsense"}`,
+		`{"evaluators": {"a": ""}, "colour": "blue"}`,
+	} {
+		if _, err := ReadScenario(strings.NewReader(bad)); err == nil {
+			t.Errorf("ReadScenario(%s) succeeded", bad)
+		}
+	}
+}
+
+func TestRunScenarioYAML(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scenario")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "plays.jsonl")
+	s, err := ReadScenario(strings.NewReader(`
+name: constrained
+seed: 2
+evaluators:
+  simple: ""
+constraints:
+  - {cards: SA SK SQ, deals: 3}
+samples: 20
+outputs:
+  - jsonl://` + out + `
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := RunScenario(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Deals) != 3 || len(r.Plays) != 3 {
+		t.Fatalf("got %d deals and %d plays, want 3 and 3", len(r.Deals), len(r.Plays))
+	}
+	want := mustCards(t, "SASKSQ")
+	for i, d := range r.Deals {
+		c, err := ParseCards(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cs, _ := NewCardSet(c)
+		for _, w := range want {
+			if !cs.Contains(w) {
+				t.Errorf("deal %d is %s, which doesn't include %s", i, d, w)
+			}
+		}
+	}
+	for _, p := range r.Plays {
+		if p.Odds == nil || p.Odds.Samples != 20 {
+			t.Errorf("play %+v has odds %v, want 20 samples", p, p.Odds)
+		}
+	}
+	b, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(b), `"type":"play"`); n != 3 {
+		t.Errorf("got %d play records, want 3:\n%s", n, b)
+	}
+}
@@ -0,0 +1,195 @@
+package cpoker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// A Scoring describes how a showdown between two Chinese poker hands
+// is scored.
+type Scoring struct {
+	Name     string
+	Slot     int // points for winning each of the front, middle and back
+	Majority int // bonus for winning more slots than the opponent
+	Scoop    int // bonus for winning all three slots
+
+	// Surrender is the points a player who passes a deal, in variants
+	// which allow it, pays to each opponent who plays it (see PassPolicy).
+	Surrender int
+
+	// Royalties[i][r] is the bonus paid for a hand of rank r in slot i
+	// (0, 1, 2 means front, middle, back). Royalties are paid whether
+	// or not the slot is won, so each player scores the difference
+	// between their royalties and their opponent's. If nil, there are
+	// no royalties.
+	Royalties *[3][]int
+}
+
+// NewRoyalties constructs a royalty table for a Scoring, by calling f
+// with an example hand of every rank in each slot (0, 1, 2 means front,
+// middle, back). Ranks distinguish every kicker of a 3-card hand,
+// so house rules which pay for exact front hands (for example, 66-5
+// but not 66-4) can be expressed.
+func NewRoyalties(f func(slot int, hand []poker.Card) int) *[3][]int {
+	var r [3][]int
+	for i := 0; i < 3; i++ {
+		r[i] = make([]int, poker.ScoreMax+1)
+		toHand := poker.EvalToHand5
+		if i == 0 {
+			toHand = poker.EvalToHand3
+		}
+		for e := range r[i] {
+			if h, ok := toHand(int16(e)); ok && Reachable(i, int16(e)) {
+				r[i][e] = f(i, h)
+			}
+		}
+	}
+	return &r
+}
+
+// makeRoyalties constructs a royalty table from a function of the
+// category and most significant card of each hand.
+func makeRoyalties(f func(slot int, class HandCategory, top int) int) *[3][]int {
+	return NewRoyalties(func(slot int, hand []poker.Card) int {
+		class, top := rankShape(hand)
+		return f(slot, class, top)
+	})
+}
+
+// Scoring presets.
+var (
+	// Scoring2to4 is one point per slot, and one point for winning
+	// the majority of slots. This is the scoring used in training.
+	Scoring2to4 = &Scoring{Name: "2-4", Slot: 1, Majority: 1}
+
+	// Scoring1to6 is one point per slot, and three points for a scoop.
+	Scoring1to6 = &Scoring{Name: "1-6", Slot: 1, Scoop: 3}
+
+	// ScoringNoRoyalties is one point per slot, with no bonuses.
+	ScoringNoRoyalties = &Scoring{Name: "noroyalties", Slot: 1}
+
+	// ScoringHK is 1-6 scoring, with commonly played royalties for
+	// trips in front, a full house or better in the middle, and quads
+	// or better in the back.
+	ScoringHK = &Scoring{Name: "hk", Slot: 1, Scoop: 3, Royalties: makeRoyalties(hkRoyalty)}
+
+	// ScoringRussianOFC is 1-6 scoring with the royalties of
+	// open-face Chinese poker.
+	ScoringRussianOFC = &Scoring{Name: "ofc", Slot: 1, Scoop: 3, Royalties: makeRoyalties(ofcRoyalty)}
+)
+
+var scorings = []*Scoring{Scoring2to4, Scoring1to6, ScoringNoRoyalties, ScoringHK, ScoringRussianOFC}
+
+func hkRoyalty(slot int, class HandCategory, top int) int {
+	switch {
+	case slot == 0 && class == Trips:
+		return 3
+	case slot == 1 && class == FullHouse:
+		return 2
+	case class == Quads:
+		return [3]int{0, 8, 4}[slot]
+	case class >= StraightFlush:
+		return [3]int{0, 10, 5}[slot]
+	}
+	return 0
+}
+
+func ofcRoyalty(slot int, class HandCategory, top int) int {
+	if slot == 0 {
+		if class == Pair && top >= 4 {
+			return top - 3 // 66 pays 1, up to AA paying 9.
+		} else if class == Trips {
+			return top + 10 // 222 pays 10, up to AAA paying 22.
+		}
+		return 0
+	}
+	if class == StraightFlush && top == 12 {
+		return [3]int{0, 50, 25}[slot]
+	}
+	back := [...]int{Straight: 2, Flush: 4, FullHouse: 6, Quads: 10, StraightFlush: 15, FiveOfAKind: 15}
+	if slot == 1 {
+		if class == Trips {
+			return 2
+		}
+		return 2 * back[class]
+	}
+	return back[class]
+}
+
+// ScoringNames returns the names of the scoring presets.
+func ScoringNames() []string {
+	var r []string
+	for _, s := range scorings {
+		r = append(r, s.Name)
+	}
+	sort.Strings(r)
+	return r
+}
+
+// ScoringByName returns the scoring preset with the given name.
+func ScoringByName(name string) (*Scoring, error) {
+	for _, s := range scorings {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown scoring %q: want one of %s", name, strings.Join(ScoringNames(), ", "))
+}
+
+// showdown takes the ranks of two hands, and returns the score for the
+// first player, and the number of slots won and lost.
+func (s *Scoring) showdown(h0, h1 [3]int16) (score, wins, losses int) {
+	for i := 0; i < 3; i++ {
+		wins += b2i(h0[i] > h1[i])
+		losses += b2i(h1[i] > h0[i])
+		if s.Royalties != nil {
+			score += s.Royalties[i][h0[i]] - s.Royalties[i][h1[i]]
+		}
+	}
+	score += s.Slot * (wins - losses)
+	score += s.Majority * (b2i(wins > losses) - b2i(losses > wins))
+	score += s.Scoop * (b2i(wins == 3) - b2i(losses == 3))
+	return score, wins, losses
+}
+
+// forfeit is the points a fouled hand loses to a hand which isn't
+// fouled, before the royalties of the hand which isn't.
+func (s *Scoring) forfeit() int {
+	return 3*s.Slot + s.Majority + s.Scoop
+}
+
+// foulShowdown is like showdown, but either hand may be fouled. A
+// fouled hand loses every slot and earns no royalties, while its
+// opponent keeps theirs. If both hands are fouled, neither scores.
+func (s *Scoring) foulShowdown(h0, h1 [3]int16) (score, wins, losses int) {
+	f0, f1 := h0[0] > h0[1] || h0[1] > h0[2], h1[0] > h1[1] || h1[1] > h1[2]
+	if !f0 && !f1 {
+		return s.showdown(h0, h1)
+	} else if f0 && f1 {
+		return 0, 0, 0
+	}
+	score = s.forfeit()
+	r := h0
+	if f0 {
+		r = h1
+	}
+	if s.Royalties != nil {
+		for i := 0; i < 3; i++ {
+			score += s.Royalties[i][r[i]]
+		}
+	}
+	if f0 {
+		return -score, 0, 3
+	}
+	return score, 3, 0
+}
+
+// Score returns a score for player 0, assuming player 0 plays h0 and
+// player 1 plays h1. The function assumes both hands are legal.
+func (s *Scoring) Score(h0, h1 *Hand) int {
+	score, _, _ := s.showdown(h0.ranks(), h1.ranks())
+	return score
+}
@@ -0,0 +1,126 @@
+package cpoker
+
+import (
+	"testing"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+func mustHand(t *testing.T, front, middle, back string) *Hand {
+	t.Helper()
+	var h Hand
+	for i, part := range []string{front, middle, back} {
+		dst := [][]poker.Card{h.Front[:], h.Middle[:], h.Back[:]}[i]
+		for j := range dst {
+			c, ok := poker.NameToCard[part[2*j:2*j+2]]
+			if !ok {
+				t.Fatalf("bad card in %q", part)
+			}
+			dst[j] = c
+		}
+	}
+	return &h
+}
+
+func TestScoringPresets(t *testing.T) {
+	strong := mustHand(t, "HASACK", "C2D2H2S2C3", "HTHJHQHKH9")
+	weak := mustHand(t, "C4S5H6", "C8D8H9SJCQ", "D3D4D5D6C7")
+	mixed := mustHand(t, "C4S5H6", "C8D8H9SJCQ", "DADKDQDJDT")
+	cases := []struct {
+		s      *Scoring
+		h0, h1 *Hand
+		want   int
+	}{
+		{Scoring2to4, strong, weak, 4},
+		{Scoring2to4, mixed, strong, -2},
+		{Scoring1to6, strong, weak, 6},
+		{Scoring1to6, mixed, strong, -1},
+		{ScoringNoRoyalties, strong, weak, 3},
+		// Royalties: middle quads and back straight flush.
+		{ScoringHK, strong, weak, 6 + 8 + 5},
+		// Front AA, middle quads, back straight flush against a back straight.
+		{ScoringRussianOFC, strong, weak, 6 + 9 + 20 + 15 - 2},
+		// Back royal flush against a middle quads and a back straight flush.
+		{ScoringRussianOFC, mixed, strong, -1 + 25 - 9 - 20 - 15},
+	}
+	for _, c := range cases {
+		if got := c.s.Score(c.h0, c.h1); got != c.want {
+			t.Errorf("%s.Score(%s, %s) = %d, want %d", c.s.Name, c.h0, c.h1, got, c.want)
+		}
+		if got := c.s.Score(c.h1, c.h0); got != -c.want {
+			t.Errorf("%s.Score(%s, %s) = %d, want %d", c.s.Name, c.h1, c.h0, got, -c.want)
+		}
+	}
+}
+
+func TestScoringByName(t *testing.T) {
+	for _, name := range ScoringNames() {
+		s, err := ScoringByName(name)
+		if err != nil || s.Name != name {
+			t.Errorf("ScoringByName(%q) = %v, %v", name, s, err)
+		}
+	}
+	if _, err := ScoringByName("nonsense"); err == nil {
+		t.Errorf("ScoringByName(nonsense) succeeded, want error")
+	}
+}
+
+func TestNewRoyaltiesExactFront(t *testing.T) {
+	// A house rule paying only for 66 with a 5 kicker in front.
+	royalties := NewRoyalties(func(slot int, hand []poker.Card) int {
+		if slot != 0 {
+			return 0
+		}
+		d, _ := poker.Describe(hand)
+		return b2i(d == "66-5")
+	})
+	for _, c := range []struct {
+		front string
+		want  int
+	}{{"C6D6H5", 1}, {"C6D6H4", 0}, {"C6D6HK", 0}} {
+		f := mustCards(t, c.front)
+		if got := royalties[0][eval(f)]; got != c.want {
+			t.Errorf("royalty for %v = %d, want %d", f, got, c.want)
+		}
+	}
+}
+
+func TestSampledEvaluatorScoring(t *testing.T) {
+	se := smallSampledEvaluator(t, 100)
+	for _, h := range []*Hand{
+		mustHand(t, "HASACK", "C2D2H2S2C3", "HTHJHQHKH9"),
+		mustHand(t, "C4S5H6", "C8D8H9SJCQ", "D3D4D5D6C7"),
+	} {
+		r := h.ranks()
+		for _, s := range scorings {
+			se.Scoring = s
+			got := se.evaluateHand(r[0], r[1], r[2])
+			// Sum the score over every combination of slots won and lost,
+			// treating the slots as independent.
+			want := 0.0
+			for won := 0; won < 8; won++ {
+				p := 1.0
+				var opp [3]int16
+				for i := 0; i < 3; i++ {
+					if won&(1<<uint(i)) != 0 {
+						p *= se.wins[i][r[i]]
+						opp[i] = -1
+					} else {
+						p *= 1 - se.wins[i][r[i]]
+						opp[i] = poker.ScoreMax + 1
+					}
+				}
+				noRoyalties := *s
+				noRoyalties.Royalties = nil
+				score, _, _ := noRoyalties.showdown(r, opp)
+				want += p * float64(score)
+			}
+			if s.Royalties != nil {
+				want += float64(s.Royalties[0][r[0]] + s.Royalties[1][r[1]] + s.Royalties[2][r[2]])
+			}
+			if d := got - want; d > 1e-9 || d < -1e-9 {
+				t.Errorf("%s: evaluateHand(%v) = %f, want %f", s.Name, r, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package cpoker
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// Bootstrap returns k evaluators whose counts are resampled (with
+// replacement) from the counts of se. The spread of their decisions
+// shows how much the choices of se depend on sampling noise.
+// The front, middle and back are resampled independently, using rnd,
+// or the global random source if it's nil. se must have counts.
+func (se *SampledEvaluator) Bootstrap(rnd *rand.Rand, k int) ([]*SampledEvaluator, error) {
+	if se.counts[0] == nil || se.samples == 0 {
+		return nil, errors.New("can't bootstrap an evaluator without sample counts")
+	}
+	intn := rand.Intn
+	if rnd != nil {
+		intn = rnd.Intn
+	}
+	var cumulative [3][]int
+	for i := 0; i < 3; i++ {
+		cumulative[i] = make([]int, len(se.counts[i]))
+		t := 0
+		for j, c := range se.counts[i] {
+			t += c
+			cumulative[i][j] = t
+		}
+	}
+	var r []*SampledEvaluator
+	for b := 0; b < k; b++ {
+		var counts [3][]int
+		for i := 0; i < 3; i++ {
+			counts[i] = make([]int, len(se.counts[i]))
+			for n := 0; n < se.samples; n++ {
+				counts[i][sort.SearchInts(cumulative[i], intn(se.samples)+1)]++
+			}
+		}
+		r = append(r, &SampledEvaluator{Scoring: se.Scoring, counts: counts, samples: se.samples, wins: winsFromCounts(counts, se.samples)})
+	}
+	return r, nil
+}
+
+// FragileAgreement is the fraction of perturbed evaluators which must
+// agree with a decision for it to be considered robust.
+const FragileAgreement = 0.9
+
+// A Stability describes how robust the arrangement chosen for
+// a deal is when the evaluator is perturbed.
+type Stability struct {
+	Hand         Hand    // The arrangement chosen by the unperturbed evaluator
+	Agreement    float64 // The fraction of perturbed evaluators choosing an equivalent arrangement
+	Alternatives int     // How many different arrangements the perturbed evaluators chose
+}
+
+// Fragile reports whether the decision is fragile, rather than robust.
+func (s Stability) Fragile() bool {
+	return s.Agreement < FragileAgreement
+}
+
+// ArrangementStability plays the 13 cards c with he, and with each of the
+// perturbed evaluators (for example, from Bootstrap), and reports how often
+// they agree. Arrangements are equivalent if they have the same ranks.
+func ArrangementStability(c []poker.Card, he HandEvaluator, perturbed []*SampledEvaluator) Stability {
+	best, _ := Play(c, he)
+	want := best.ranks()
+	seen := map[[3]int16]bool{}
+	agree := 0
+	for _, p := range perturbed {
+		h, _ := Play(c, p)
+		r := h.ranks()
+		agree += b2i(r == want)
+		if r != want {
+			seen[r] = true
+		}
+	}
+	s := Stability{Hand: best, Agreement: 1, Alternatives: len(seen)}
+	if len(perturbed) > 0 {
+		s.Agreement = float64(agree) / float64(len(perturbed))
+	}
+	return s
+}
@@ -0,0 +1,52 @@
+package cpoker
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestBootstrap(t *testing.T) {
+	se := smallSampledEvaluator(t, 100)
+	for _, rnd := range []*rand.Rand{rand.New(rand.NewSource(1)), nil} {
+		bs, err := se.Bootstrap(rnd, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(bs) != 4 {
+			t.Fatalf("got %d resampled evaluators, want 4", len(bs))
+		}
+		for _, b := range bs {
+			for i := 0; i < 3; i++ {
+				total := 0
+				for r, n := range b.Counts(i) {
+					total += n
+					if n > 0 && se.Counts(i)[r] == 0 {
+						t.Errorf("slot %d has %d resampled hands of rank %d, which wasn't sampled", i, n, r)
+					}
+				}
+				if total != se.Samples() {
+					t.Errorf("slot %d has %d resampled hands, want %d", i, total, se.Samples())
+				}
+			}
+		}
+	}
+	if _, err := (&SampledEvaluator{}).Bootstrap(nil, 1); err == nil {
+		t.Errorf("bootstrapped an evaluator without counts")
+	}
+}
+
+func TestArrangementStability(t *testing.T) {
+	se := smallSampledEvaluator(t, 100)
+	same := *se
+	rnd := rand.New(rand.NewSource(2))
+	for i := 0; i < 5; i++ {
+		c := randomDeal(rnd)
+		s := ArrangementStability(c, se, []*SampledEvaluator{&same, &same, &same})
+		if s.Agreement != 1 || s.Alternatives != 0 || s.Fragile() {
+			t.Errorf("copies of the evaluator disagree about %v: %+v", c, s)
+		}
+		if err := CheckHand(&s.Hand, c); err != nil {
+			t.Errorf("%v: %s", &s.Hand, err)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package cpoker
+
+import "math"
+
+// A Stake converts points into money, optionally taking a rake
+// from the winner of each hand.
+type Stake struct {
+	PerPoint float64 // The value of one point.
+	Rake     float64 // The fraction of a hand's winnings taken as rake.
+	RakeCap  float64 // The largest rake taken from a hand, or 0 for no cap.
+}
+
+// Settle returns the money won by a player who scores the given number
+// of points on a hand, after rake, and the rake paid. Only the
+// winner of a hand pays rake.
+func (s *Stake) Settle(points int) (net, rake float64) {
+	won := float64(points) * s.PerPoint
+	if won <= 0 {
+		return won, 0
+	}
+	rake = won * s.Rake
+	if s.RakeCap > 0 {
+		rake = math.Min(rake, s.RakeCap)
+	}
+	return won - rake, rake
+}
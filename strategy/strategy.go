@@ -8,6 +8,8 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"math"
+	"math/rand"
 	"strings"
 
 	"github.com/paulhankin/cpoker"
@@ -16,9 +18,42 @@ import (
 
 var (
 	fromFile = flag.String("from", "", "file to load coefficients from")
-	mode     = flag.String("mode", "ends", "all/ends/percent/per5 : show all hands, just the end of each range, or one hand per percent, one hand per 5 percent")
+	mode     = flag.String("mode", "ends", "all/ends/percent/per5/categories/clusters/histogram/fronts : show all hands, just the end of each range, or one hand per percent, one hand per 5 percent, how often each category of hand is played, how often each combination of categories is played, a chart of winning percentage across the hands in each slot, or the weakest arrangement played with each front")
+	deals    = flag.Int("deals", 10000, "how many random deals to play for -mode=categories and -mode=clusters, and for each class of front for -mode=fronts")
+	compare  = flag.String("compare", "", "if set, instead of -mode, compare the winning percentages of the hands at the ends of each range with those from this file")
+	diffPct  = flag.Float64("threshold", 1, "with -compare, mark hands whose winning percentages differ by more than this many percentage points")
+	outSpec  = flag.String("out", "", "if set, also write a record of each row of the output to this output, which looks like jsonl://path")
 )
 
+var (
+	parts = []string{"front", "middle", "back"}
+	out   *cpoker.RecordWriter
+)
+
+// writeRecord writes a record to the -out output, if there is one.
+func writeRecord(typ string, v interface{}) {
+	if err := out.Write(typ, v); err != nil {
+		log.Fatalf("failed to write output: %s", err)
+	}
+}
+
+// A rankRecord is the winning probability of a hand in a slot.
+type rankRecord struct {
+	Slot   string  `json:"slot"`
+	Rank   int16   `json:"rank"`
+	Hand   string  `json:"hand"`
+	Win    float64 `json:"win"`
+	StdErr float64 `json:"stderr,omitempty"`
+}
+
+func newRankRecord(se *cpoker.SampledEvaluator, i int, e int16) rankRecord {
+	r := rankRecord{Slot: parts[i], Rank: e, Hand: describeRank(i, e), Win: se.WinProbabilities(i)[e]}
+	if se.Samples() > 0 {
+		r.StdErr = se.WinStdErr(i, e)
+	}
+	return r
+}
+
 var ends5m = [][2]string{
 	{"75432", "AKQJ9"},
 	{"22345", "TTAKQ"},
@@ -136,34 +171,49 @@ func eval(h []poker.Card) int16 {
 	return 0
 }
 
+// percent formats the winning percentage of a hand of rank e
+// in slot i, annotated with its standard error if it's known.
+func percent(se *cpoker.SampledEvaluator, i int, e int16, width int, plusMinus string) string {
+	r := fmt.Sprintf("%*.2f", width, se.WinProbabilities(i)[e]*100)
+	if se.Samples() > 0 {
+		r += fmt.Sprintf("%s%.2f", plusMinus, se.WinStdErr(i, e)*100)
+	}
+	return r
+}
+
 func ends(se *cpoker.SampledEvaluator) {
-	parts := []string{"front", "middle", "back"}
-	fmt.Printf("|            |%-60s| __Winning Percentage__ |\n", " __Hand Range__")
-	fmt.Printf("|------------|%-60s|:-----------------------|\n", ":"+strings.Repeat("-", 58)+":")
+	width := 24
+	if se.Samples() > 0 {
+		width = 48
+	}
+	fmt.Printf("|            |%-60s|%-*s|\n", " __Hand Range__", width, " __Winning Percentage__")
+	fmt.Printf("|------------|%-60s|:%s|\n", ":"+strings.Repeat("-", 58)+":", strings.Repeat("-", width-1))
 	for i := range parts {
-		fmt.Printf("| %-10s |%60s|%24s|\n", "__"+parts[i]+"__", "", "")
+		fmt.Printf("| %-10s |%60s|%*s|\n", "__"+parts[i]+"__", "", width, "")
 		ends := [][][2]string{ends3, ends5m, ends5b}[i]
-		wins := se.WinProbabilities(i)
 		for _, es := range ends {
 			h0 := parseHand(es[0])
 			h1 := parseHand(es[1])
 			d0 := mustDescribeShort(h0)
 			d1 := mustDescribeShort(h1)
-			fmt.Printf("|%12s| %21s &mdash; %-21s &nbsp; | %6.2f &mdash; %6.2f  |\n", "", d0, d1, wins[eval(h0)]*100, wins[eval(h1)]*100)
+			p0 := percent(se, i, eval(h0), 6, "&plusmn;")
+			p1 := percent(se, i, eval(h1), 6, "&plusmn;")
+			fmt.Printf("|%12s| %21s &mdash; %-21s &nbsp; | %s &mdash; %s  |\n", "", d0, d1, p0, p1)
+			writeRecord("range", struct {
+				Low  rankRecord `json:"low"`
+				High rankRecord `json:"high"`
+			}{newRankRecord(se, i, eval(h0)), newRankRecord(se, i, eval(h1))})
 		}
 	}
 	fmt.Println()
 }
 
 func percents(se *cpoker.SampledEvaluator, x float64) {
-	parts := []string{"front", "middle", "back"}
 	for i := range parts {
-		wantLen := 3
 		fmt.Println(parts[i])
 		toHand := poker.EvalToHand3
 		if i > 0 {
 			toHand = poker.EvalToHand5
-			wantLen = 5
 		}
 		oldp := 0.0
 		last := ""
@@ -172,7 +222,7 @@ func percents(se *cpoker.SampledEvaluator, x float64) {
 				continue
 			}
 			h, ok := toHand(int16(r))
-			if !ok || len(h) != wantLen {
+			if !ok || !cpoker.Reachable(i, int16(r)) {
 				continue
 			}
 			// We can have multiple hands with the same short description.
@@ -182,7 +232,8 @@ func percents(se *cpoker.SampledEvaluator, x float64) {
 			// should be an average, but the differences are tiny.
 			rShort := mustDescribeShort(h)
 			if rShort != last {
-				fmt.Printf("%5.2f : %s\n", 100*p, mustDescribeShort(h))
+				fmt.Printf("%s : %s\n", percent(se, i, int16(r), 5, " +/- "), mustDescribeShort(h))
+				writeRecord("rank", newRankRecord(se, i, int16(r)))
 				last = rShort
 			}
 			oldp = p
@@ -191,15 +242,103 @@ func percents(se *cpoker.SampledEvaluator, x float64) {
 	}
 }
 
+// compareEnds prints the winning percentages of the hands at the end
+// of each range from two evaluators, marking those which differ by
+// more than the threshold.
+func compareEnds(se, other *cpoker.SampledEvaluator, threshold float64) {
+	fmt.Printf("a: %s\nb: %s\n\n", *fromFile, *compare)
+	fmt.Printf("  %-20s %8s %8s %8s\n", "hand", "a", "b", "b-a")
+	for i := range parts {
+		fmt.Println(parts[i])
+		ends := [][][2]string{ends3, ends5m, ends5b}[i]
+		for _, es := range ends {
+			for _, hs := range es {
+				h := parseHand(hs)
+				e := eval(h)
+				p0 := se.WinProbabilities(i)[e] * 100
+				p1 := other.WinProbabilities(i)[e] * 100
+				mark := ""
+				if math.Abs(p1-p0) > threshold {
+					mark = " <--"
+				}
+				fmt.Printf("  %-20s %8.2f %8.2f %+8.2f%s\n", mustDescribeShort(h), p0, p1, p1-p0, mark)
+				writeRecord("compare", struct {
+					A rankRecord `json:"a"`
+					B rankRecord `json:"b"`
+				}{newRankRecord(se, i, e), newRankRecord(other, i, e)})
+			}
+		}
+		fmt.Println()
+	}
+}
+
+// histogram prints a bar chart for each slot, showing the average
+// winning percentage of each tenth of the reachable hands, from
+// weakest to strongest.
+func histogram(se *cpoker.SampledEvaluator) {
+	const buckets, barWidth = 10, 50
+	for i := range parts {
+		fmt.Println(parts[i])
+		ranks := cpoker.ReachableRanks(i)
+		wp := se.WinProbabilities(i)
+		for b := 0; b < buckets; b++ {
+			lo, hi := b*len(ranks)/buckets, (b+1)*len(ranks)/buckets
+			sum := 0.0
+			for _, e := range ranks[lo:hi] {
+				sum += wp[e]
+			}
+			mean := sum / float64(hi-lo)
+			fmt.Printf("%3d-%3d%% %-*s %6.2f%%  %s .. %s\n", b*100/buckets, (b+1)*100/buckets, barWidth,
+				strings.Repeat("#", int(mean*barWidth+0.5)), mean*100, describeRank(i, ranks[lo]), describeRank(i, ranks[hi-1]))
+			writeRecord("bucket", struct {
+				Slot    string  `json:"slot"`
+				Bucket  int     `json:"bucket"`
+				MeanWin float64 `json:"mean_win"`
+				Low     string  `json:"low"`
+				High    string  `json:"high"`
+			}{parts[i], b, mean, describeRank(i, ranks[lo]), describeRank(i, ranks[hi-1])})
+		}
+		fmt.Println()
+	}
+}
+
+// describeRank returns a short description of a hand of rank e in slot i.
+func describeRank(i int, e int16) string {
+	toHand := poker.EvalToHand3
+	if i > 0 {
+		toHand = poker.EvalToHand5
+	}
+	h, ok := toHand(e)
+	if !ok {
+		return "?"
+	}
+	return mustDescribeShort(h)
+}
+
 func main() {
 	flag.Parse()
 	if *fromFile == "" {
 		log.Fatalf("-from must be specified")
 	}
-	se, err := cpoker.LoadSampledEvaluator(*fromFile)
+	if *outSpec != "" {
+		var err error
+		if out, err = cpoker.OpenOutput(*outSpec); err != nil {
+			log.Fatalf("failed to open -out: %s", err)
+		}
+		defer out.Close()
+	}
+	se, err := cpoker.LoadEvaluatorFile(*fromFile)
 	if err != nil {
 		log.Fatalf("failed to load coefficients: %s", err)
 	}
+	if *compare != "" {
+		other, err := cpoker.LoadEvaluatorFile(*compare)
+		if err != nil {
+			log.Fatalf("failed to load coefficients to compare: %s", err)
+		}
+		compareEnds(se, other, *diffPct)
+		return
+	}
 	switch *mode {
 	case "percent":
 		percents(se, 100)
@@ -209,6 +348,48 @@ func main() {
 		percents(se, 20)
 	case "ends":
 		ends(se)
+	case "histogram":
+		histogram(se)
+	case "categories":
+		ps := cpoker.CollectPlayStats(rand.New(rand.NewSource(1)), se, *deals)
+		fmt.Print(ps.String())
+		for i := range parts {
+			for c, n := range ps.Categories[i] {
+				if n > 0 {
+					writeRecord("category", struct {
+						Slot     string  `json:"slot"`
+						Category string  `json:"category"`
+						Fraction float64 `json:"fraction"`
+					}{parts[i], cpoker.HandCategory(c).String(), ps.Fraction(i, cpoker.HandCategory(c))})
+				}
+			}
+		}
+	case "clusters":
+		clusters := cpoker.ClusterPlays(rand.New(rand.NewSource(1)), se, *deals)
+		fmt.Print(cpoker.FormatClusters(clusters))
+		for _, c := range clusters {
+			writeRecord("cluster", struct {
+				Pattern string  `json:"pattern"`
+				Deals   int     `json:"deals"`
+				MeanEV  float64 `json:"mean_ev"`
+			}{c.Pattern.String(), c.Deals, c.MeanEV})
+		}
+	case "fronts":
+		fps := cpoker.WeakestFrontPlays(rand.New(rand.NewSource(1)), se, *deals)
+		fmt.Print(cpoker.FormatFrontPlays(fps))
+		for _, fp := range fps {
+			r := struct {
+				Front  string `json:"front"`
+				Deals  int    `json:"deals"`
+				Played int    `json:"played"`
+				Middle string `json:"middle,omitempty"`
+				Back   string `json:"back,omitempty"`
+			}{Front: fp.Front, Deals: fp.Deals, Played: fp.Played}
+			if fp.Played > 0 {
+				r.Middle, r.Back = describeRank(1, fp.Middle), describeRank(2, fp.Back)
+			}
+			writeRecord("front", r)
+		}
 	default:
 		log.Fatalf("Unknown value for flag -mode: <%s>", *mode)
 	}
@@ -0,0 +1,127 @@
+package cpoker
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// A PassPolicy decides whether to pass a deal rather than play it, in
+// variants where players may pass (surrender) before setting their
+// hands, paying Scoring.Surrender points to each opponent who plays.
+// Deals are passed if their strength is below the threshold.
+type PassPolicy struct {
+	Evaluator EvaluatorPoints // How deals are played and judged
+	Scoring   *Scoring        // How hands are scored. If nil, Scoring2to4 is used.
+	Threshold float64         // Deals with a strength below this are passed
+}
+
+// Strength returns the strength of a deal: the points the policy's
+// evaluator expects to score against each opponent by playing it.
+func (p *PassPolicy) Strength(c []poker.Card) float64 {
+	_, points, _ := PlayForPoints(c, p.Evaluator, p.Scoring)
+	return points
+}
+
+// Pass reports whether to pass the deal. A nil policy never passes.
+func (p *PassPolicy) Pass(c []poker.Card) bool {
+	return p != nil && p.Strength(c) < p.Threshold
+}
+
+// PlayWithBudget plays c with he, as PlayWithBudget does, and reports
+// whether the policy passes the deal. The strength of the deal is from
+// he, rather than the policy's evaluator, so if he isn't an
+// EvaluatorPoints, the deal is never passed. Otherwise the deal is
+// played for points, scored with the policy's scoring, and the points
+// expected from the hand played are its strength, so deciding whether to
+// pass needs no more work than playing. A nil policy never passes.
+func (p *PassPolicy) PlayWithBudget(c []poker.Card, he HandEvaluator, b PlayBudget) (Hand, EvalStats, bool, error) {
+	ep, ok := he.(EvaluatorPoints)
+	if p == nil || !ok {
+		h, stats, err := PlayWithBudget(c, he, b)
+		return h, stats, false, err
+	}
+	pe := &pointsEvaluator{ep: ep, s: p.Scoring}
+	h, stats, err := PlayWithBudget(c, pe, b)
+	if err != nil {
+		return h, stats, false, err
+	}
+	r := h.ranks()
+	return h, stats, pe.ev(r[0], r[1], r[2]) < p.Threshold, nil
+}
+
+// A pointsEvaluator evaluates hands in points, keeping the last
+// evaluator it made, so that the hand played can be valued again
+// without making another.
+type pointsEvaluator struct {
+	ep EvaluatorPoints
+	s  *Scoring
+	ev func(f, m, b int16) float64
+}
+
+func (pe *pointsEvaluator) Evaluator(c []poker.Card) func(f, m, b int16) float64 {
+	pe.ev = pe.ep.Points(c, pe.s)
+	return pe.ev
+}
+
+// passShowdown is like showdown, but either player may have passed. A
+// player who passed pays the other Surrender points, and there's no
+// showdown, so no slots are won or lost.
+func (s *Scoring) passShowdown(h0, h1 [3]int16, pass0, pass1 bool) (score, wins, losses int) {
+	if pass0 || pass1 {
+		return s.Surrender * (b2i(pass1) - b2i(pass0)), 0, 0
+	}
+	return s.showdown(h0, h1)
+}
+
+// TrainPassPolicy chooses the threshold of a pass policy for a player
+// who plays and judges deals with he, scored with s (or Scoring2to4 if
+// it's nil). It plays n deals, using rnd (or the global random source
+// if it's nil), at a table of nPlayers (from 2 to 4), where the others
+// play with opp and never pass, and chooses the threshold which would
+// have scored the most over those deals. If passing never pays, the
+// threshold is -Inf.
+func TrainPassPolicy(rnd *rand.Rand, he EvaluatorPoints, opp HandEvaluator, nPlayers, n int, s *Scoring) (*PassPolicy, error) {
+	if nPlayers < 2 || nPlayers > 4 {
+		return nil, errors.New("a table must have between 2 and 4 players")
+	}
+	if s == nil {
+		s = Scoring2to4
+	}
+	type outcome struct {
+		strength float64
+		score    int // against all the opponents, if the deal is played
+	}
+	outcomes := make([]outcome, n)
+	for d := range outcomes {
+		hands := DealPlayers(rnd, nPlayers)
+		h, strength, _ := PlayForPoints(hands[0], he, s)
+		outcomes[d].strength = strength
+		for _, c := range hands[1:] {
+			o, _, _ := PlayForPoints(c, opp, s)
+			score, _, _ := s.showdown(h.ranks(), o.ranks())
+			outcomes[d].score += score
+		}
+	}
+	sort.Slice(outcomes, func(i, j int) bool { return outcomes[i].strength < outcomes[j].strength })
+	// Find how many of the weakest deals it pays best to pass.
+	passScore := -s.Surrender * (nPlayers - 1)
+	gain, bestGain, bestK := 0, 0, 0
+	for k, o := range outcomes {
+		gain += passScore - o.score
+		if gain > bestGain {
+			bestGain, bestK = gain, k+1
+		}
+	}
+	p := &PassPolicy{Evaluator: he, Scoring: s, Threshold: math.Inf(-1)}
+	switch {
+	case bestK == n && n > 0:
+		p.Threshold = math.Inf(1)
+	case bestK > 0:
+		p.Threshold = (outcomes[bestK-1].strength + outcomes[bestK].strength) / 2
+	}
+	return p, nil
+}
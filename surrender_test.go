@@ -0,0 +1,49 @@
+package cpoker
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestPassPolicy(t *testing.T) {
+	se := smallSampledEvaluator(t, 300)
+	rnd := rand.New(rand.NewSource(13))
+	var thresholds []float64
+	for _, cost := range []int{0, 1, 20} {
+		s := &Scoring{Name: "surrender", Slot: 1, Majority: 1, Surrender: cost}
+		p, err := TrainPassPolicy(rnd, se, MaxProdEvaluator{}, 3, 300, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		thresholds = append(thresholds, p.Threshold)
+	}
+	// Passing costs more than any showdown can lose, so it never pays.
+	if !math.IsInf(thresholds[2], -1) {
+		t.Errorf("passing for 20 points has threshold %f, want -Inf", thresholds[2])
+	}
+	if !(thresholds[0] >= thresholds[1] && thresholds[1] > thresholds[2]) {
+		t.Errorf("thresholds for passing costs 0, 1 and 20 are %v, want them to decrease", thresholds)
+	}
+	if _, err := TrainPassPolicy(rnd, se, MaxProdEvaluator{}, 5, 10, nil); err == nil {
+		t.Errorf("trained a pass policy for 5 players")
+	}
+
+	s := &Scoring{Name: "surrender", Slot: 1, Majority: 1, Surrender: 2}
+	always := &PassPolicy{Evaluator: se, Scoring: s, Threshold: math.Inf(1)}
+	c := CompareEvaluatorsWithOptions(MaxProdEvaluator{}, MaxProdEvaluator{}, 10, 0, CompareOptions{Scoring: s, Passes: []*PassPolicy{always}, Rand: rnd})
+	if c.HeroPassed != 20 || c.VillainPassed != 0 || c.EVPerHand != -2 {
+		t.Errorf("the hero passed %d hands, and the villain %d, for %f per hand; want 20, 0 and -2", c.HeroPassed, c.VillainPassed, c.EVPerHand)
+	}
+	c = CompareEvaluatorsWithOptions(MaxProdEvaluator{}, MaxProdEvaluator{}, 10, 0, CompareOptions{Scoring: s, Passes: []*PassPolicy{always, always}, Rand: rnd})
+	if c.EVPerHand != 0 || c.VillainPassed != 20 {
+		t.Errorf("both players passed every hand, and scored %f per hand", c.EVPerHand)
+	}
+	ts, err := SimulateTable([]HandEvaluator{MaxProdEvaluator{}, MaxProdEvaluator{}, MaxProdEvaluator{}}, 10, CompareOptions{Scoring: s, Passes: []*PassPolicy{nil, always}, Rand: rnd})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts.Passed[1] != 10 || ts.Player[1] != -4 || ts.Passed[0] != 0 {
+		t.Errorf("at a table where player 1 always passes, got %+v", ts)
+	}
+}
@@ -0,0 +1,88 @@
+package cpoker
+
+import (
+	"errors"
+)
+
+// TableStats are aggregated statistics from a multi-player simulation.
+type TableStats struct {
+	Played int       // The number of deals played
+	Player []float64 // Expectation per deal of each player
+	Seat   []float64 // Expectation per deal of each seat. Seat 0 is the dealer.
+	Scoops []int     // How many times each player scooped an opponent
+	Passed []int     // How many deals each player passed (see CompareOptions.Passes)
+
+	// These are only set if the options have a Stake. Each showdown
+	// between two players is settled separately.
+	Net      []float64 // Money won by each player per deal, after rake
+	RakePaid float64   // Total rake paid by all the players
+}
+
+// SimulateTable plays n deals between 2 to 4 players, each of whom
+// plays every other player at the table. The players move round the
+// seats after each deal, so the expectation of each seat can
+// be measured separately from the strength of the players in it.
+// In closed-hand play the seats are symmetric, so differences between
+// seats are just sampling noise.
+func SimulateTable(players []HandEvaluator, n int, opts CompareOptions) (TableStats, error) {
+	k := len(players)
+	if k < 2 || k > 4 {
+		return TableStats{}, errors.New("a table must have between 2 and 4 players")
+	}
+	if opts.OnHand != nil || opts.AdjustSamples > 0 || opts.Printer != nil || opts.Progress != nil {
+		return TableStats{}, errors.New("OnHand, AdjustSamples, Printer and Progress can't be used with a table")
+	}
+	scoring := opts.Scoring
+	if scoring == nil {
+		scoring = Scoring2to4
+	}
+	result := TableStats{
+		Player: make([]float64, k),
+		Seat:   make([]float64, k),
+		Scoops: make([]int, k),
+		Passed: make([]int, k),
+	}
+	if opts.Stake != nil {
+		result.Net = make([]float64, k)
+	}
+	playerTotal := make([]int, k)
+	net := make([]float64, k)
+	seatTotal := make([]int, k)
+	ranks := make([][3]int16, k)
+	passed := make([]bool, k)
+	for deal := 0; deal < n; deal++ {
+		hands := DealPlayers(opts.Rand, k)
+		for s := 0; s < k; s++ {
+			h, _, _ := opts.play(hands[s], players[(s+deal)%k], scoring)
+			ranks[s] = h.ranks()
+			passed[s] = opts.passes((s+deal)%k, hands[s])
+			result.Passed[(s+deal)%k] += b2i(passed[s])
+		}
+		for s := 0; s < k; s++ {
+			p := (s + deal) % k
+			for t := 0; t < k; t++ {
+				if s == t {
+					continue
+				}
+				score, wins, _ := scoring.passShowdown(ranks[s], ranks[t], passed[s], passed[t])
+				seatTotal[s] += score
+				playerTotal[p] += score
+				result.Scoops[p] += b2i(wins == 3)
+				if opts.Stake != nil {
+					n, rake := opts.Stake.Settle(score)
+					net[p] += n
+					result.RakePaid += rake
+				}
+			}
+		}
+		result.Played++
+	}
+	for i := 0; i < k && result.Played > 0; i++ {
+		result.Player[i] = float64(playerTotal[i]) / float64(result.Played)
+		result.Seat[i] = float64(seatTotal[i]) / float64(result.Played)
+		if opts.Stake != nil {
+			result.Net[i] = net[i] / float64(result.Played)
+		}
+	}
+	return result, nil
+}
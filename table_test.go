@@ -0,0 +1,66 @@
+package cpoker
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSimulateTableStake(t *testing.T) {
+	players := []HandEvaluator{MaxProdEvaluator{}, MaxProdEvaluator{}, DefaultHeuristic}
+	for _, stake := range []*Stake{{PerPoint: 2}, {PerPoint: 2, Rake: 0.1, RakeCap: 1}} {
+		ts, err := SimulateTable(players, 30, CompareOptions{Stake: stake, Rand: rand.New(rand.NewSource(1))})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The money the players win, and the rake, add up to nothing.
+		total := ts.RakePaid / float64(ts.Played)
+		for i, net := range ts.Net {
+			total += net
+			if stake.Rake == 0 && net != ts.Player[i]*stake.PerPoint {
+				t.Errorf("with stake %+v, player %d won %f per deal for %f points", stake, i, net, ts.Player[i])
+			}
+		}
+		if math.Abs(total) > 1e-9 {
+			t.Errorf("with stake %+v, the players won %v and paid %f rake over %d deals", stake, ts.Net, ts.RakePaid, ts.Played)
+		}
+		if (stake.Rake > 0) != (ts.RakePaid > 0) {
+			t.Errorf("with stake %+v, %f rake was paid", stake, ts.RakePaid)
+		}
+	}
+}
+
+func TestSimulateTableSeats(t *testing.T) {
+	players := []HandEvaluator{MaxProdEvaluator{}, DefaultHeuristic, MaxProdEvaluator{}, DefaultHeuristic}
+	for k := 2; k <= 4; k++ {
+		const n = 40
+		ts, err := SimulateTable(players[:k], n, CompareOptions{Rand: rand.New(rand.NewSource(int64(k)))})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ts.Played != n || len(ts.Player) != k || len(ts.Seat) != k || len(ts.Scoops) != k || len(ts.Passed) != k {
+			t.Fatalf("%d players played %d deals, want %d, with results for each: %+v", k, ts.Played, n, ts)
+		}
+		// Every showdown is won by one player and lost by another,
+		// whether it's counted by player or by seat.
+		var byPlayer, bySeat float64
+		for i := 0; i < k; i++ {
+			byPlayer += ts.Player[i]
+			bySeat += ts.Seat[i]
+		}
+		if math.Abs(byPlayer) > 1e-9 || math.Abs(bySeat) > 1e-9 {
+			t.Errorf("with %d players, players won %v and seats won %v, want each to sum to 0", k, ts.Player, ts.Seat)
+		}
+	}
+	bad := []CompareOptions{
+		{OnHand: func(HandRecord) {}},
+		{AdjustSamples: 10},
+		{Printer: TextProgressPrinter{}},
+		{Progress: func(int, Comparison) {}},
+	}
+	for _, opts := range bad {
+		if _, err := SimulateTable(players, 1, opts); err == nil {
+			t.Errorf("SimulateTable accepted options it ignores: %+v", opts)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package cpoker
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// A tableInit builds a table on first use, like a sync.Once: it's
+// safe for concurrent use, and only one caller builds the table, while
+// the others wait for it. Unlike a sync.Once, it can be reset, so that
+// tests can build a table again under different conditions (for
+// example, as if the rank tables had changed).
+type tableInit struct {
+	mu   sync.Mutex
+	done uint32
+}
+
+// Do calls build if the table hasn't been built since the tableInit
+// was created or last reset.
+func (ti *tableInit) Do(build func()) {
+	if atomic.LoadUint32(&ti.done) == 1 {
+		return
+	}
+	ti.mu.Lock()
+	defer ti.mu.Unlock()
+	if ti.done == 0 {
+		defer atomic.StoreUint32(&ti.done, 1)
+		build()
+	}
+}
+
+// reset makes the next call to Do build the table again. It's for
+// tests, and mustn't be called while the table is in use.
+func (ti *tableInit) reset() {
+	ti.mu.Lock()
+	defer ti.mu.Unlock()
+	atomic.StoreUint32(&ti.done, 0)
+}
@@ -0,0 +1,46 @@
+package cpoker
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTableInit(t *testing.T) {
+	var ti tableInit
+	var builds int32
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ti.Do(func() { atomic.AddInt32(&builds, 1) })
+		}()
+	}
+	wg.Wait()
+	ti.Do(func() { atomic.AddInt32(&builds, 1) })
+	if builds != 1 {
+		t.Errorf("built %d times, want 1", builds)
+	}
+	ti.reset()
+	ti.Do(func() { atomic.AddInt32(&builds, 1) })
+	if builds != 2 {
+		t.Errorf("built %d times after a reset, want 2", builds)
+	}
+
+	// If the rank tables change, the percentiles are computed by
+	// enumerating hands, and are the same.
+	want := [2][]float64{handPercentiles(0), handPercentiles(1)}
+	defer func(sum string) {
+		handCountsChecksum = sum
+		percentilesInit.reset()
+	}(handCountsChecksum)
+	handCountsChecksum = "changed"
+	percentilesInit.reset()
+	for i := range want {
+		if got := handPercentiles(i); !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("handPercentiles(%d) differs when computed by enumerating hands", i)
+		}
+	}
+}
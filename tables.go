@@ -0,0 +1,196 @@
+package cpoker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// A VariantConfig describes the rules of the game a strategy plays.
+type VariantConfig struct {
+	Name    string `json:"name"`              // A name for the rules, for example "standard"
+	Discard bool   `json:"discard,omitempty"` // Whether 14 cards are dealt, and one discarded (see PlayWithDiscard)
+	Lowball string `json:"lowball,omitempty"` // If set, hands are ranked as low hands of this kind
+}
+
+// The kinds of lowball a VariantConfig can have.
+const (
+	LowballDeuceToSeven = "2-7" // As EvalLow27
+	LowballAceToFive    = "a-5" // As EvalLowA5
+)
+
+// Tables rank hands for a variant. Larger ranks are better, whatever
+// the variant. In the standard game, ranks of 3-card and 5-card hands
+// are comparable, but in lowball they're ranked separately.
+type Tables struct {
+	cfg VariantConfig
+	low [2]*lowTable // For 3-card and 5-card hands, or nil for high hands
+
+	checksumInit tableInit
+	checksum     string
+}
+
+// DefaultTables rank hands in the standard game, using the poker
+// package's tables.
+var DefaultTables = &Tables{cfg: VariantConfig{Name: "standard"}}
+
+// BuildTables returns tables for the variant. High hands use the poker
+// package's tables, and lowball variants get their own, which are
+// built on first use.
+func BuildTables(cfg VariantConfig) (*Tables, error) {
+	switch cfg.Lowball {
+	case "":
+		return &Tables{cfg: cfg}, nil
+	case LowballDeuceToSeven, LowballAceToFive:
+		return newLowballTables(cfg), nil
+	}
+	return nil, fmt.Errorf("unknown lowball %q: want %q or %q", cfg.Lowball, LowballDeuceToSeven, LowballAceToFive)
+}
+
+// newLowballTables returns unbuilt tables for a lowball variant.
+func newLowballTables(cfg VariantConfig) *Tables {
+	return &Tables{cfg: cfg, low: [2]*lowTable{newLowTable(cfg.Lowball, 3), newLowTable(cfg.Lowball, 5)}}
+}
+
+// Config returns the variant the tables are for.
+func (t *Tables) Config() VariantConfig {
+	return t.cfg
+}
+
+// Eval3 returns the rank of a 3-card hand. In lowball, ranks are from
+// 1, and 3-card hands never make straights or flushes.
+func (t *Tables) Eval3(c *[3]poker.Card) int16 {
+	if t.low[0] != nil {
+		return t.low[0].eval(c[:])
+	}
+	return poker.Eval3(c)
+}
+
+// Eval5 returns the rank of a 5-card hand.
+func (t *Tables) Eval5(c *[5]poker.Card) int16 {
+	if t.low[1] != nil {
+		return t.low[1].eval(c[:])
+	}
+	return poker.Eval5(c)
+}
+
+// EvalToHand returns an example hand of 3 or 5 cards with the given
+// rank, and whether there is one.
+func (t *Tables) EvalToHand(size int, e int16) ([]poker.Card, bool) {
+	switch {
+	case size != 3 && size != 5:
+		return nil, false
+	case t.low[0] != nil:
+		return t.low[size/5].toHand(e)
+	case size == 3:
+		return poker.EvalToHand3(e)
+	}
+	return poker.EvalToHand5(e)
+}
+
+// ScoreMax returns the largest rank of any hand.
+func (t *Tables) ScoreMax() int {
+	if t.low[1] == nil {
+		return poker.ScoreMax
+	}
+	t.low[1].init()
+	return len(t.low[1].classes) - 1
+}
+
+// Checksum identifies how the tables rank hands, as TableChecksum does
+// for the poker package's tables, which are what the standard game's
+// checksum is.
+func (t *Tables) Checksum() string {
+	if t.low[0] == nil {
+		return TableChecksum()
+	}
+	t.checksumInit.Do(func() {
+		h := sha256.New()
+		fmt.Fprintf(h, "lowball %s %d\n", t.cfg.Lowball, t.ScoreMax())
+		for _, size := range []int{3, 5} {
+			for e := 1; ; e++ {
+				c, ok := t.EvalToHand(size, int16(e))
+				if !ok {
+					break
+				}
+				fmt.Fprintf(h, "%v\n", cardNames(c))
+			}
+		}
+		t.checksum = hex.EncodeToString(h.Sum(nil))[:16]
+	})
+	return t.checksum
+}
+
+// Info returns the metadata of the tables.
+func (t *Tables) Info() TableMetadata {
+	if t.low[0] == nil {
+		return TableInfo()
+	}
+	t.low[0].init()
+	t.low[1].init()
+	return TableMetadata{
+		ScoreMax: t.ScoreMax(),
+		Ranks3:   len(t.low[0].classes) - 1,
+		Ranks5:   len(t.low[1].classes) - 1,
+		Checksum: t.Checksum(),
+	}
+}
+
+var (
+	variantsMu sync.Mutex
+	variants   = map[string]*Tables{
+		"standard":    DefaultTables,
+		"lowball-2-7": newLowballTables(VariantConfig{Name: "lowball-2-7", Lowball: LowballDeuceToSeven}),
+		"lowball-a-5": newLowballTables(VariantConfig{Name: "lowball-a-5", Lowball: LowballAceToFive}),
+	}
+)
+
+// RegisterVariant makes tables available by the name of their
+// variant. It's an error if a variant with that name is already
+// registered.
+func RegisterVariant(t *Tables) error {
+	variantsMu.Lock()
+	defer variantsMu.Unlock()
+	if _, ok := variants[t.cfg.Name]; ok {
+		return fmt.Errorf("variant %q is already registered", t.cfg.Name)
+	}
+	variants[t.cfg.Name] = t
+	return nil
+}
+
+// VariantNames returns the names of the registered variants.
+func VariantNames() []string {
+	variantsMu.Lock()
+	defer variantsMu.Unlock()
+	var r []string
+	for name := range variants {
+		r = append(r, name)
+	}
+	sort.Strings(r)
+	return r
+}
+
+// TablesFor returns the tables of a variant: the registered tables with
+// its name if there are any, and otherwise tables built for it. It's an
+// error if tables are registered with the name but rank hands another
+// way (for example, if the config names the standard game, but has
+// a lowball), so a config can't get tables that don't match it.
+// Serializers use it to record and check which tables ranks refer to,
+// so that, for example, an evaluator trained for lowball isn't used
+// to play the standard game.
+func TablesFor(cfg VariantConfig) (*Tables, error) {
+	variantsMu.Lock()
+	t, ok := variants[cfg.Name]
+	variantsMu.Unlock()
+	if !ok {
+		return BuildTables(cfg)
+	}
+	if t.cfg.Lowball != cfg.Lowball {
+		return nil, fmt.Errorf("variant %q is registered with lowball %q, not %q", cfg.Name, t.cfg.Lowball, cfg.Lowball)
+	}
+	return t, nil
+}
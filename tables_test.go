@@ -0,0 +1,137 @@
+package cpoker
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+func TestBuildTables(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	low27, err := BuildTables(VariantConfig{Name: "2-7", Lowball: LowballDeuceToSeven})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lowA5, err := BuildTables(VariantConfig{Name: "a-5", Lowball: LowballAceToFive})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		c := randomDeal(rnd)
+		var h3 [3]poker.Card
+		var h5 [5]poker.Card
+		copy(h3[:], c)
+		copy(h5[:], c[3:])
+		if DefaultTables.Eval3(&h3) != poker.Eval3(&h3) || DefaultTables.Eval5(&h5) != poker.Eval5(&h5) {
+			t.Errorf("the default tables rank %v and %v differently from the poker package", h3, h5)
+		}
+		if low27.Eval5(&h5) != EvalLow27(h5[:]) || lowA5.Eval5(&h5) != EvalLowA5(h5[:]) {
+			t.Errorf("lowball tables rank %v differently from EvalLow27 and EvalLowA5", h5)
+		}
+	}
+	if DefaultTables.ScoreMax() != poker.ScoreMax || low27.ScoreMax() != LowRankMax || lowA5.ScoreMax() != LowA5RankMax {
+		t.Errorf("got ScoreMax %d, %d, %d", DefaultTables.ScoreMax(), low27.ScoreMax(), lowA5.ScoreMax())
+	}
+	// The best 3-card low hands, and some worse ones.
+	for _, tc := range []struct {
+		tables      *Tables
+		best, worse string
+	}{
+		{low27, "C2D3H4", "C2D3HA"},
+		{lowA5, "CAD2H3", "C2D3H4"},
+		{lowA5, "CKDQHJ", "C2D2H3"},
+	} {
+		var best, worse [3]poker.Card
+		copy(best[:], mustCards(t, tc.best))
+		copy(worse[:], mustCards(t, tc.worse))
+		if b, w := tc.tables.Eval3(&best), tc.tables.Eval3(&worse); b <= w {
+			t.Errorf("%s: %s has rank %d, not better than %s with %d", tc.tables.Config().Name, tc.best, b, tc.worse, w)
+		}
+	}
+	for _, tables := range []*Tables{low27, lowA5} {
+		for e := int16(1); ; e++ {
+			h, ok := tables.EvalToHand(3, e)
+			if !ok {
+				if e != 456 {
+					t.Errorf("%s: found %d 3-card ranks, want 455", tables.Config().Name, e-1)
+				}
+				break
+			}
+			if got := tables.Eval3(&[3]poker.Card{h[0], h[1], h[2]}); got != e {
+				t.Fatalf("%s: EvalToHand(3, %d) = %v, which has rank %d", tables.Config().Name, e, h, got)
+			}
+		}
+	}
+	if _, err := BuildTables(VariantConfig{Lowball: "razz"}); err == nil {
+		t.Errorf("built tables for an unknown lowball")
+	}
+}
+
+func TestVariantRegistry(t *testing.T) {
+	sums := map[string]string{}
+	for _, cfg := range []VariantConfig{
+		{Name: "standard"},
+		{Name: "lowball-2-7", Lowball: LowballDeuceToSeven},
+		{Name: "lowball-a-5", Lowball: LowballAceToFive},
+	} {
+		name := cfg.Name
+		tables, err := TablesFor(cfg)
+		if err != nil || tables.Config().Name != name {
+			t.Fatalf("TablesFor(%s) = %v, %v", name, tables, err)
+		}
+		sum := tables.Checksum()
+		if other, ok := sums[sum]; ok {
+			t.Errorf("variants %s and %s have the same checksum %s", name, other, sum)
+		}
+		sums[sum] = name
+		if info := tables.Info(); info.ScoreMax != tables.ScoreMax() || info.Checksum != sum {
+			t.Errorf("%s: got info %+v", name, info)
+		}
+	}
+	if DefaultTables.Checksum() != TableChecksum() {
+		t.Errorf("the standard game's checksum is %s, want %s", DefaultTables.Checksum(), TableChecksum())
+	}
+	if info := variants["lowball-2-7"].Info(); info.ScoreMax != LowRankMax || info.Ranks3 != 455 || info.Ranks5 != LowRankMax {
+		t.Errorf("got deuce-to-seven info %+v", info)
+	}
+	if err := RegisterVariant(DefaultTables); err == nil {
+		t.Errorf("registered the standard game twice")
+	}
+	custom, err := BuildTables(VariantConfig{Name: "test-lowball", Lowball: LowballAceToFive})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterVariant(custom); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		variantsMu.Lock()
+		delete(variants, "test-lowball")
+		variantsMu.Unlock()
+	}()
+	if got, _ := TablesFor(VariantConfig{Name: "test-lowball", Lowball: LowballAceToFive}); got != custom {
+		t.Errorf("TablesFor didn't return the registered tables")
+	}
+	// A registered name with different ranking isn't a variant.
+	for _, cfg := range []VariantConfig{
+		{Name: "standard", Lowball: LowballDeuceToSeven},
+		{Name: "lowball-2-7"},
+		{Name: "test-lowball", Lowball: LowballDeuceToSeven},
+	} {
+		if tables, err := TablesFor(cfg); err == nil {
+			t.Errorf("TablesFor(%+v) = tables for %+v, want an error", cfg, tables.Config())
+		}
+	}
+	// An unregistered variant gets tables built for it.
+	if tables, err := TablesFor(VariantConfig{Name: "pineapple", Discard: true}); err != nil || tables.Checksum() != TableChecksum() {
+		t.Errorf("TablesFor(pineapple) = %v, %v, want the standard tables", tables, err)
+	}
+	found := false
+	for _, name := range VariantNames() {
+		found = found || name == "test-lowball"
+	}
+	if !found {
+		t.Errorf("VariantNames() = %v, which is missing test-lowball", VariantNames())
+	}
+}
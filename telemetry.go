@@ -0,0 +1,122 @@
+package cpoker
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// A TelemetryRecord is an anonymous record of a hand an engine played,
+// which can be shared to help improve strategies. It contains nothing
+// about who played the hand. The deal is recorded only as a keyed hash
+// (see DealHash), so duplicate records can be found, but without the
+// key, the cards can't be recovered.
+type TelemetryRecord struct {
+	Deal    string   `json:"deal"`    // The DealHash of the cards
+	Pattern string   `json:"pattern"` // The categories of the front, middle and back
+	Ranks   [3]int16 `json:"ranks"`   // The ranks of the front, middle and back
+
+	// Margin is how much more EV the hand played has than the next
+	// best arrangement the engine considered. It's omitted if there
+	// was no other arrangement.
+	Margin *float64 `json:"margin,omitempty"`
+
+	// Truncated is set if the engine's budget ran out before it
+	// considered every arrangement, so it may have missed a better one.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// DealHash returns a hash identifying a set of cards, which doesn't
+// depend on their order. It's an HMAC keyed with a secret, since there
+// are few enough deals that an unkeyed hash could be reversed by
+// hashing every one. Hashes of the same deal only match if they're
+// made with the same key.
+func DealHash(key []byte, c []poker.Card) string {
+	names := cardNames(c)
+	sort.Strings(names)
+	h := hmac.New(sha256.New, key)
+	for _, n := range names {
+		h.Write([]byte(n))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// NewTelemetryRecord returns a record of the hand h played from the
+// cards c, with the stats from playing it (as returned by
+// PlayWithBudget) and the deal hashed with key. It does no more work
+// than that.
+func NewTelemetryRecord(key []byte, c []poker.Card, h *Hand, stats EvalStats) TelemetryRecord {
+	r := h.ranks()
+	var p Pattern
+	for i, e := range r {
+		p[i] = slotCategory(i, e)
+	}
+	rec := TelemetryRecord{
+		Deal:      DealHash(key, c),
+		Pattern:   p.String(),
+		Ranks:     r,
+		Truncated: stats.Truncated,
+	}
+	if !math.IsInf(stats.Margin, 1) {
+		rec.Margin = &stats.Margin
+	}
+	return rec
+}
+
+// A TelemetryAggregator merges telemetry logs into counts of the ranks
+// played in each slot, which can be used to construct a SampledEvaluator
+// that's trained against the engines that produced the logs. Records
+// with the same deal hash are counted once.
+type TelemetryAggregator struct {
+	counts [3][]int
+	seen   map[string]bool
+	Hands  int // How many distinct hands have been counted
+}
+
+// Add reads a log of JSON telemetry records, one per line.
+func (ta *TelemetryAggregator) Add(r io.Reader) error {
+	if ta.seen == nil {
+		ta.seen = map[string]bool{}
+		for i := range ta.counts {
+			ta.counts[i] = make([]int, poker.ScoreMax+1)
+		}
+	}
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		var rec TelemetryRecord
+		if err := json.Unmarshal(s.Bytes(), &rec); err != nil {
+			return fmt.Errorf("line %d: %s", line, err)
+		}
+		for i, e := range rec.Ranks {
+			if !Reachable(i, e) {
+				return fmt.Errorf("line %d: rank %d isn't possible in slot %d", line, e, i)
+			}
+		}
+		if ta.seen[rec.Deal] {
+			continue
+		}
+		ta.seen[rec.Deal] = true
+		for i, e := range rec.Ranks {
+			ta.counts[i][e]++
+		}
+		ta.Hands++
+	}
+	return s.Err()
+}
+
+// Evaluator returns a SampledEvaluator from the counts of the
+// hands aggregated so far.
+func (ta *TelemetryAggregator) Evaluator() (*SampledEvaluator, error) {
+	if ta.Hands == 0 {
+		return nil, fmt.Errorf("no telemetry has been aggregated")
+	}
+	return NewSampledEvaluatorFromCounts(ta.counts)
+}
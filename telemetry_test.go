@@ -0,0 +1,52 @@
+package cpoker
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestTelemetry(t *testing.T) {
+	c := randomDeal(rand.New(rand.NewSource(9)))
+	deal := "deal " + strings.Join(cardNames(c), " ")
+	var telemetry bytes.Buffer
+	e := &Engine{Name: "test", Evaluators: map[string]HandEvaluator{"default": MaxProdEvaluator{}}, Telemetry: &telemetry}
+	// The same deal twice, in a different order the second time.
+	rev := append([]string{}, cardNames(c)...)
+	for i, j := 0, len(rev)-1; i < j; i, j = i+1, j-1 {
+		rev[i], rev[j] = rev[j], rev[i]
+	}
+	in := strings.NewReader(EngineProtocol + "\n" + deal + "\ndeal " + strings.Join(rev, " ") + "\nquit\n")
+	if err := e.Serve(in, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(telemetry.String(), cardNames(c[:1])[0]) {
+		t.Errorf("telemetry %q contains a card name", telemetry.String())
+	}
+	// The margin is the one found when playing the hand.
+	var rec TelemetryRecord
+	if err := json.Unmarshal(bytes.SplitN(telemetry.Bytes(), []byte("\n"), 2)[0], &rec); err != nil {
+		t.Fatal(err)
+	}
+	_, stats, _ := PlayWithBudget(c, MaxProdEvaluator{}, PlayBudget{})
+	if rec.Margin == nil || *rec.Margin != stats.Margin || rec.Truncated {
+		t.Errorf("got telemetry %+v, want margin %f", rec, stats.Margin)
+	}
+	// Deals are hashed with the engine's key.
+	if rec.Deal != DealHash(e.TelemetryKey, c) || rec.Deal == DealHash([]byte("other"), c) {
+		t.Errorf("deal hash %s doesn't depend on the key", rec.Deal)
+	}
+	var ta TelemetryAggregator
+	if err := ta.Add(&telemetry); err != nil {
+		t.Fatal(err)
+	}
+	if ta.Hands != 1 {
+		t.Errorf("aggregated %d hands, want 1", ta.Hands)
+	}
+	if _, err := ta.Evaluator(); err != nil {
+		t.Errorf("Evaluator failed: %s", err)
+	}
+}
@@ -14,20 +14,48 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/paulhankin/cpoker"
 )
 
 var (
 	fromFile       = flag.String("from", "", "file to read weights from")
-	toFile         = flag.String("to", "", "file to write trained weights to")
+	toFile         = flag.String("to", "", "file to write trained weights to; if it ends in .cpk, a bundle with the training scoring is written")
 	trainN         = flag.Int("hands", 0, "how many hands to train on")
 	trainCycles    = flag.Int("train_cycles", 1, "how many training iterations to perform")
+	probeDeals     = flag.Int("probe_deals", 200, "how many fixed deals to replay after each training cycle to measure how much the strategy changes")
 	evalSamples    = flag.Int("eval_samples", 10000, "how many hands to use to produce the optimal opponent")
 	evalHands      = flag.Int("eval_hands", 0, "how many hands to evaluate the trained evaluator on")
 	evalSep        = flag.Bool("eval_separable", true, "consider front/middle/back as independent when training the opponent")
 	evalRollAll    = flag.Bool("eval_rollall", false, "rollout every hand separately")
+	evalMinCount   = flag.Int("eval_min_count", 0, "if positive, keep sampling hands for the optimal opponent until most of them (see -eval_coverage) have ranks seen this many times (up to 10 times -eval_samples)")
+	evalCoverage   = flag.Float64("eval_coverage", 0.99, "with -eval_min_count, the fraction of sampled hands in each slot whose ranks must be seen that many times")
 	evalPrintEvery = flag.Int("eval_printn", 100, "show running summaries for eval every this many hands")
+	evalQuiet      = flag.Bool("eval_quiet", false, "don't print running summaries for eval, though they're still recorded in the metrics")
+	evalAdjust     = flag.Int("eval_adjust", 0, "if positive, also report EV-adjusted results, valuing each hand against this many sampled opponent hands")
+	evalStake      = flag.Float64("eval_stake", 0, "if non-zero, also report results in money, with each point worth this much")
+	evalRake       = flag.Float64("eval_rake", 0, "fraction of each hand's winnings taken as rake (with -eval_stake)")
+	evalRakeCap    = flag.Float64("eval_rake_cap", 0, "the largest rake taken from a single hand, or 0 for no cap (with -eval_stake)")
+	trainScoring   = flag.String("train_scoring", "2-4", "the scoring the evaluator is trained to play well under: "+strings.Join(cpoker.ScoringNames(), ", "))
+	evalScoring    = flag.String("eval_scoring", "2-4", "how to score hands in the evaluation: "+strings.Join(cpoker.ScoringNames(), ", "))
+	probeDiffs     = flag.String("probe_diffs", "", "if set, write a report of the probe deals played differently after each training cycle to this file")
+	evalRecords    = flag.String("eval_records", "", "if set, write a record of every hand of the evaluation to this file, in Arrow IPC stream format")
+	augmentSuits   = flag.Bool("augment_suits", false, "with -eval_records, also write each hand with the suits relabeled in every distinct way (the copies aren't independent samples)")
+	metricsFile    = flag.String("metrics", "", "if set, append training and evaluation metrics to this file (see the dash binary)")
+	runName        = flag.String("run", "train", "the name of this run in the metrics and experiments files")
+	experiments    = flag.String("experiments", "", "if set, append a record of this run's parameters, seed and outputs to this file")
+	seed           = flag.Int64("seed", 0, "the random seed, or 0 to choose one from the time")
+	outSpec        = flag.String("out", "", "if set, write a record of each training cycle, evaluation hand, and the evaluation result to this output, which looks like jsonl://path")
+	checkpoints    = flag.Int("checkpoints", 0, "if positive, save the evaluator after each training cycle to -to with a .cycle-N suffix, keeping this many of the latest, and the best so far with a .best suffix")
+	benchHands     = flag.Int("benchmark_hands", 1000, "with -checkpoints, how many deals to play against the benchmark to choose the best checkpoint")
+	benchFile      = flag.String("benchmark", "", "with -checkpoints, the file of the evaluator to benchmark against, or empty for the heuristic evaluator")
+	start          = flag.String("start", "heuristic", "heuristic/maxprod : the evaluator to start from if -from isn't set")
+	chainFile      = flag.String("chain", "", "if set, save the evaluator from every training cycle to this file, so the average strategy can be reconstructed")
+	chainOpponent  = flag.Bool("chain_opponent", false, "train each cycle against the average strategy of the cycles so far (fictitious play), rather than averaging win probabilities with the latest evaluator")
 )
 
 func main() {
@@ -38,17 +66,160 @@ func main() {
 	if *evalHands > 0 && *evalSamples <= 0 {
 		log.Fatalln("eval_samples must be positive if an evaluation is asked for")
 	}
-	var hero cpoker.HandEvaluator = cpoker.MaxProdEvaluator{} // Default is simple rank-based evaluator.
+	scoring, err := cpoker.ScoringByName(*evalScoring)
+	if err != nil {
+		log.Fatalf("bad -eval_scoring: %s", err)
+	}
+	tScoring, err := cpoker.ScoringByName(*trainScoring)
+	if err != nil {
+		log.Fatalf("bad -train_scoring: %s", err)
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	// Training and evaluation deal from rnd, so a run can be repeated
+	// with its seed.
+	rnd := rand.New(rand.NewSource(*seed))
+	ex := cpoker.NewExperiment(*runName, flag.CommandLine)
+	ex.Seeds["seed"] = *seed
+	finish := func() {
+		if *experiments == "" {
+			return
+		}
+		if err := ex.Append(*experiments); err != nil {
+			log.Fatalf("failed to write experiment: %s", err)
+		}
+	}
+	var out *cpoker.RecordWriter
+	if *outSpec != "" {
+		if out, err = cpoker.OpenOutput(*outSpec); err != nil {
+			log.Fatalf("failed to open -out: %s", err)
+		}
+		defer out.Close()
+	}
+	writeRecord := func(typ string, v interface{}) {
+		if err := out.Write(typ, v); err != nil {
+			log.Fatalf("failed to write output: %s", err)
+		}
+	}
+	opts := cpoker.CompareOptions{Scoring: scoring, AdjustSamples: *evalAdjust, Rand: rnd}
+	if !*evalQuiet {
+		opts.Printer = cpoker.TextProgressPrinter{W: os.Stdout}
+	}
+	if *evalStake != 0 {
+		opts.Stake = &cpoker.Stake{PerPoint: *evalStake, Rake: *evalRake, RakeCap: *evalRakeCap}
+	}
+	record := func(cpoker.MetricRecord) {}
+	if *metricsFile != "" {
+		mw, err := cpoker.OpenMetrics(*metricsFile)
+		if err != nil {
+			log.Fatalf("failed to open metrics: %s", err)
+		}
+		defer mw.Close()
+		record = func(r cpoker.MetricRecord) {
+			r.Run = *runName
+			if err := mw.Write(r); err != nil {
+				log.Printf("failed to write metrics: %s", err)
+			}
+		}
+		opts.Progress = func(_ int, c cpoker.Comparison) {
+			record(cpoker.MetricRecord{Metric: "ev", Step: c.Played, Value: c.EVPerHand, StdErr: c.StdErr})
+		}
+	}
+	var hero cpoker.HandEvaluator
+	switch *start {
+	case "heuristic":
+		hero = cpoker.DefaultHeuristic
+	case "maxprod":
+		hero = cpoker.MaxProdEvaluator{}
+	default:
+		log.Fatalf("Unknown value for flag -start: <%s>", *start)
+	}
 	if *fromFile != "" {
-		var err error
-		if hero, err = cpoker.LoadSampledEvaluator(*fromFile); err != nil {
+		se, err := cpoker.LoadEvaluatorFile(*fromFile)
+		if err != nil {
 			log.Fatalf("failed to load evaluator: %s", err)
 		}
+		se.Scoring = tScoring
+		hero = se
+	}
+	if *checkpoints > 0 && *toFile == "" {
+		log.Fatalln("-checkpoints needs -to")
 	}
 	if *trainN > 0 {
+		probes := cpoker.NewProbeSet(rand.New(rand.NewSource(1)), *probeDeals)
+		cp := &cpoker.Checkpoints{Filename: *toFile, Keep: *checkpoints}
+		var bench cpoker.HandEvaluator = cpoker.DefaultHeuristic
+		if *benchFile != "" {
+			if bench, err = cpoker.LoadEvaluatorFile(*benchFile); err != nil {
+				log.Fatalf("failed to load benchmark: %s", err)
+			}
+		}
+		var diffs *os.File
+		if *probeDiffs != "" {
+			if diffs, err = os.Create(*probeDiffs); err != nil {
+				log.Fatalf("failed to create probe diffs: %s", err)
+			}
+			defer diffs.Close()
+		}
+		probes.Update(hero)
+		chain := &cpoker.OpponentChain{Scoring: tScoring}
 		for i := 0; i < *trainCycles; i++ {
 			log.Printf("Training cycle: %d/%d\n", i+1, *trainCycles)
-			hero = cpoker.NewTrainedSampledEvaluator(hero, *trainN)
+			opp := hero
+			if *chainOpponent && len(chain.Members) > 0 {
+				opp = chain
+			}
+			trained, err := cpoker.TrainSampledEvaluator(opp, *trainN, tScoring, rnd)
+			if err != nil {
+				log.Fatalf("failed to train evaluator: %s", err)
+			}
+			hero = trained
+			chain.Add(trained)
+			if *chainFile != "" {
+				if err := chain.Save(*chainFile); err != nil {
+					log.Fatalf("failed to save chain: %s", err)
+				}
+			}
+			changed := 0
+			if *probeDeals > 0 {
+				changes := probes.UpdateChanges(hero)
+				changed = len(changes)
+				log.Printf("%d/%d probe deals played differently\n", changed, *probeDeals)
+				if diffs != nil {
+					fmt.Fprintf(diffs, "cycle %d: %d/%d probe deals played differently\n", i+1, changed, *probeDeals)
+					if err := cpoker.WriteChanges(diffs, changes); err != nil {
+						log.Fatalf("failed to write probe diffs: %s", err)
+					}
+				}
+				record(cpoker.MetricRecord{Metric: "probe_changes", Step: i + 1, Value: float64(changed) / float64(*probeDeals)})
+			}
+			var benchEV float64
+			if *checkpoints > 0 {
+				// Every cycle is benchmarked on the same deals.
+				c := cpoker.CompareEvaluatorsWithOptions(hero, bench, *benchHands, 0, cpoker.CompareOptions{Scoring: scoring, Rand: rand.New(rand.NewSource(cpoker.BenchmarkSeed))})
+				benchEV = c.EVPerHand
+				record(cpoker.MetricRecord{Metric: "benchmark_ev", Step: i + 1, Value: c.EVPerHand, StdErr: c.StdErr})
+				best, err := cp.Save(hero.(*cpoker.SampledEvaluator), i+1, benchEV)
+				if err != nil {
+					log.Fatalf("failed to save checkpoint: %s", err)
+				}
+				log.Printf("%.4f +/- %.4f per hand against the benchmark, best is cycle %d\n", c.EVPerHand, c.StdErr, cp.BestCycle)
+				if best {
+					ex.Results = map[string]string{"best_cycle": fmt.Sprint(cp.BestCycle), "best_benchmark_ev": fmt.Sprint(cp.BestScore)}
+				}
+			}
+			writeRecord("cycle", struct {
+				Cycle        int     `json:"cycle"`
+				ProbeChanges int     `json:"probe_changes"`
+				ProbeDeals   int     `json:"probe_deals"`
+				BenchmarkEV  float64 `json:"benchmark_ev,omitempty"`
+			}{i + 1, changed, *probeDeals, benchEV})
+		}
+		if cp.BestCycle != 0 {
+			if err := ex.AddArtifact(cp.BestFilename()); err != nil {
+				log.Fatalf("failed to hash evaluator: %s", err)
+			}
 		}
 	}
 	if *toFile != "" {
@@ -56,16 +227,72 @@ func main() {
 		if !ok {
 			log.Fatalln("can't save initial evaluator")
 		}
-		if err := se.Save(*toFile); err != nil {
+		save := se.Save
+		if strings.HasSuffix(*toFile, ".cpk") {
+			b := &cpoker.Bundle{
+				Evaluator: se,
+				Scoring:   tScoring,
+				Variant:   cpoker.VariantConfig{Name: "standard"},
+				Metadata:  map[string]string{"run": *runName},
+			}
+			save = b.Save
+		}
+		if err := save(*toFile); err != nil {
 			log.Fatalf("failed to save evaluator: %s", err)
 		}
+		if err := ex.AddArtifact(*toFile); err != nil {
+			log.Fatalf("failed to hash evaluator: %s", err)
+		}
 	}
 	if *evalHands == 0 {
+		finish()
 		return
 	}
-	opp := &cpoker.RolloutEvaluator{PreRollout: !*evalRollAll, Separable: *evalSep, Opponent: hero, N: *evalSamples}
+	opp := &cpoker.RolloutEvaluator{PreRollout: !*evalRollAll, Separable: *evalSep, Opponent: hero, N: *evalSamples, MinCount: *evalMinCount, Coverage: *evalCoverage, Scoring: scoring}
 	log.Println("training optimal opponent...")
 	opp.Init()
 	log.Println("running comparison...")
-	fmt.Printf("\n%+v", cpoker.CompareEvaluators(hero, opp, *evalHands, *evalPrintEvery))
+	var records []cpoker.HandRecord
+	if *evalRecords != "" || out != nil {
+		opts.OnHand = func(r cpoker.HandRecord) {
+			if *evalRecords != "" {
+				records = append(records, r)
+			}
+			writeRecord("hand", r)
+		}
+	}
+	result := cpoker.CompareEvaluatorsWithOptions(hero, opp, *evalHands, *evalPrintEvery, opts)
+	if *evalRecords != "" {
+		f, err := os.Create(*evalRecords)
+		if err != nil {
+			log.Fatalf("failed to create records file: %s", err)
+		}
+		if *augmentSuits {
+			records = cpoker.AugmentHandRecords(records)
+		}
+		if err := cpoker.WriteHandRecordsArrow(f, records); err != nil {
+			log.Fatalf("failed to write records: %s", err)
+		}
+		if err := f.Close(); err != nil {
+			log.Fatalf("failed to write records: %s", err)
+		}
+		if err := ex.AddArtifact(*evalRecords); err != nil {
+			log.Fatalf("failed to hash records: %s", err)
+		}
+	}
+	record(cpoker.MetricRecord{Metric: "ev", Step: result.Played, Value: result.EVPerHand, StdErr: result.StdErr})
+	fmt.Printf("\n%+v", result)
+	writeRecord("result", result)
+	if ex.Results == nil {
+		ex.Results = map[string]string{}
+	}
+	ex.Results["played"] = fmt.Sprint(result.Played)
+	ex.Results["ev"] = fmt.Sprint(result.EVPerHand)
+	ex.Results["ev_stderr"] = fmt.Sprint(result.StdErr)
+	if *evalAdjust > 0 {
+		record(cpoker.MetricRecord{Metric: "adjusted_ev", Step: result.Played, Value: result.AdjustedEV, StdErr: result.AdjustedStdErr})
+		ex.Results["adjusted_ev"] = fmt.Sprint(result.AdjustedEV)
+		ex.Results["adjusted_ev_stderr"] = fmt.Sprint(result.AdjustedStdErr)
+	}
+	finish()
 }
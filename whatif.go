@@ -0,0 +1,170 @@
+package cpoker
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/paulhankin/poker/v2/poker"
+)
+
+// A WhatIf is a session for editing the arrangement of one deal, as
+// the backend of a trainer where the player moves cards between slots
+// and sees what each change is worth. The evaluator for the deal is
+// made once (which for a RolloutEvaluator means one rollout), and the
+// value of every arrangement seen is kept, so each edit is cheap.
+type WhatIf struct {
+	hand   Hand
+	ranks  [3]int16
+	ev     func(f, m, b int16) float64
+	points bool
+	wins   *SampledEvaluator // nil if the evaluator doesn't have win probabilities
+	values map[[3]int16]float64
+	best   Hand
+	last   float64 // the value before the latest edit
+}
+
+// A WhatIfResult is the value of an arrangement in a WhatIf session.
+type WhatIfResult struct {
+	Hand   Hand
+	Fouled bool
+	Value  float64 // The value of the hand, or -Inf if it's fouled
+	Change float64 // How much the latest edit changed the value
+	Regret float64 // How much less the hand is worth than the best arrangement
+
+	// Win is the probability that each of the front, middle and back
+	// wins, if the evaluator has win probabilities.
+	Win [3]float64
+
+	// Points is set if values are expected points, rather than in the
+	// evaluator's own units.
+	Points bool
+}
+
+func (r *WhatIfResult) String() string {
+	if r.Fouled {
+		return fmt.Sprintf("%s: fouls", &r.Hand)
+	}
+	unit := ""
+	if r.Points {
+		unit = " points"
+	}
+	return fmt.Sprintf("%s: %.3f%s (%+.3f), %.3f less than the best", &r.Hand, r.Value, unit, r.Change, r.Regret)
+}
+
+// NewWhatIf starts a session editing the draft arrangement, which must
+// be of 13 distinct cards. Hands are valued with he; if it's an
+// EvaluatorPoints, they're valued in expected points scored with s (or
+// Scoring2to4 if s is nil).
+func NewWhatIf(draft *Hand, he HandEvaluator, s *Scoring) (*WhatIf, error) {
+	c := draft.cards()
+	if err := checkDeal(c); err != nil {
+		return nil, err
+	}
+	w := &WhatIf{hand: *draft, ranks: draft.ranks(), ev: he.Evaluator(c), values: map[[3]int16]float64{}}
+	if ep, ok := he.(EvaluatorPoints); ok {
+		if s == nil {
+			s = Scoring2to4
+		}
+		w.ev, w.points = ep.Points(c, s), true
+	}
+	switch e := he.(type) {
+	case *SampledEvaluator:
+		w.wins = e
+	case *ConditionedEvaluator:
+		w.wins = e.Class(DealClass(c))
+	}
+	w.best, _ = Play(c, fixedEvaluator(w.ev))
+	w.last = w.value(w.ranks)
+	return w, nil
+}
+
+// value returns the value of a hand with the given ranks.
+func (w *WhatIf) value(r [3]int16) float64 {
+	if r[0] > r[1] || r[1] > r[2] {
+		return math.Inf(-1)
+	}
+	v, ok := w.values[r]
+	if !ok {
+		v = w.ev(r[0], r[1], r[2])
+		w.values[r] = v
+	}
+	return v
+}
+
+// Result returns the value of the current arrangement.
+func (w *WhatIf) Result() WhatIfResult {
+	r := WhatIfResult{Hand: w.hand, Value: w.value(w.ranks), Points: w.points}
+	r.Fouled = math.IsInf(r.Value, -1)
+	r.Change = r.Value - w.last
+	if r.Fouled && math.IsInf(w.last, -1) {
+		r.Change = 0
+	}
+	br := w.best.ranks()
+	r.Regret = w.value(br) - r.Value
+	if w.wins != nil {
+		for i := range r.Win {
+			r.Win[i] = w.wins.WinProbabilities(i)[w.ranks[i]]
+		}
+	}
+	return r
+}
+
+// Best returns the value of the best arrangement of the cards.
+func (w *WhatIf) Best() WhatIfResult {
+	r := WhatIfResult{Hand: w.best, Points: w.points}
+	br := w.best.ranks()
+	r.Value = w.value(br)
+	if w.wins != nil {
+		for i := range r.Win {
+			r.Win[i] = w.wins.WinProbabilities(i)[br[i]]
+		}
+	}
+	return r
+}
+
+// slots returns the cards of each slot of the current arrangement.
+func (w *WhatIf) slots() [3][]poker.Card {
+	return [3][]poker.Card{w.hand.Front[:], w.hand.Middle[:], w.hand.Back[:]}
+}
+
+// find returns the slot of a card in the current arrangement, and its
+// position in the slot.
+func (w *WhatIf) find(card poker.Card) (slot, pos int, ok bool) {
+	for i, s := range w.slots() {
+		for j, c := range s {
+			if c == card {
+				return i, j, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// Swap swaps two cards of the arrangement, and returns the value of
+// the new arrangement. Swapping cards in the same slot changes nothing.
+func (w *WhatIf) Swap(a, b poker.Card) (WhatIfResult, error) {
+	ia, ja, okA := w.find(a)
+	ib, jb, okB := w.find(b)
+	if !okA || !okB {
+		return WhatIfResult{}, fmt.Errorf("can't swap %s and %s: both must be in the hand %s", a, b, &w.hand)
+	}
+	w.last = w.value(w.ranks)
+	slots := w.slots()
+	slots[ia][ja], slots[ib][jb] = b, a
+	// Only the slots that changed are ranked again.
+	w.ranks[ia], w.ranks[ib] = eval(slots[ia]), eval(slots[ib])
+	return w.Result(), nil
+}
+
+// Set replaces the arrangement with another of the same cards, which
+// may be fouled, and returns its value.
+func (w *WhatIf) Set(h *Hand) (WhatIfResult, error) {
+	got, ok := NewCardSet(h.cards())
+	want, _ := NewCardSet(w.hand.cards())
+	if !ok || got != want {
+		return WhatIfResult{}, fmt.Errorf("hand %s isn't an arrangement of %s", h, &w.hand)
+	}
+	w.last = w.value(w.ranks)
+	w.hand, w.ranks = *h, h.ranks()
+	return w.Result(), nil
+}
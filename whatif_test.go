@@ -0,0 +1,53 @@
+package cpoker
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWhatIf(t *testing.T) {
+	se := smallSampledEvaluator(t, 300)
+	draft := mustHand(t, "C4S5H6", "C8D8H9SJCQ", "D3D4D5D6C7")
+	w, err := NewWhatIf(draft, se, ScoringHK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := w.Result()
+	if start.Fouled || !start.Points || start.Change != 0 || start.Regret < 0 {
+		t.Errorf("the draft is worth %v", &start)
+	}
+	for i, r := range draft.ranks() {
+		if start.Win[i] != se.WinProbabilities(i)[r] {
+			t.Errorf("slot %d wins with probability %f, want %f", i, start.Win[i], se.WinProbabilities(i)[r])
+		}
+	}
+	best := w.Best()
+	if want, _, _ := PlayForPoints(draft.cards(), se, ScoringHK); best.Hand.Key() != want.Key() {
+		t.Errorf("the best hand is %v, want %v", &best.Hand, &want)
+	}
+	// Moving the straight's seven to the front fouls, and moving it
+	// back undoes the change.
+	c := mustCards(t, "C4C7")
+	r, err := w.Swap(c[0], c[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Fouled || !math.IsInf(r.Change, -1) {
+		t.Errorf("after swapping %s and %s, the hand is worth %v", c[0], c[1], &r)
+	}
+	if r, err = w.Swap(c[1], c[0]); err != nil || r.Value != start.Value || r.Hand != *draft {
+		t.Errorf("after swapping back, the hand is worth %v, want %v", &r, &start)
+	}
+	if r, err = w.Set(&best.Hand); err != nil || r.Regret != 0 || r.Change != best.Value-start.Value {
+		t.Errorf("after setting the best hand, it's worth %v (error %v)", &r, err)
+	}
+	if _, err := w.Swap(c[0], mustCards(t, "SA")[0]); err == nil {
+		t.Errorf("swapped in a card that isn't in the hand")
+	}
+	if _, err := w.Set(mustHand(t, "C4S5H6", "C8D8H9SJCQ", "D3D4D5D6SA")); err == nil {
+		t.Errorf("set a hand with other cards")
+	}
+	if _, err := NewWhatIf(mustHand(t, "C4S5H6", "C8D8H9SJCQ", "D3D4D5D6C4"), se, nil); err == nil {
+		t.Errorf("started editing a hand with a repeated card")
+	}
+}